@@ -0,0 +1,43 @@
+package httpretty
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// printWriteAborted prints if writing the response failed due to the server write timeout (see http.Server.WriteTimeout)
+// or the client disconnecting, and how much of the response was written before.
+//
+// Writes are buffered by the server, so a failure is only noticed once the handler writes more than fits the buffer,
+// or after the handler returns, when it cannot be logged anymore.
+func (p *printer) printWriteAborted(req *http.Request, rec *responseRecorder) {
+	err := rec.writeErr
+
+	if err == nil {
+		return
+	}
+
+	written := formatBytes(rec.written)
+
+	var ne net.Error
+
+	if errors.As(err, &ne) && ne.Timeout() {
+		timeout := "write timeout"
+
+		if srv, ok := req.Context().Value(http.ServerContextKey).(*http.Server); ok && srv.WriteTimeout > 0 {
+			timeout += " (" + srv.WriteTimeout.String() + ")"
+		}
+
+		p.printf("* %s\n", p.format(color.FgRed,
+			"response aborted by the server %s after writing %s: %v", timeout, written, err))
+		return
+	}
+
+	if req.Context().Err() != nil {
+		p.printf("* %s\n", p.format(color.FgRed,
+			"client disconnected after %s of the response were written: %v", written, err))
+	}
+}
@@ -0,0 +1,89 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeUntilError writes chunks of the response until writing fails.
+func writeUntilError(w http.ResponseWriter) {
+	chunk := bytes.Repeat([]byte("a"), 32<<10)
+
+	for i := 0; i < 1000; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+	}
+}
+
+func TestIncomingWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		writeUntilError(w)
+	})), 1)
+
+	ts := httptest.NewUnstartedServer(is)
+	ts.Config.WriteTimeout = 50 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	if resp, err := newServerClient().Get(ts.URL); err == nil {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	is.Wait()
+
+	want := regexp.MustCompile(`^\* response aborted by the server write timeout \(50ms\) after writing [0-9.]+ [KM]?i?B: .*i/o timeout\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged output = %q, wanted it to match %q", got, want)
+	}
+}
+
+func TestIncomingClientDisconnected(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		writeUntilError(w)
+	})), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	client := newServerClient()
+	client.Timeout = 50 * time.Millisecond
+
+	if _, err := client.Get(ts.URL); err == nil {
+		t.Errorf("expected request to time out")
+	}
+
+	is.Wait()
+
+	if got, want := buf.String(), "* client disconnected after "; !strings.HasPrefix(got, want) {
+		t.Errorf("logged output = %q, wanted prefix %q", got, want)
+	}
+}
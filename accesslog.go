@@ -0,0 +1,212 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// CommonLogFormat is the access log template for the Common Log Format used by Apache and NGINX.
+// See https://httpd.apache.org/docs/current/logs.html#common
+const CommonLogFormat = `{{dash .RemoteHost}} - {{dash .User}} [{{.Time.Format "02/Jan/2006:15:04:05 -0700"}}] "{{.Method}} {{.URI}} {{.Proto}}" {{.Status}} {{dash .ResponseBytes}}`
+
+// CombinedLogFormat is the access log template for the Combined Log Format used by Apache and NGINX,
+// which adds the referer and user agent to the Common Log Format.
+// See https://httpd.apache.org/docs/current/logs.html#combined
+const CombinedLogFormat = CommonLogFormat + ` "{{dash .Referer}}" "{{dash .UserAgent}}"`
+
+// NewAccessLogTemplate parses text as a template for Logger.AccessLogTemplate.
+// The template is executed with an AccessLogEntry, and can use the dash function,
+// which prints "-" for empty strings and unknown sizes.
+func NewAccessLogTemplate(text string) (*template.Template, error) {
+	return template.New("access log").Funcs(template.FuncMap{
+		"dash": dash,
+	}).Parse(text)
+}
+
+// dash replaces empty values with "-", as access logs usually do.
+func dash(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		if vv == "" {
+			return "-"
+		}
+
+		return vv
+	case int64:
+		if vv < 0 {
+			return "-"
+		}
+
+		return strconv.FormatInt(vv, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// AccessLogEntry describes an exchange for a line of the access log. See Logger.AccessLogTemplate.
+type AccessLogEntry struct {
+	// RemoteAddr of the client that sent the request, and its RemoteHost.
+	// They are only known by the server.
+	RemoteAddr string
+	RemoteHost string
+
+	// User authenticated, set with SetPrincipal, or else with basic authentication.
+	User string
+
+	// Principal set with SetPrincipal.
+	Principal string
+
+	// Time the request began.
+	Time time.Time
+
+	// Sequence number of the exchange, if Logger.SequenceNumbers is set.
+	Sequence uint64
+
+	Method string
+	Host   string
+	Path   string
+	URI    string
+	Proto  string
+
+	Referer   string
+	UserAgent string
+
+	// Route and Fields set with WithRoute and WithFields.
+	Route  string
+	Fields map[string]string
+
+	// Status code of the response, or 0 if there was no response.
+	Status int
+
+	Duration time.Duration
+
+	// RequestBytes and ResponseBytes are the sizes of the bodies, or -1 if unknown.
+	RequestBytes  int64
+	ResponseBytes int64
+
+	// Err is the error that interrupted the exchange, if any.
+	Err string
+}
+
+// newAccessLogEntry with the fields describing the request.
+func (p *printer) newAccessLogEntry(req *http.Request, start time.Time) *AccessLogEntry {
+	host := req.Host
+
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	uri := req.RequestURI
+
+	if uri == "" {
+		uri = req.URL.RequestURI()
+	}
+
+	e := &AccessLogEntry{
+		RemoteAddr: req.RemoteAddr,
+		RemoteHost: req.RemoteAddr,
+		Time:       start,
+		Method:     req.Method,
+		Host:       host,
+		Path:       p.maskPath(req.URL.Path),
+		URI:        p.maskURI(uri),
+		Proto:      req.Proto,
+		Referer:    req.Referer(),
+		UserAgent:  req.UserAgent(),
+		Route:      p.route,
+		Fields:     p.fields,
+		Sequence:   p.sequence,
+
+		RequestBytes:  -1,
+		ResponseBytes: -1,
+	}
+
+	if h, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		e.RemoteHost = h
+	}
+
+	if user, _, ok := req.BasicAuth(); ok {
+		e.User = user
+	}
+
+	if a, ok := req.Context().Value(contextAnnotations{}).(*annotations); ok {
+		e.Principal = a.getPrincipal()
+	}
+
+	if e.Principal != "" {
+		e.User = e.Principal
+	}
+
+	return e
+}
+
+// logfmt line of the entry.
+func (e *AccessLogEntry) logfmt() string {
+	var line logfmtLine
+
+	if e.Sequence != 0 {
+		line.add("seq", strconv.FormatUint(e.Sequence, 10))
+	}
+
+	line.add("method", e.Method)
+	line.add("host", e.Host)
+	line.add("path", e.Path)
+
+	if e.Route != "" {
+		line.add("route", e.Route)
+	}
+
+	for _, key := range sortedKeys(e.Fields) {
+		line.add(key, e.Fields[key])
+	}
+
+	if e.Principal != "" {
+		line.add("principal", e.Principal)
+	}
+
+	if e.Status != 0 {
+		line.add("status", strconv.Itoa(e.Status))
+	}
+
+	line.add("dur", e.Duration.String())
+
+	if e.RequestBytes >= 0 {
+		line.add("req_bytes", strconv.FormatInt(e.RequestBytes, 10))
+	}
+
+	if e.ResponseBytes >= 0 {
+		line.add("resp_bytes", strconv.FormatInt(e.ResponseBytes, 10))
+	}
+
+	if e.Err != "" {
+		line.add("err", e.Err)
+	}
+
+	return line.String()
+}
+
+// printAccessLog prints the line of the entry, using Logger.AccessLogTemplate if it is set.
+func (p *printer) printAccessLog(e *AccessLogEntry) {
+	tmpl := p.logger.AccessLogTemplate
+
+	if tmpl == nil {
+		p.print(e.logfmt())
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, e); err != nil {
+		p.printf("* cannot execute access log template: %v\n", err)
+		return
+	}
+
+	// keep it to a single line.
+	p.println(strings.TrimRight(strings.Replace(buf.String(), "\n", " ", -1), " "))
+}
@@ -0,0 +1,105 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIncomingAccessLogTemplate(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := NewAccessLogTemplate(CombinedLogFormat)
+
+	if err != nil {
+		t.Fatalf("cannot parse template: %v", err)
+	}
+
+	logger := &Logger{
+		Logfmt:            true,
+		AccessLogTemplate: tmpl,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello, world!"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users?token=secret&page=2", strings.NewReader("hello"))
+	req.SetBasicAuth("gopher", "password")
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := regexp.MustCompile(`^192\.0\.2\.1 - gopher \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /users\?token=█+&page=2 HTTP/1\.1" 201 13 "-" "curl/7\.64\.1"\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
+
+func TestOutgoingAccessLogTemplate(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	tmpl, err := NewAccessLogTemplate(`{{.Method}} {{.Path}} {{.Status}} req={{dash .RequestBytes}} resp={{dash .ResponseBytes}} remote={{dash .RemoteAddr}}
+`)
+
+	if err != nil {
+		t.Fatalf("cannot parse template: %v", err)
+	}
+
+	logger := &Logger{
+		Logfmt:            true,
+		AccessLogTemplate: tmpl,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Get(ts.URL + "/users"); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	if got, want := buf.String(), "GET /users 200 req=0 resp=13 remote=-\n"; got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestAccessLogTemplateError(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := NewAccessLogTemplate(`{{.Missing}}`)
+
+	if err != nil {
+		t.Fatalf("cannot parse template: %v", err)
+	}
+
+	logger := &Logger{
+		Logfmt:            true,
+		AccessLogTemplate: tmpl,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := "* cannot execute access log template: template: access log:1:2: executing \"access log\" at <.Missing>: can't evaluate field Missing in type *httpretty.AccessLogEntry\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
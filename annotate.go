@@ -0,0 +1,116 @@
+package httpretty
+
+import (
+	"context"
+	"sync"
+)
+
+type contextAnnotations struct{}
+
+// annotations attached to an exchange.
+type annotations struct {
+	mu        sync.Mutex
+	keys      []string
+	m         map[string]string
+	principal string
+}
+
+// WithAnnotations returns a context that can receive annotations with Annotate.
+//
+// Server-side requests are annotatable already. On the client-side, use it on the request context
+// if you want to annotate the request before sending it, otherwise only inner transports
+// wrapped by Logger.RoundTripper can annotate it.
+func WithAnnotations(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextAnnotations{}).(*annotations); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, contextAnnotations{}, &annotations{})
+}
+
+// Annotate attaches a key/value pair to the exchange the context belongs to,
+// so you can tie decisions of your application to the HTTP traffic.
+// It is printed like "* note cache=miss". Annotating a key again replaces its value.
+//
+// Annotate does nothing if the context doesn't belong to an exchange (see WithAnnotations).
+// This function is concurrency safe.
+func Annotate(ctx context.Context, key, value string) {
+	a, ok := ctx.Value(contextAnnotations{}).(*annotations)
+
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.m == nil {
+		a.m = map[string]string{}
+	}
+
+	if _, ok := a.m[key]; !ok {
+		a.keys = append(a.keys, key)
+	}
+
+	a.m[key] = value
+}
+
+// SetPrincipal reports the principal authenticated by the handler for the exchange the context belongs to,
+// such as "user:42", tying requests to identities for audit logs. It is printed like "* principal: user:42"
+// after the request, and is also part of the Logfmt line and of the AccessLogEntry.
+//
+// SetPrincipal does nothing if the context doesn't belong to an exchange (see WithAnnotations).
+// This function is concurrency safe.
+func SetPrincipal(ctx context.Context, principal string) {
+	a, ok := ctx.Value(contextAnnotations{}).(*annotations)
+
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.principal = principal
+}
+
+// getPrincipal set with SetPrincipal.
+func (a *annotations) getPrincipal() string {
+	if a == nil {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.principal
+}
+
+// list of annotations, in the order they were first added.
+func (a *annotations) list() (keys, values []string) {
+	if a == nil {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, key := range a.keys {
+		keys = append(keys, key)
+		values = append(values, a.m[key])
+	}
+
+	return keys, values
+}
+
+func (p *printer) printAnnotations() {
+	keys, values := p.annotations.list()
+
+	for i, key := range keys {
+		p.printf("* note %s=%s\n", key, values[i])
+	}
+}
+
+func (p *printer) printPrincipal() {
+	if principal := p.annotations.getPrincipal(); principal != "" {
+		p.printf("* principal: %s\n", principal)
+	}
+}
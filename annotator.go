@@ -0,0 +1,38 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ResponseAnnotator computes lines to print under a response, such as a summary of its rate limit headers.
+type ResponseAnnotator func(resp *http.Response) []string
+
+// SetResponseAnnotator sets a function computing lines to print under each response,
+// after its headers, such as "rate limit: 14/100 remaining, resets in 32s".
+// Lines are printed prefixed with "* ". On the server-side, the response has its status code and headers only.
+// Pass nil to remove the annotator. This method is concurrency safe.
+func (l *Logger) SetResponseAnnotator(f ResponseAnnotator) {
+	l.updateConfig(func(c *config) {
+		c.annotator = f
+	})
+}
+
+// printResponseAnnotations prints the lines computed by the response annotator.
+func (p *printer) printResponseAnnotations(resp *http.Response) {
+	f := p.config.annotator
+
+	if f == nil {
+		return
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			p.printf("* panic while annotating response: %v\n", e)
+		}
+	}()
+
+	for _, line := range f(resp) {
+		p.printf("* %s\n", strings.TrimSuffix(strings.TrimPrefix(line, "* "), "\n"))
+	}
+}
@@ -0,0 +1,121 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func rateLimitAnnotator(resp *http.Response) []string {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+
+	if remaining == "" {
+		return nil
+	}
+
+	return []string{
+		fmt.Sprintf("rate limit: %s/%s remaining, resets in %ss", remaining,
+			resp.Header.Get("X-RateLimit-Limit"), resp.Header.Get("X-RateLimit-Reset")),
+	}
+}
+
+type rateLimitHandler struct{}
+
+func (h rateLimitHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-RateLimit-Limit", "100")
+	w.Header().Set("X-RateLimit-Remaining", "14")
+	w.Header().Set("X-RateLimit-Reset", "32")
+	w.Header()["Date"] = nil
+	fmt.Fprint(w, "Hello, world!")
+}
+
+func TestOutgoingResponseAnnotator(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(rateLimitHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+	}
+
+	logger.SetResponseAnnotator(rateLimitAnnotator)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, world!"))
+
+	want := `< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+< X-Ratelimit-Limit: 100
+< X-Ratelimit-Remaining: 14
+< X-Ratelimit-Reset: 32
+
+* rate limit: 14/100 remaining, resets in 32s
+Hello, world!
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingResponseAnnotator(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(rateLimitHandler{})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	if got := buf.String(); got != "" {
+		t.Errorf("logged HTTP request without annotator %q; want nothing", got)
+	}
+
+	logger.SetResponseAnnotator(func(resp *http.Response) []string {
+		return append(rateLimitAnnotator(resp), fmt.Sprintf("* status: %d", resp.StatusCode))
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	want := "* rate limit: 14/100 remaining, resets in 32s\n* status: 200\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+
+	buf.Reset()
+
+	logger.SetResponseAnnotator(func(resp *http.Response) []string {
+		panic("bad annotator")
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	want = "* panic while annotating response: bad annotator\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
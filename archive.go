@@ -0,0 +1,390 @@
+package httpretty
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Archive writes the exchanges of a process run into a single tar.gz archive,
+// convenient for attaching to bug reports.
+// Each exchange is saved as a directory containing a request.http and a response.http file
+// with the messages in their wire format. Use it with Logger.Archive.
+//
+// Headers and URLs are sanitized as they are in the log, unless Logger.SkipSanitize is set,
+// and bodies have the secrets of Logger.RedactSecrets redacted, so archives can be shared.
+// Bodies are read before being passed along, up to Logger.MaxRequestBody and Logger.MaxResponseBody,
+// or 4096 bytes for a body of unknown length if they aren't set; the messages of longer bodies are saved without them.
+type Archive struct {
+	// DeduplicateBodies saves the bodies on files of their own, request.body and response.body,
+	// next to the messages, saving identical bodies only once: the files of later exchanges
+	// are hard links to the first one. Use it for long captures dominated by repeated payloads.
+	// Set it before using the archive.
+	DeduplicateBodies bool
+
+	mu     sync.Mutex
+	gw     *gzip.Writer
+	tw     *tar.Writer
+	n      int
+	bodies map[[sha256.Size]byte]string // name of the first file of each body saved
+	err    error
+}
+
+// NewArchive creates an archive writing to w. Call Close to finish writing it.
+func NewArchive(w io.Writer) *Archive {
+	gw := gzip.NewWriter(w)
+
+	return &Archive{
+		gw: gw,
+		tw: tar.NewWriter(gw),
+	}
+}
+
+// Close finishes writing the archive. It doesn't close the underlying writer.
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+
+	if err := a.gw.Close(); err != nil {
+		return err
+	}
+
+	return a.err
+}
+
+// add an exchange to the archive.
+func (a *Archive) add(request, response []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.n++
+	dir := fmt.Sprintf("%04d", a.n)
+	now := time.Now()
+
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"request", request},
+		{"response", response},
+	} {
+		if file.data == nil {
+			continue
+		}
+
+		if err := a.writeMessage(dir+"/"+file.name, file.data, now); err != nil && a.err == nil {
+			a.err = err
+		}
+	}
+}
+
+// writeMessage in its wire format, saving the body on its own file if Archive.DeduplicateBodies is set.
+func (a *Archive) writeMessage(name string, data []byte, modTime time.Time) error {
+	var body []byte
+
+	if a.DeduplicateBodies {
+		if i := bytes.Index(data, []byte("\r\n\r\n")); i != -1 {
+			data, body = data[:i+4], data[i+4:]
+		}
+	}
+
+	if err := a.write(name+".http", data, modTime); err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if a.bodies == nil {
+		a.bodies = map[[sha256.Size]byte]string{}
+	}
+
+	sum := sha256.Sum256(body)
+
+	if first, ok := a.bodies[sum]; ok {
+		return a.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeLink,
+			Name:     name + ".body",
+			Linkname: first,
+			Mode:     0644,
+			ModTime:  modTime,
+		})
+	}
+
+	a.bodies[sum] = name + ".body"
+	return a.write(name+".body", body, modTime)
+}
+
+func (a *Archive) write(name string, data []byte, modTime time.Time) error {
+	if err := a.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+
+	_, err := a.tw.Write(data)
+	return err
+}
+
+// archiveRoundTripper saves the exchanges of a client to an archive.
+type archiveRoundTripper struct {
+	logger  *Logger
+	archive *Archive
+	next    http.RoundTripper
+}
+
+func (art archiveRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	er := art.logger.exchangeRedaction(req)
+
+	var body []byte
+	body, req.Body = archivedBody(er, req.Body, req.ContentLength, art.logger.MaxRequestBody)
+
+	// DumpRequestOut sends the request through a fake connection, so the context is dropped to avoid calling
+	// its traces, such as the ones detecting retries.
+	dumped := req.WithContext(context.Background())
+	dumped.URL = er.redactedURL(req.URL)
+	dumped.Header = er.header(req.Header)
+
+	if body != nil {
+		setArchivedBody(&dumped.Body, &dumped.ContentLength, body)
+	}
+
+	request, err := httputil.DumpRequestOut(dumped, body != nil)
+
+	if err != nil {
+		request = []byte(fmt.Sprintf("cannot dump request: %v\n", err))
+	}
+
+	resp, err := art.next.RoundTrip(req)
+
+	var response []byte
+
+	switch {
+	case err != nil:
+		response = []byte(fmt.Sprintf("error: %v\n", err))
+	case resp != nil:
+		dumped := *resp
+		dumped.Header = er.header(resp.Header)
+		body, resp.Body = archivedBody(er, resp.Body, resp.ContentLength, art.logger.MaxResponseBody)
+		dumped.Body = resp.Body
+
+		if body != nil {
+			setArchivedBody(&dumped.Body, &dumped.ContentLength, body)
+		}
+
+		if response, err = httputil.DumpResponse(&dumped, body != nil); err != nil {
+			response = []byte(fmt.Sprintf("cannot dump response: %v\n", err))
+			err = nil
+		}
+	}
+
+	art.archive.add(request, response)
+	return resp, err
+}
+
+// archivedBody reads a body for the archive, returning a copy to dump with its secrets redacted,
+// or nil if it is too long (see readBodyLimit), and the body to pass along.
+func archivedBody(er exchangeRedaction, body io.ReadCloser, length, max int64) (dumped []byte, newBody io.ReadCloser) {
+	if body == nil || body == http.NoBody {
+		return nil, body
+	}
+
+	b, ok, newBody := readBodyLimit(body, length, max)
+
+	if !ok {
+		return nil, newBody
+	}
+
+	return er.body(b), newBody
+}
+
+// setArchivedBody sets the body of a message to dump, with its length updated if it is known,
+// as redacting secrets might have changed it.
+func setArchivedBody(body *io.ReadCloser, length *int64, b []byte) {
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+
+	if *length > 0 {
+		*length = int64(len(b))
+	}
+}
+
+// archiveHandler saves the exchanges of a server to an archive.
+type archiveHandler struct {
+	logger  *Logger
+	archive *Archive
+	next    http.Handler
+}
+
+func (ah archiveHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	er := ah.logger.exchangeRedaction(req)
+	body, newBody := archivedBody(er, req.Body, req.ContentLength, ah.logger.MaxRequestBody)
+	req.Body = newBody
+
+	dumped := *req
+	dumped.URL = er.redactedURL(req.URL)
+	dumped.RequestURI = er.uri(req.RequestURI)
+	dumped.Header = er.header(req.Header)
+	dumped.Body = nil
+
+	if body != nil {
+		setArchivedBody(&dumped.Body, &dumped.ContentLength, body)
+	}
+
+	request, err := httputil.DumpRequest(&dumped, body != nil)
+
+	if err != nil {
+		request = []byte(fmt.Sprintf("cannot dump request: %v\n", err))
+	}
+
+	aw := &copyingResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+		max:            ah.logger.MaxResponseBody,
+	}
+
+	defer func() {
+		if aw.hijacked {
+			ah.archive.add(request, nil)
+			return
+		}
+
+		resp := &http.Response{
+			StatusCode:    aw.statusCode,
+			ProtoMajor:    req.ProtoMajor,
+			ProtoMinor:    req.ProtoMinor,
+			Header:        er.header(w.Header()),
+			ContentLength: aw.size,
+		}
+
+		if !aw.tooLong {
+			setArchivedBody(&resp.Body, &resp.ContentLength, er.body(aw.body.Bytes()))
+		}
+
+		response, err := httputil.DumpResponse(resp, !aw.tooLong)
+
+		if err != nil {
+			response = []byte(fmt.Sprintf("cannot dump response: %v\n", err))
+		}
+
+		ah.archive.add(request, response)
+	}()
+
+	ah.next.ServeHTTP(aw, req)
+}
+
+// copyingResponseWriter keeps a copy of the response, such as for the archive, with its body up to a maximum length:
+// max, or 4096 bytes for a body without a Content-Length if max isn't set.
+type copyingResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	size       int64
+	hijacked   bool
+
+	max     int64
+	tooLong bool
+	body    bytes.Buffer
+}
+
+func (cw *copyingResponseWriter) Write(p []byte) (int, error) {
+	cw.limit()
+	n, err := cw.ResponseWriter.Write(p)
+	cw.copy(p[:n])
+	return n, err
+}
+
+func (cw *copyingResponseWriter) WriteHeader(statusCode int) {
+	cw.ResponseWriter.WriteHeader(statusCode)
+	cw.statusCode = statusCode
+}
+
+// limit the copy by the Content-Length set by the handler before writing the body.
+func (cw *copyingResponseWriter) limit() {
+	if cw.size != 0 || cw.tooLong {
+		return
+	}
+
+	length, err := strconv.ParseInt(cw.Header().Get("Content-Length"), 10, 64)
+
+	switch {
+	case err == nil && cw.max > 0 && length > cw.max:
+		cw.tooLong = true
+	case err != nil && cw.max <= 0:
+		cw.max = maxDefaultUnknownReadable
+	}
+}
+
+// copy what was written, dropping the copy once the body is too long.
+func (cw *copyingResponseWriter) copy(p []byte) {
+	cw.size += int64(len(p))
+
+	switch {
+	case cw.tooLong:
+	case cw.max > 0 && cw.size > cw.max:
+		cw.tooLong = true
+		cw.body = bytes.Buffer{}
+	default:
+		cw.body.Write(p)
+	}
+}
+
+// Flush sends any buffered data to the client, if the underlying writer supports it,
+// so streaming responses, such as server-sent events, aren't held back by the copy.
+func (cw *copyingResponseWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the underlying ResponseWriter supports it.
+// The response of a hijacked connection isn't known, so it isn't copied.
+func (cw *copyingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, errors.New("httpretty: underlying ResponseWriter doesn't support hijacking")
+	}
+
+	conn, rw, err := hj.Hijack()
+
+	if err == nil {
+		cw.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// ReadFrom lets the underlying writer send files with sendfile once the body is too long to be copied.
+func (cw *copyingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	cw.limit()
+	rf, ok := cw.ResponseWriter.(io.ReaderFrom)
+
+	if !ok || !cw.tooLong {
+		return io.Copy(writerOnly{cw}, src)
+	}
+
+	n, err := rf.ReadFrom(src)
+	cw.size += n
+	return n, err
+}
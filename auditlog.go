@@ -0,0 +1,147 @@
+package httpretty
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const auditHashPrefix = "* audit hash: "
+
+// AuditSink makes the logs tamper-evident by chaining a hash to every record written to it.
+// Use it as the output of a logger with Logger.SetOutput, with the OnEnd flusher so each exchange is a single record.
+//
+// Each record is followed by a line with its hash, computed as SHA-256(previous hash || record),
+// so changing, removing, or reordering records breaks the chain. Use VerifyAuditLog to check it.
+// Removing records at the end of the log can only be detected by keeping the last hash elsewhere.
+type AuditSink struct {
+	// Output where the records and their hashes are written to. It is required.
+	Output io.Writer
+
+	// Previous hash, encoded in hexadecimal, to continue the chain of an existing log.
+	// If value is not set, the chain starts from a hash with all bits zero.
+	Previous string
+
+	mu   sync.Mutex
+	prev []byte
+}
+
+// Write a record to the output, followed by its hash.
+func (a *AuditSink) Write(p []byte) (n int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.prev == nil {
+		if a.prev, err = auditSeed(a.Previous); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var b bytes.Buffer
+	b.Write(p)
+
+	if p[len(p)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+
+	sum := auditHash(a.prev, b.Bytes())
+	b.WriteString(auditHashPrefix + hex.EncodeToString(sum) + "\n")
+
+	if _, err := b.WriteTo(a.Output); err != nil {
+		return 0, err
+	}
+
+	a.prev = sum
+	return len(p), nil
+}
+
+// Hash of the last record written, encoded in hexadecimal.
+func (a *AuditSink) Hash() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.prev == nil {
+		return a.Previous
+	}
+
+	return hex.EncodeToString(a.prev)
+}
+
+func auditSeed(previous string) ([]byte, error) {
+	if previous == "" {
+		return make([]byte, sha256.Size), nil
+	}
+
+	seed, err := hex.DecodeString(previous)
+
+	if err != nil || len(seed) != sha256.Size {
+		return nil, errors.New("invalid previous audit hash")
+	}
+
+	return seed, nil
+}
+
+func auditHash(prev, record []byte) []byte {
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(record)
+	return h.Sum(nil)
+}
+
+// VerifyAuditLog checks the chain of hashes of a log written by an AuditSink, returning the number of records verified.
+// Pass the hash the chain continues from as previous, if any (see AuditSink.Previous).
+// It returns an error for the first record that doesn't match its hash, or if the log ends without a hash.
+func VerifyAuditLog(r io.Reader, previous string) (records int, err error) {
+	prev, err := auditSeed(previous)
+
+	if err != nil {
+		return 0, err
+	}
+
+	br := bufio.NewReader(r)
+
+	var record bytes.Buffer
+
+	for {
+		line, err := br.ReadString('\n')
+
+		if err != nil && err != io.EOF {
+			return records, err
+		}
+
+		if strings.HasPrefix(line, auditHashPrefix) {
+			got := strings.TrimSuffix(strings.TrimPrefix(line, auditHashPrefix), "\n")
+			sum := auditHash(prev, record.Bytes())
+
+			if want := hex.EncodeToString(sum); got != want {
+				return records, fmt.Errorf("audit hash of record %d doesn't match: got %s, expected %s", records+1, got, want)
+			}
+
+			records++
+			prev = sum
+			record.Reset()
+		} else {
+			record.WriteString(line)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if record.Len() != 0 {
+		return records, fmt.Errorf("record %d has no audit hash", records+1)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,73 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditSink(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	sink := &AuditSink{Output: &out}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+	}
+
+	logger.SetOutput(sink)
+	logger.SetFlusher(OnEnd)
+
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	log := out.String()
+
+	if n, err := VerifyAuditLog(strings.NewReader(log), ""); n != 3 || err != nil {
+		t.Errorf("VerifyAuditLog() = %d, %v, wanted 3 records verified", n, err)
+	}
+
+	if !strings.HasSuffix(log, auditHashPrefix+sink.Hash()+"\n") {
+		t.Errorf("log doesn't end with the last hash %s", sink.Hash())
+	}
+
+	tampered := strings.Replace(log, "GET /b", "GET /x", 1)
+
+	if n, err := VerifyAuditLog(strings.NewReader(tampered), ""); n != 1 || err == nil ||
+		!strings.HasPrefix(err.Error(), "audit hash of record 2 doesn't match") {
+		t.Errorf("VerifyAuditLog() of tampered log = %d, %v, wanted error on record 2", n, err)
+	}
+
+	// continue the chain on another output.
+	var next bytes.Buffer
+	cont := &AuditSink{Output: &next, Previous: sink.Hash()}
+	cont.Write([]byte("appended"))
+
+	if n, err := VerifyAuditLog(strings.NewReader(next.String()), sink.Hash()); n != 1 || err != nil {
+		t.Errorf("VerifyAuditLog() of continued log = %d, %v, wanted 1 record verified", n, err)
+	}
+
+	if _, err := VerifyAuditLog(strings.NewReader(next.String()), ""); err == nil {
+		t.Errorf("expected continued log to fail verification without the previous hash")
+	}
+}
+
+func TestVerifyAuditLogMissingHash(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	sink := &AuditSink{Output: &out}
+	sink.Write([]byte("first\n"))
+	out.WriteString("unchained\n")
+
+	if n, err := VerifyAuditLog(&out, ""); n != 1 || err == nil || err.Error() != "record 2 has no audit hash" {
+		t.Errorf("VerifyAuditLog() = %d, %v, wanted error on record 2", n, err)
+	}
+}
@@ -0,0 +1,36 @@
+package httpretty
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// transportHeaders returns the headers a *http.Transport is going to add to a request automatically.
+// See https://github.com/golang/go/blob/go1.14/src/net/http/transport.go
+// and https://github.com/golang/go/blob/go1.14/src/net/http/request.go
+func transportHeaders(req *http.Request, rt http.RoundTripper) http.Header {
+	h := http.Header{}
+	transport, ok := rt.(*http.Transport)
+
+	if !ok {
+		return h
+	}
+
+	if !transport.DisableCompression &&
+		req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" &&
+		req.Method != http.MethodHead {
+		h.Set("Accept-Encoding", "gzip")
+	}
+
+	if _, ok := req.Header["User-Agent"]; !ok && req.URL.Scheme == "http" {
+		// HTTP/2 is only negotiated on TLS connections, where it would be "Go-http-client/2.0".
+		h.Set("User-Agent", "Go-http-client/1.1")
+	}
+
+	if req.ContentLength > 0 && req.Header.Get("Content-Length") == "" {
+		h.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+
+	return h
+}
@@ -0,0 +1,83 @@
+package httpretty
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// countRequestBody counts the bytes of a request body that isn't printed, if Logger.CountBodyBytes is set.
+func (p *printer) countRequestBody(req *http.Request) {
+	if !p.logger.CountBodyBytes || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	cb := &countingBody{ReadCloser: req.Body}
+	req.Body = cb
+	p.counted = cb
+}
+
+// printRequestBodySize prints the size of the counted request body.
+func (p *printer) printRequestBodySize() {
+	if cb := p.counted; cb != nil {
+		p.counted = nil
+
+		if n := cb.size(); n != 0 {
+			p.printf("* request body: %s\n", formatBytes(n))
+		}
+	}
+}
+
+// printResponseBodySize prints the size of a response body that isn't printed, if Logger.CountBodyBytes is set.
+func (p *printer) printResponseBodySize(n int64) {
+	if p.logger.CountBodyBytes && n != 0 {
+		p.printf("* response body: %s\n", formatBytes(n))
+	}
+}
+
+// countResponseBody counts the bytes of a response body received by the client that isn't printed,
+// printing its size once it is read or closed, if Logger.CountBodyBytes is set.
+func (p *printer) countResponseBody(resp *http.Response) {
+	if !p.logger.CountBodyBytes || resp.Body == nil || resp.Body == http.NoBody ||
+		(resp.Request != nil && resp.Request.Method == http.MethodHead) {
+		return
+	}
+
+	l, statusCode := p.logger, p.statusCode
+
+	resp.Body = &doneBody{
+		ReadCloser: resp.Body,
+		done: func(n int64) {
+			rp := newPrinter(l)
+			rp.statusCode = statusCode
+			rp.printResponseBodySize(n)
+			rp.flush()
+		},
+	}
+}
+
+// doneBody counts the bytes read from a body, calling done once it is read to the end or closed.
+type doneBody struct {
+	io.ReadCloser
+
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (db *doneBody) Read(p []byte) (int, error) {
+	n, err := db.ReadCloser.Read(p)
+	db.n += int64(n)
+
+	if err != nil {
+		db.once.Do(func() { db.done(db.n) })
+	}
+
+	return n, err
+}
+
+func (db *doneBody) Close() error {
+	err := db.ReadCloser.Close()
+	db.once.Do(func() { db.done(db.n) })
+	return err
+}
@@ -0,0 +1,146 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// echoHandler writes back the request body, flushing it so the response has no Content-Length.
+func echoHandler(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	w.Header()["Date"] = nil
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	w.Write(body)
+}
+
+func TestOutgoingCountBodyBytes(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+		CountBodyBytes:  true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(strings.Repeat("a", 2048)))
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, bytes.Repeat([]byte("a"), 2048))
+
+	want := fmt.Sprintf(`> POST / HTTP/1.1
+> Host: %s
+
+* request body: 2.0 KiB
+< HTTP/1.1 200 OK
+< Content-Type: text/plain
+
+* response body: 2.0 KiB
+`, ts.Listener.Addr())
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingCountBodyBytes(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+		CountBodyBytes:  true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ioutil.ReadAll(req.Body)
+		fmt.Fprint(w, "Hello, world!")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("hello"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `> POST / HTTP/1.1
+> Host: example.com
+
+* request body: 5 B
+< HTTP/1.1 200 OK
+< Content-Type: text/plain; charset=utf-8
+
+* response body: 13 B
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingLogfmtCountBodyBytes(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer ts.Close()
+
+	logger := &Logger{
+		Logfmt:         true,
+		CountBodyBytes: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	// the length of the body is unknown, so it is sent chunked.
+	body := ioutil.NopCloser(strings.NewReader("hello"))
+	resp, err := client.Post(ts.URL+"/echo", "text/plain", body)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	if got := buf.String(); got != "" {
+		t.Errorf("logfmt line printed before the response body was read: %q", got)
+	}
+
+	testBody(t, resp.Body, []byte("hello"))
+
+	want := regexp.MustCompile(`^method=POST host=127\.0\.0\.1:\d+ path=/echo status=200 dur=\S+ req_bytes=5 resp_bytes=5\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
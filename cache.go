@@ -0,0 +1,154 @@
+package httpretty
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// printCacheStatus prints a summary of how caches along the way handled a response,
+// such as "* cache: HIT from Cloudflare, age 312s, fresh for 288s (max-age 600s)".
+// It is only printed when a cache reported its status, or the Age header is set.
+func (p *printer) printCacheStatus(h http.Header) {
+	status, from := cacheStatus(h)
+	age, hasAge := headerSeconds(h.Get("Age"))
+
+	if status == "" && !hasAge {
+		return
+	}
+
+	var parts []string
+
+	switch {
+	case status != "" && from != "":
+		parts = append(parts, status+" from "+from)
+	case status != "":
+		parts = append(parts, status)
+	default:
+		parts = append(parts, "served from a cache")
+	}
+
+	if hasAge {
+		parts = append(parts, "age "+strconv.FormatInt(age, 10)+"s")
+	}
+
+	if freshness := cacheFreshness(h.Get("Cache-Control"), age); freshness != "" {
+		parts = append(parts, freshness)
+	}
+
+	line := strings.Join(parts, ", ")
+
+	if strings.Contains(line, "stale") {
+		line = p.format(color.FgYellow, "%s", line)
+	}
+
+	p.printf("* cache: %s\n", line)
+}
+
+// cacheStatus returns the status reported by the cache closest to the client, and which cache it was.
+func cacheStatus(h http.Header) (status, from string) {
+	// see https://www.rfc-editor.org/rfc/rfc9211 (the last cache listed is the closest to the client).
+	if entries := splitList(h.Get("Cache-Status")); len(entries) != 0 {
+		params := strings.Split(entries[len(entries)-1], ";")
+		from = strings.Trim(strings.TrimSpace(params[0]), `"`)
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+
+			switch {
+			case param == "hit":
+				return "HIT", from
+			case strings.HasPrefix(param, "fwd="):
+				return strings.ToUpper(strings.TrimPrefix(param, "fwd=")), from
+			}
+		}
+	}
+
+	if v := strings.TrimSpace(h.Get("CF-Cache-Status")); v != "" {
+		return strings.ToUpper(v), "Cloudflare"
+	}
+
+	for _, key := range []string{"X-Cache", "X-Cache-Status", "X-Proxy-Cache"} {
+		// Fastly lists the status of every cache, the last one being the closest to the client.
+		entries := splitList(h.Get(key))
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		entry := entries[len(entries)-1]
+
+		if i := strings.Index(strings.ToLower(entry), " from "); i != -1 {
+			entry, from = entry[:i], strings.TrimSpace(entry[i+len(" from "):])
+		}
+
+		// Squid and Akamai use values such as TCP_MEM_HIT.
+		status = strings.ToUpper(strings.TrimSpace(entry))
+
+		for _, s := range []string{"REFRESH_HIT", "HIT", "MISS", "EXPIRED", "STALE"} {
+			if strings.HasPrefix(status, "TCP_") && strings.HasSuffix(status, s) {
+				status = s
+				break
+			}
+		}
+
+		return status, from
+	}
+
+	return "", ""
+}
+
+// cacheFreshness tells if a response of the given age is still fresh according to its Cache-Control header.
+func cacheFreshness(cacheControl string, age int64) string {
+	var (
+		maxAge    int64
+		hasMaxAge bool
+		directive = "max-age"
+		notes     []string
+	)
+
+	for _, d := range splitList(cacheControl) {
+		name, value := d, ""
+
+		if i := strings.IndexByte(d, '='); i != -1 {
+			name, value = d[:i], strings.Trim(d[i+1:], `"`)
+		}
+
+		switch name = strings.ToLower(name); name {
+		case "no-store", "no-cache", "private":
+			notes = append(notes, name)
+		case "max-age", "s-maxage":
+			// s-maxage overrides max-age for shared caches, such as CDNs.
+			if n, ok := headerSeconds(value); ok && (name == "s-maxage" || directive != "s-maxage") {
+				maxAge, hasMaxAge, directive = n, true, name
+			}
+		}
+	}
+
+	var freshness string
+
+	switch {
+	case !hasMaxAge:
+	case age < maxAge:
+		freshness = "fresh for " + strconv.FormatInt(maxAge-age, 10) + "s (" + directive + " " + strconv.FormatInt(maxAge, 10) + "s)"
+	default:
+		freshness = "stale by " + strconv.FormatInt(age-maxAge, 10) + "s (" + directive + " " + strconv.FormatInt(maxAge, 10) + "s)"
+	}
+
+	if len(notes) != 0 {
+		if freshness != "" {
+			freshness += " "
+		}
+
+		freshness += "[" + strings.Join(notes, ", ") + "]"
+	}
+
+	return freshness
+}
+
+func headerSeconds(v string) (int64, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	return n, err == nil && n >= 0
+}
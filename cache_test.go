@@ -0,0 +1,119 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrintCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		h    http.Header
+		want string
+	}{
+		{
+			name: "not cached",
+			h:    http.Header{"Cache-Control": {"max-age=600"}},
+		},
+		{
+			name: "cloudflare",
+			h: http.Header{
+				"Cf-Cache-Status": {"HIT"},
+				"Age":             {"312"},
+				"Cache-Control":   {"public, max-age=600"},
+			},
+			want: "* cache: HIT from Cloudflare, age 312s, fresh for 288s (max-age 600s)\n",
+		},
+		{
+			name: "cloudfront stale",
+			h: http.Header{
+				"X-Cache":       {"Hit from cloudfront"},
+				"Age":           {"900"},
+				"Cache-Control": {"max-age=60, s-maxage=600"},
+			},
+			want: "* cache: HIT from cloudfront, age 900s, stale by 300s (s-maxage 600s)\n",
+		},
+		{
+			name: "fastly",
+			h:    http.Header{"X-Cache": {"HIT, MISS"}},
+			want: "* cache: MISS\n",
+		},
+		{
+			name: "squid",
+			h:    http.Header{"X-Cache-Status": {"TCP_MEM_HIT"}},
+			want: "* cache: HIT\n",
+		},
+		{
+			name: "cache-status",
+			h: http.Header{
+				"Cache-Status":  {`OriginCache; hit; ttl=1100, "CDN Company Here"; fwd=uri-miss; stored`},
+				"Cache-Control": {"no-cache"},
+			},
+			want: "* cache: URI-MISS from CDN Company Here, [no-cache]\n",
+		},
+		{
+			name: "age only",
+			h:    http.Header{"Age": {"12"}},
+			want: "* cache: served from a cache, age 12s\n",
+		},
+		{
+			name: "empty list",
+			h:    http.Header{"X-Cache": {" , "}},
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printCacheStatus(tc.h)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("printCacheStatus(%s) = %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOutgoingCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Cache", "HIT from edge-1")
+		w.Header().Set("Age", "30")
+		w.Header().Set("Cache-Control", "max-age=10")
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if want := "* cache: HIT from edge-1, age 30s, stale by 20s (max-age 10s)\n< HTTP/1.1 200 OK\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("logged output doesn't contain %q:\n%s", want, buf.String())
+	}
+}
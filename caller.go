@@ -0,0 +1,51 @@
+package httpretty
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// maxCallerFrames is the number of frames printed when Logger.DebugCaller is set.
+const maxCallerFrames = 3
+
+// callerFrames returns the frames of the code that initiated an outgoing request,
+// skipping the standard library HTTP client frames.
+func callerFrames(skip int) []runtime.Frame {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	var list []runtime.Frame
+
+	for {
+		frame, more := frames.Next()
+
+		if !isHTTPClientFrame(frame.Function) {
+			list = append(list, frame)
+		}
+
+		if !more || len(list) == maxCallerFrames {
+			return list
+		}
+	}
+}
+
+func isHTTPClientFrame(function string) bool {
+	return strings.HasPrefix(function, "net/http.") ||
+		strings.HasPrefix(function, "runtime.") ||
+		strings.HasPrefix(function, "github.com/henvic/httpretty.roundTripper.")
+}
+
+func (p *printer) printCaller(frames []runtime.Frame) {
+	if len(frames) == 0 {
+		return
+	}
+
+	p.println("* Request initiated by:")
+
+	for _, frame := range frames {
+		p.printf("*  %s (%s:%d)\n", p.format(color.FgBlue, "%s", frame.Function), frame.File, frame.Line)
+	}
+}
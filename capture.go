@@ -0,0 +1,62 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isCaptured checks if the bodies of an exchange should be printed, given the status code and headers of its response.
+// A zero status code means the exchange failed without a response.
+// See Logger.CaptureHeader and Logger.ErrorBodiesOnly.
+func (p *printer) isCaptured(statusCode int, h http.Header) bool {
+	if p.logger.ErrorBodiesOnly && statusCode != 0 && statusCode < 400 {
+		return false
+	}
+
+	key := p.logger.CaptureHeader
+
+	if key == "" {
+		return true
+	}
+
+	v := h.Get(key)
+	return v != "" && v != "0" && !strings.EqualFold(v, "false")
+}
+
+// holdRequestBody prints the request body to a separate buffer, released by releaseRequestBody
+// once the response shows if the exchange should be captured.
+func (p *printer) holdRequestBody(req *http.Request) {
+	held := &printer{
+		logger:     p.logger,
+		config:     p.config,
+		formatters: p.formatters,
+		flusher:    OnEnd,
+	}
+
+	held.printRequestBody(req)
+	p.held = held
+}
+
+// releaseRequestBody prints the held request body if the exchange is captured, or discards it otherwise.
+func (p *printer) releaseRequestBody(captured bool) {
+	held := p.held
+	p.held = nil
+
+	if held == nil {
+		return
+	}
+
+	p.logger.mu.Lock()
+	s := held.buf.String()
+	held.buf.Reset()
+	held.updateBuffered()
+	p.logger.mu.Unlock()
+
+	if !captured || s == "" {
+		return
+	}
+
+	p.println("* request body:")
+	p.print(s)
+	p.println()
+}
@@ -0,0 +1,192 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type captureHandler struct{}
+
+func (h captureHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ioutil.ReadAll(req.Body)
+
+	if req.URL.Path == "/debug" {
+		w.Header().Set("X-Debug-Capture", "1")
+	}
+
+	w.Header()["Date"] = nil
+	fmt.Fprint(w, "Hello, world!")
+}
+
+func TestOutgoingCaptureHeader(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(captureHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		CaptureHeader:   "X-Debug-Capture",
+		RequestBody:     true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+	}
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "/",
+			want: `< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+
+`,
+		},
+		{
+			path: "/debug",
+			want: `< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+< X-Debug-Capture: 1
+
+* request body:
+Hi
+
+Hello, world!
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			resp, err := client.Post(ts.URL+tc.path, "text/plain", strings.NewReader("Hi"))
+
+			if err != nil {
+				t.Fatalf("cannot connect to the server: %v", err)
+			}
+
+			testBody(t, resp.Body, []byte("Hello, world!"))
+
+			if got := buf.String(); got != tc.want {
+				t.Errorf("logged HTTP request %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIncomingCaptureHeader(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		CaptureHeader:   "X-Debug-Capture",
+		RequestBody:     true,
+		ResponseBody:    true,
+	}
+
+	handler := logger.Middleware(captureHandler{})
+
+	for path, want := range map[string]string{
+		"/":      "",
+		"/debug": "* request body:\nHi\n\nHello, world!\n",
+	} {
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+path, strings.NewReader("Hi"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got := buf.String(); got != want {
+			t.Errorf("logged HTTP request for %s %q; want %q", path, got, want)
+		}
+	}
+}
+
+type errorBodyHandler struct{}
+
+func (h errorBodyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ioutil.ReadAll(req.Body)
+
+	if req.URL.Path == "/missing" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	fmt.Fprint(w, "Hello, world!")
+}
+
+func TestIncomingErrorBodiesOnly(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ErrorBodiesOnly: true,
+		RequestBody:     true,
+		ResponseBody:    true,
+	}
+
+	handler := logger.Middleware(errorBodyHandler{})
+
+	for path, want := range map[string]string{
+		"/":        "",
+		"/missing": "* request body:\nHi\n\nnot found\n\n",
+	} {
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+path, strings.NewReader("Hi"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got := buf.String(); got != want {
+			t.Errorf("logged HTTP request for %s %q; want %q", path, got, want)
+		}
+	}
+}
+
+func TestOutgoingErrorBodiesOnlyTransportError(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ErrorBodiesOnly: true,
+		RequestBody:     true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(errorRoundTripper{}),
+	}
+
+	if _, err := client.Post("http://example.com/", "text/plain", strings.NewReader("Hi")); err == nil {
+		t.Fatal("expected request to fail")
+	}
+
+	want := "* transport failed\n* request body:\nHi\n\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+type errorRoundTripper struct{}
+
+func (errorRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("transport failed")
+}
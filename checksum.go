@@ -0,0 +1,40 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// checksumBody reads a body too long to print to print its checksum. See Logger.ChecksumLongBodies.
+func (p *printer) checksumBody(body io.ReadCloser) io.ReadCloser {
+	var buf bytes.Buffer
+	h := sha256.New()
+
+	n, err := io.Copy(io.MultiWriter(&buf, h), body)
+	body.Close()
+
+	if err != nil {
+		p.printf("* cannot read body for checksum: %v\n", p.format(color.FgRed, err))
+		return ioutil.NopCloser(io.MultiReader(&buf, errorReader{err}))
+	}
+
+	p.printChecksum(h.Sum(nil), n)
+	return ioutil.NopCloser(&buf)
+}
+
+func (p *printer) printChecksum(sum []byte, n int64) {
+	p.printf("* body sha256: %x (%d bytes)\n", sum, n)
+}
+
+// errorReader returns the error it was given, so a body can fail the same way it failed when read for the logger.
+type errorReader struct {
+	err error
+}
+
+func (er errorReader) Read(p []byte) (int, error) {
+	return 0, er.err
+}
@@ -0,0 +1,106 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type longHandler struct{}
+
+func (h longHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Length", strconv.Itoa(len(petition)))
+	fmt.Fprint(w, petition)
+}
+
+func TestResponseRecorderDeclaredTooLong(t *testing.T) {
+	t.Parallel()
+
+	rec := &responseRecorder{
+		ResponseWriter:  httptest.NewRecorder(),
+		maxReadableBody: 10,
+		buf:             &bytes.Buffer{},
+	}
+
+	rec.Header().Set("Content-Length", "20")
+	rec.Write([]byte("short"))
+
+	if rec.buf != nil {
+		t.Errorf("body declared too long should not be buffered, got %q", rec.buf)
+	}
+
+	if rec.size != 5 {
+		t.Errorf("got size = %d, wanted 5", rec.size)
+	}
+}
+
+func TestIncomingChecksumLongBodies(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo:    true,
+		ResponseBody:       true,
+		MaxResponseBody:    100,
+		ChecksumLongBodies: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	logger.Middleware(longHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	want := fmt.Sprintf(`* body is too long (%d bytes) to print, skipping (longer than 100 bytes)
+* body sha256: %x (%d bytes)
+`, len(petition), sha256.Sum256([]byte(petition)), len(petition))
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingChecksumLongBodies(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(longHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:    true,
+		RequestBody:        true,
+		ResponseBody:       true,
+		MaxRequestBody:     5,
+		MaxResponseBody:    100,
+		ChecksumLongBodies: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("Hello, server!"))
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte(petition))
+
+	want := fmt.Sprintf(`* body is too long (14 bytes) to print, skipping (longer than 5 bytes)
+* body sha256: %x (14 bytes)
+* body is too long (%d bytes) to print, skipping (longer than 100 bytes)
+* body sha256: %x (%d bytes)
+`, sha256.Sum256([]byte("Hello, server!")), len(petition), sha256.Sum256([]byte(petition)), len(petition))
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package httpretty
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -18,6 +19,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
@@ -774,6 +776,92 @@ func TestOutgoingWithTimeRequest(t *testing.T) {
 	}
 }
 
+func TestOutgoingWithTimeDeadline(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		Time: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	if _, err = client.Do(req); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	if got := buf.String(); !regexp.MustCompile(`\* used \S+ of 1m0s deadline\n`).MatchString(got) {
+		t.Errorf("missing printing deadline budget: %s", got)
+	}
+}
+
+func TestOutgoingCapturedResponseBody(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	testCases := []struct {
+		name     string
+		max      int64
+		captured bool
+	}{
+		{name: "unlimited", captured: true},
+		{name: "under limit", max: 100, captured: true},
+		{name: "over limit", max: 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &Logger{
+				CapturedResponseBody: true,
+				ResponseBody:         true,
+				MaxResponseBody:      tc.max,
+			}
+
+			logger.SetOutput(ioutil.Discard)
+
+			client := &http.Client{
+				Transport: logger.RoundTripper(newTransport()),
+			}
+
+			resp, err := client.Get(ts.URL)
+
+			if err != nil {
+				t.Fatalf("cannot connect to the server: %v", err)
+			}
+
+			cb, ok := resp.Body.(*CapturedBody)
+
+			if ok != tc.captured {
+				t.Fatalf("got response body of type %T, wanted captured = %v", resp.Body, tc.captured)
+			}
+
+			if ok && string(cb.Bytes()) != "Hello, world!" {
+				t.Errorf("got captured body %q, wanted %q", cb.Bytes(), "Hello, world!")
+			}
+
+			testBody(t, resp.Body, []byte("Hello, world!"))
+		})
+	}
+}
+
 type jsonHandler struct{}
 
 func (h jsonHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -1220,11 +1308,11 @@ func TestOutgoingBinaryBodyNoMediatypeHeader(t *testing.T) {
 > POST /convert HTTP/1.1
 > Host: %s
 
-* body contains binary data
+* body appears to be image/webp (14 B)
 < HTTP/1.1 200 OK
 < Content-Length: 16
 
-* body contains binary data
+* body appears to be application/pdf (16 B)
 `, uri, ts.Listener.Addr())
 
 	if got := buf.String(); got != want {
@@ -1690,8 +1778,8 @@ func TestOutgoingMultipartForm(t *testing.T) {
 	defer ts.Close()
 
 	logger := &Logger{
-		RequestHeader: true,
-		// TODO(henvic): print request body once support for printing out multipart/formdata body is added.
+		RequestHeader:  true,
+		RequestBody:    true,
 		ResponseHeader: true,
 		ResponseBody:   true,
 	}
@@ -1732,12 +1820,22 @@ func TestOutgoingMultipartForm(t *testing.T) {
 > Host: %s
 > Content-Type: %s
 
+* part 1: field "author" (18 B)
+*  Content-Disposition: form-data; name="author"
+Frédéric Bastiat
+* part 2: field "title" (22 B)
+*  Content-Disposition: form-data; name="title"
+Candlemakers' Petition
+* part 3: field "file", file "petition", application/octet-stream (9.6 KiB)
+*  Content-Disposition: form-data; name="file"; filename="petition"
+*  Content-Type: application/octet-stream
+%s
 < HTTP/1.1 200 OK
 < Content-Length: 15
 < Content-Type: text/plain; charset=utf-8
 
 upload received
-`, uri, ts.Listener.Addr(), writer.FormDataContentType())
+`, uri, ts.Listener.Addr(), writer.FormDataContentType(), petition)
 
 	if got := buf.String(); got != want {
 		t.Errorf("logged HTTP request %s; want %s", got, want)
@@ -2292,3 +2390,584 @@ func netListener() (listener net.Listener, err error) {
 
 	return
 }
+
+func TestOutgoingMethods(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	logger.Methods([]string{http.MethodPost, "delete"})
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	testCases := []struct {
+		method string
+		full   bool
+	}{
+		{method: http.MethodGet},
+		{method: http.MethodPost, full: true},
+		{method: http.MethodDelete, full: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			req, err := http.NewRequest(tc.method, ts.URL, nil)
+
+			if err != nil {
+				t.Errorf("cannot create request: %v", err)
+			}
+
+			if _, err = client.Do(req); err != nil {
+				t.Errorf("cannot connect to the server: %v", err)
+			}
+
+			summary := fmt.Sprintf("* Request to %s\n", ts.URL)
+
+			if got := buf.String(); !tc.full && got != summary {
+				t.Errorf("logged HTTP request %s; want %s", got, summary)
+			}
+
+			if got := buf.String(); tc.full && !strings.Contains(got, "Hello, world!") {
+				t.Errorf("logged HTTP request %s; wanted full request and response", got)
+			}
+		})
+	}
+}
+
+func TestOutgoingHosts(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot parse server URL: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		only   []string
+		skip   []string
+		logged bool
+	}{
+		{name: "any", logged: true},
+		{name: "only", only: []string{"example.com", strings.ToUpper(u.Hostname())}, logged: true},
+		{name: "only other", only: []string{"example.com"}},
+		{name: "skip", skip: []string{u.Host}},
+		{name: "skip other", skip: []string{"example.com"}, logged: true},
+		{name: "only and skip", only: []string{u.Hostname()}, skip: []string{u.Hostname()}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &Logger{
+				ResponseBody: true,
+			}
+
+			logger.OnlyHosts(tc.only...)
+			logger.SkipHosts(tc.skip...)
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			client := &http.Client{
+				Transport: logger.RoundTripper(newTransport()),
+			}
+
+			if _, err := client.Get(ts.URL); err != nil {
+				t.Errorf("cannot connect to the server: %v", err)
+			}
+
+			if got := buf.String(); tc.logged != strings.Contains(got, "Hello, world!") {
+				t.Errorf("logged HTTP request %q; wanted logged = %v", got, tc.logged)
+			}
+		})
+	}
+}
+
+func TestOutgoingSkipUnchangedResponseBody(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:           true,
+		ResponseBody:              true,
+		SkipUnchangedResponseBody: true,
+	}
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	testCases := []struct {
+		path      string
+		unchanged bool
+	}{
+		{path: "/poll"},
+		{path: "/poll", unchanged: true},
+		{path: "/other"},
+		{path: "/poll", unchanged: true},
+	}
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		resp, err := client.Get(ts.URL + tc.path)
+
+		if err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+
+		testBody(t, resp.Body, []byte("Hello, world!"))
+
+		got := buf.String()
+
+		if want := "Hello, world!\n"; !tc.unchanged && got != want {
+			t.Errorf("logged HTTP request %s; want %s", got, want)
+		}
+
+		if want := "* response unchanged (same as "; tc.unchanged && !strings.HasPrefix(got, want) {
+			t.Errorf("logged HTTP request %s; want %s", got, want)
+		}
+	}
+}
+
+func TestOutgoingMaxExchangeBytes(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:  true,
+		ResponseHeader:   true,
+		ResponseBody:     true,
+		MaxExchangeBytes: 24,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, world!"))
+
+	want := `< HTTP/1.1 200 OK
+< Cont
+* exchange output truncated (longer than 24 bytes)
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+type gzipHandler struct{}
+
+func (h gzipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header()["Date"] = nil
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	gz := gzip.NewWriter(w)
+	fmt.Fprint(gz, "Hello, compressed world!")
+	gz.Close()
+}
+
+func TestOutgoingContentEncoding(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&gzipHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Errorf("cannot create request: %v", err)
+	}
+
+	// setting Accept-Encoding explicitly disables the transparent decompression by the transport.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+
+	if err != nil {
+		t.Errorf("response body should remain compressed: %v", err)
+	}
+
+	testBody(t, gz, []byte("Hello, compressed world!"))
+
+	if got, want := buf.String(), "Hello, compressed world!\n"; got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingDebugCaller(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		DebugCaller:     true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Get(ts.URL); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	got := buf.String()
+
+	if want := "* Request initiated by:\n*  github.com/henvic/httpretty.TestOutgoingDebugCaller ("; !strings.HasPrefix(got, want) {
+		t.Errorf("logged HTTP request %s; want prefix %s", got, want)
+	}
+
+	if want := "client_test.go:"; !strings.Contains(got, want) {
+		t.Errorf("logged HTTP request %s; want it to contain %s", got, want)
+	}
+}
+
+func TestOutgoingAnnotateAutoHeaders(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:     true,
+		RequestHeader:       true,
+		AnnotateAutoHeaders: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("hello"))
+
+	if err != nil {
+		t.Errorf("cannot create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "text/plain")
+
+	if _, err = client.Do(req); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	want := fmt.Sprintf(`> POST / HTTP/1.1
+> Host: %s (auto)
+> Accept-Encoding: gzip (auto)
+> Content-Length: 5 (auto)
+> Content-Type: text/plain
+> User-Agent: Go-http-client/1.1 (auto)
+
+`, ts.Listener.Addr())
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+// slowWriter blocks the first write for a while.
+type slowWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	delay time.Duration
+	once  sync.Once
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	s.once.Do(func() {
+		time.Sleep(s.delay)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *slowWriter) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+type slowHandler struct{}
+
+func (h slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(100 * time.Millisecond)
+	w.Header()["Date"] = nil
+	fmt.Fprintf(w, "Hello, world!")
+}
+
+func TestOutgoingWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&slowHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+		WriteTimeout:    10 * time.Millisecond,
+	}
+
+	w := &slowWriter{delay: 50 * time.Millisecond}
+	logger.SetOutput(w)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Errorf("cannot create request: %v", err)
+	}
+
+	start := time.Now()
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, world!"))
+
+	want := fmt.Sprintf(`> GET / HTTP/1.1
+* output writer blocked for more than 10ms: %d bytes of this exchange were dropped
+`, len(fmt.Sprintf("> Host: %s\n\n< HTTP/1.1 200 OK\n< Content-Length: 13\n< Content-Type: text/plain; charset=utf-8\n\nHello, world!\n", ts.Listener.Addr())))
+
+	if got := w.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, logger should not stall it", elapsed)
+	}
+}
+
+type annotatingTransport struct {
+	rt http.RoundTripper
+}
+
+func (a annotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	Annotate(req.Context(), "transport", "inner")
+	return a.rt.RoundTrip(req)
+}
+
+func TestOutgoingAnnotate(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(annotatingTransport{newTransport()}),
+	}
+
+	ctx := WithAnnotations(context.Background())
+	Annotate(ctx, "caller", "test")
+	Annotate(context.Background(), "ignored", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Errorf("cannot create request: %v", err)
+	}
+
+	if _, err = client.Do(req); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	want := `* note caller=test
+* note transport=inner
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingTransparentDecompression(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&gzipHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, compressed world!"))
+
+	want := `< HTTP/1.1 200 OK
+< Content-Type: text/plain; charset=utf-8
+
+* body was transparently gunzipped by the transport; original size unknown, decoded 24 B
+Hello, compressed world!
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+type hangingFormatter struct {
+	release chan struct{}
+}
+
+func (h *hangingFormatter) Match(mediatype string) bool {
+	return true
+}
+
+func (h *hangingFormatter) Format(w io.Writer, src []byte) error {
+	<-h.release
+	return nil
+}
+
+func TestOutgoingFormatterTimeout(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	hanging := &hangingFormatter{release: make(chan struct{})}
+	defer close(hanging.release)
+
+	logger := &Logger{
+		SkipRequestInfo:  true,
+		ResponseBody:     true,
+		FormatterTimeout: 10 * time.Millisecond,
+		Formatters:       []Formatter{hanging},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, world!"))
+
+	want := `* body cannot be formatted: formatter timed out after 10ms
+Hello, world!
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingFormatterTimeoutFormatted(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&jsonHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:  true,
+		ResponseBody:     true,
+		FormatterTimeout: time.Minute,
+		Formatters:       []Formatter{&panickingFormatterMatcher{}, &JSONFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Get(ts.URL); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "{\n    \"result\": \"Hello, world!\"") {
+		t.Errorf("logged HTTP request %s; wanted formatted JSON", got)
+	}
+}
@@ -0,0 +1,82 @@
+package httpretty
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// conditionalHeaders used by clients to make conditional requests.
+// See https://tools.ietf.org/html/rfc7232
+var conditionalHeaders = []string{
+	"If-Match",
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Unmodified-Since",
+	"If-Range",
+}
+
+// printConditional prints a summary of the outcome of a conditional request.
+func (p *printer) printConditional(req *http.Request, statusCode int) {
+	var validators []string
+
+	for _, h := range conditionalHeaders {
+		if req.Header.Get(h) != "" {
+			validators = append(validators, h)
+		}
+	}
+
+	if len(validators) == 0 {
+		return
+	}
+
+	p.printf("* conditional request (%s): %s\n", strings.Join(validators, ", "), conditionalOutcome(req, statusCode))
+}
+
+// printRange prints a summary of the outcome of a range request.
+func (p *printer) printRange(req *http.Request, statusCode int, h http.Header) {
+	r := req.Header.Get("Range")
+
+	if r == "" {
+		return
+	}
+
+	var outcome string
+
+	switch statusCode {
+	case http.StatusPartialContent:
+		outcome = "served 206 (partial content)"
+
+		if cr := h.Get("Content-Range"); cr != "" {
+			outcome += ", Content-Range: " + cr
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		outcome = "not satisfiable, served 416"
+	case http.StatusOK:
+		outcome = "ignored, served 200 (full content)"
+	default:
+		outcome = fmt.Sprintf("served %d", statusCode)
+	}
+
+	p.printf("* range request (%s): %s\n", r, outcome)
+}
+
+func conditionalOutcome(req *http.Request, statusCode int) string {
+	switch {
+	case statusCode == http.StatusNotModified:
+		return "validator matched, served 304"
+	case statusCode == http.StatusPreconditionFailed:
+		return "precondition failed, served 412"
+	case statusCode == http.StatusPartialContent && req.Header.Get("If-Range") != "":
+		return "validator matched, served 206 (partial content)"
+	case statusCode >= 200 && statusCode < 300 && req.Header.Get("If-Range") != "":
+		return fmt.Sprintf("validator did not match, served %d (full content)", statusCode)
+	case statusCode >= 200 && statusCode < 300 &&
+		(req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""):
+		return fmt.Sprintf("validator did not match, served %d", statusCode)
+	case statusCode >= 200 && statusCode < 300:
+		return fmt.Sprintf("precondition passed, served %d", statusCode)
+	default:
+		return fmt.Sprintf("served %d", statusCode)
+	}
+}
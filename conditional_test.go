@@ -0,0 +1,32 @@
+package httpretty
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConditionalOutcome(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		header     string
+		statusCode int
+		want       string
+	}{
+		{header: "If-None-Match", statusCode: 304, want: "validator matched, served 304"},
+		{header: "If-Modified-Since", statusCode: 200, want: "validator did not match, served 200"},
+		{header: "If-Match", statusCode: 412, want: "precondition failed, served 412"},
+		{header: "If-Unmodified-Since", statusCode: 204, want: "precondition passed, served 204"},
+		{header: "If-Range", statusCode: 206, want: "validator matched, served 206 (partial content)"},
+		{header: "If-Range", statusCode: 200, want: "validator did not match, served 200 (full content)"},
+		{header: "If-Match", statusCode: 500, want: "served 500"},
+	}
+
+	for _, tc := range testCases {
+		req := &http.Request{Header: http.Header{tc.header: []string{"x"}}}
+
+		if got := conditionalOutcome(req, tc.statusCode); got != tc.want {
+			t.Errorf("conditionalOutcome(%s, %d) = %v, wanted %v", tc.header, tc.statusCode, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package httpretty
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// config holds the settings changed with the Logger methods, such as SkipHeader and SetFilter.
+//
+// It is copy-on-write: a config is never changed once in use, and the methods replace it with an updated copy.
+// Each exchange takes the current one when it starts (see newPrinter), along with Logger.Formatters,
+// so reconfiguring a logger during live traffic applies to the next exchanges as a whole,
+// and never partially to one in progress.
+type config struct {
+	filter     Filter
+	skipHeader map[string]struct{}
+	methods    map[string]struct{}
+	onlyHosts  map[string]struct{}
+	skipHosts  map[string]struct{}
+	paths      []pathPattern
+	bodyFilter BodyFilter
+	annotator  ResponseAnnotator
+	controller *Controller
+	postFilter PostFilter
+	encoder    Encoder
+}
+
+// updateConfig replaces the config with an updated copy.
+func (l *Logger) updateConfig(update func(c *config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var c config
+
+	if l.config != nil {
+		c = *l.config
+	}
+
+	update(&c)
+	l.config = &c
+}
+
+// getConfig returns the config to use for an exchange. The logger mutex must be held.
+func (l *Logger) getConfig() *config {
+	if l.config == nil {
+		l.config = &config{}
+	}
+
+	return l.config
+}
+
+// SetFormatters replaces Logger.Formatters with a copy of the given formatters.
+// Unlike setting the field, it is safe to call while the logger is in use:
+// exchanges in progress keep using the formatters they started with. This method is concurrency safe.
+func (l *Logger) SetFormatters(formatters ...Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Formatters = append([]Formatter(nil), formatters...)
+}
+
+// isHostLogged checks if requests to the host of the URL should be logged.
+func (c *config) isHostLogged(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+
+	if _, ok := c.skipHosts[host]; ok {
+		return false
+	}
+
+	if c.onlyHosts == nil {
+		return true
+	}
+
+	_, ok := c.onlyHosts[host]
+	return ok
+}
+
+// isFullyLogged checks if a request should be fully logged, rather than only summarized.
+func (c *config) isFullyLogged(method string) bool {
+	if c.controller != nil && !c.controller.Enabled() {
+		return false
+	}
+
+	if c.methods == nil {
+		return true
+	}
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	_, ok := c.methods[method]
+	return ok
+}
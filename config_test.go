@@ -0,0 +1,104 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIncomingReconfigureDuringExchange(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&JSONFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// only applies to the next exchanges.
+		logger.SkipHeader([]string{"X-Trace"})
+		logger.SetFormatters()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Trace", "abc")
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := `< HTTP/1.1 200 OK
+< Content-Type: application/json
+< X-Trace: abc
+
+{
+    "ok": true
+}
+< HTTP/1.1 200 OK
+< Content-Type: application/json
+
+{"ok":true}
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestSetFormattersCopies(t *testing.T) {
+	t.Parallel()
+
+	formatters := []Formatter{&JSONFormatter{}}
+
+	logger := &Logger{}
+	logger.SetFormatters(formatters...)
+	formatters[0] = &XMLFormatter{}
+
+	if _, ok := logger.Formatters[0].(*JSONFormatter); !ok || len(logger.Formatters) != 1 {
+		t.Errorf("expected formatters to be copied, got %v", logger.Formatters)
+	}
+}
+
+func TestConcurrentReconfiguration(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+	}
+
+	logger.SetOutput(&bytes.Buffer{})
+
+	handler := logger.Middleware(&helloHandler{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			logger.SkipHeader([]string{"X-Header-" + fmt.Sprint(i)})
+			logger.SetFilter(func(req *http.Request) (bool, error) {
+				return strings.HasPrefix(req.URL.Path, "/skip"), nil
+			})
+			logger.SetFormatters(&JSONFormatter{})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	<-done
+}
@@ -0,0 +1,124 @@
+package httpretty
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// TrackConnections prints when the server closes a connection, how long it was idle, and how many
+// requests it served, explaining "connection reset" errors reported by clients reusing idle connections.
+// Connections are identified by their remote address, which is also printed for each request.
+//
+// It wraps the srv.ConnState hook, so call it before starting the server.
+func (l *Logger) TrackConnections(srv *http.Server) {
+	ct := &connTracker{
+		logger: l,
+		next:   srv.ConnState,
+		conns:  map[net.Conn]*connInfo{},
+
+		idleTimeout:       srv.IdleTimeout,
+		readHeaderTimeout: srv.ReadHeaderTimeout,
+	}
+
+	// see http.Server: ReadTimeout is used when the other timeouts are not set.
+	if ct.idleTimeout == 0 {
+		ct.idleTimeout = srv.ReadTimeout
+	}
+
+	if ct.readHeaderTimeout == 0 {
+		ct.readHeaderTimeout = srv.ReadTimeout
+	}
+
+	srv.ConnState = ct.track
+}
+
+type connTracker struct {
+	logger *Logger
+	next   func(net.Conn, http.ConnState)
+
+	idleTimeout       time.Duration
+	readHeaderTimeout time.Duration
+
+	mu    sync.Mutex
+	conns map[net.Conn]*connInfo
+}
+
+type connInfo struct {
+	state    http.ConnState
+	since    time.Time
+	requests int
+}
+
+func (ct *connTracker) track(c net.Conn, state http.ConnState) {
+	if ct.next != nil {
+		ct.next(c, state)
+	}
+
+	ct.mu.Lock()
+	info, ok := ct.conns[c]
+
+	if !ok {
+		info = &connInfo{}
+		ct.conns[c] = info
+	}
+
+	prev, since := info.state, info.since
+
+	if state == http.StateIdle && prev == http.StateActive {
+		info.requests++
+	}
+
+	info.state, info.since = state, time.Now()
+
+	if state == http.StateClosed || state == http.StateHijacked {
+		delete(ct.conns, c)
+	}
+
+	requests := info.requests
+	ct.mu.Unlock()
+
+	if state == http.StateClosed && ok {
+		ct.printClosed(c.RemoteAddr().String(), prev, time.Since(since), requests)
+	}
+}
+
+func (ct *connTracker) printClosed(addr string, prev http.ConnState, d time.Duration, requests int) {
+	p := newPrinter(ct.logger)
+	defer p.flush()
+
+	from := p.format(color.FgBlue, "%s", addr)
+	d = d.Round(time.Millisecond)
+
+	switch prev {
+	case http.StateIdle:
+		p.printf("* Connection from %s closed while idle for %v, after serving %s%s\n",
+			from, d, countRequests(requests), timeoutHint("idle", d, ct.idleTimeout))
+	case http.StateNew:
+		p.printf("* Connection from %s closed without receiving a request after %v%s\n",
+			from, d, timeoutHint("read header", d, ct.readHeaderTimeout))
+	default:
+		p.printf("* Connection from %s closed after serving %s\n", from, countRequests(requests))
+	}
+}
+
+// timeoutHint explains a connection was likely closed by a server timeout.
+func timeoutHint(name string, d, timeout time.Duration) string {
+	if timeout <= 0 || d < timeout {
+		return ""
+	}
+
+	return " (" + name + " timeout: " + timeout.String() + ")"
+}
+
+func countRequests(n int) string {
+	if n == 1 {
+		return "1 request"
+	}
+
+	return strconv.Itoa(n) + " requests"
+}
@@ -0,0 +1,72 @@
+package httpretty
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTrackConnections(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	srv := &http.Server{
+		Handler:     helloHandler{},
+		IdleTimeout: 50 * time.Millisecond,
+	}
+
+	logger.TrackConnections(srv)
+
+	closed := make(chan struct{})
+	track := srv.ConnState
+
+	srv.ConnState = func(c net.Conn, state http.ConnState) {
+		track(c, state)
+
+		if state == http.StateClosed {
+			close(closed)
+		}
+	}
+
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	client := &http.Client{
+		Transport: newTransport(),
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://" + ln.Addr().String())
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		testBody(t, resp.Body, []byte("Hello, world!"))
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not closed by the server")
+	}
+
+	want := regexp.MustCompile(`^\* Connection from 127\.0\.0\.1:\d+ closed while idle for \S+, after serving 2 requests \(idle timeout: 50ms\)\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged %q; want %v", got, want)
+	}
+}
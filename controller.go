@@ -0,0 +1,133 @@
+package httpretty
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// Controller enables full logging temporarily, such as to debug a production server for a few minutes.
+// While disabled, the logger only prints the request line summary (unless SkipRequestInfo is set).
+type Controller struct {
+	duration time.Duration
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// NewController attaches a controller to the logger, which starts disabled.
+// Enabling it turns on full logging for the given duration.
+func NewController(l *Logger, d time.Duration) *Controller {
+	c := &Controller{
+		duration: d,
+	}
+
+	l.updateConfig(func(cfg *config) {
+		cfg.controller = c
+	})
+
+	return c
+}
+
+// Enable full logging for the duration of the controller, starting now.
+func (c *Controller) Enable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.until = time.Now().Add(c.duration)
+}
+
+// Disable full logging.
+func (c *Controller) Disable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.until = time.Time{}
+}
+
+// Enabled checks if full logging is enabled.
+func (c *Controller) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Before(c.until)
+}
+
+// NotifySignal enables full logging whenever the process receives one of the signals (such as syscall.SIGUSR1).
+// Call stop to stop listening to the signals.
+func (c *Controller) NotifySignal(sig ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				c.Enable()
+			}
+		}
+	}()
+
+	return stopper(done, func() {
+		signal.Stop(ch)
+	})
+}
+
+// WatchFile enables full logging whenever the modification time of the named file changes
+// (for example, when you touch it), checking it at every interval.
+// Call stop to stop watching the file.
+func (c *Controller) WatchFile(name string, interval time.Duration) (stop func()) {
+	modTime := func() time.Time {
+		if fi, err := os.Stat(name); err == nil {
+			return fi.ModTime()
+		}
+
+		return time.Time{}
+	}
+
+	last := modTime()
+
+	return c.watch(func() bool {
+		m := modTime()
+		changed := !m.Equal(last)
+		last = m
+		return changed
+	}, interval)
+}
+
+func (c *Controller) watch(changed func() bool, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if changed() {
+					c.Enable()
+				}
+			}
+		}
+	}()
+
+	return stopper(done, nil)
+}
+
+func stopper(done chan struct{}, cleanup func()) (stop func()) {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			if cleanup != nil {
+				cleanup()
+			}
+
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// +build !windows,!plan9
+
+package httpretty
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestControllerNotifySignal(t *testing.T) {
+	t.Parallel()
+
+	c := NewController(&Logger{}, time.Minute)
+	stop := c.NotifySignal(syscall.SIGUSR1)
+	defer stop()
+
+	if c.Enabled() {
+		t.Errorf("controller should be disabled before the signal is received")
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+
+	if err != nil {
+		t.Fatalf("cannot find process: %v", err)
+	}
+
+	if err := p.Signal(syscall.SIGUSR1); err != nil {
+		t.Fatalf("cannot send signal: %v", err)
+	}
+
+	waitEnabled(t, c)
+}
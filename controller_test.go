@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestController(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		ResponseBody: true,
+	}
+
+	c := NewController(logger, time.Minute)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	summary := fmt.Sprintf("* Request to %s\n", ts.URL)
+
+	testCases := []struct {
+		name   string
+		toggle func()
+		want   string
+	}{
+		{name: "disabled", toggle: func() {}, want: summary},
+		{name: "enabled", toggle: c.Enable, want: summary + "Hello, world!\n"},
+		{name: "disabled again", toggle: c.Disable, want: summary},
+	}
+
+	for _, tc := range testCases {
+		tc.toggle()
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		if _, err := client.Get(ts.URL); err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: logged HTTP request %s; want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestControllerExpires(t *testing.T) {
+	t.Parallel()
+
+	c := NewController(&Logger{}, 10*time.Millisecond)
+	c.Enable()
+
+	if !c.Enabled() {
+		t.Errorf("controller should be enabled")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if c.Enabled() {
+		t.Errorf("controller should be disabled once its duration passes")
+	}
+}
+
+func TestControllerWatchFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "httpretty")
+
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "debug")
+	c := NewController(&Logger{}, time.Minute)
+	stop := c.WatchFile(name, time.Millisecond)
+	defer stop()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if c.Enabled() {
+		t.Errorf("controller should be disabled before the file changes")
+	}
+
+	if err := ioutil.WriteFile(name, nil, 0600); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	waitEnabled(t, c)
+}
+
+func waitEnabled(t *testing.T, c *Controller) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if c.Enabled() {
+			return
+		}
+	}
+
+	t.Errorf("controller should be enabled")
+}
@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// isCORSPreflight checks if the request is a CORS preflight request.
+// See https://fetch.spec.whatwg.org/#cors-preflight-request
+func isCORSPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions &&
+		req.Header.Get("Origin") != "" &&
+		req.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// printCORSPreflight prints a summary of the CORS negotiation of a preflight request.
+func (p *printer) printCORSPreflight(req *http.Request, h http.Header) {
+	if !isCORSPreflight(req) {
+		return
+	}
+
+	origin := req.Header.Get("Origin")
+	method := req.Header.Get("Access-Control-Request-Method")
+	requestedHeaders := splitList(req.Header.Get("Access-Control-Request-Headers"))
+
+	p.printf("* CORS preflight from %s\n", p.format(color.FgBlue, "%s", origin))
+	p.printf("*  requested method: %s\n", method)
+
+	if len(requestedHeaders) != 0 {
+		p.printf("*  requested headers: %s\n", strings.Join(requestedHeaders, ", "))
+	}
+
+	for _, v := range []struct {
+		name   string
+		header string
+	}{
+		{"allowed origin", "Access-Control-Allow-Origin"},
+		{"allowed methods", "Access-Control-Allow-Methods"},
+		{"allowed headers", "Access-Control-Allow-Headers"},
+		{"allow credentials", "Access-Control-Allow-Credentials"},
+		{"max age", "Access-Control-Max-Age"},
+	} {
+		if value := h.Get(v.header); value != "" {
+			p.printf("*  %s: %s\n", v.name, value)
+		}
+	}
+
+	if reason := corsDenied(origin, method, requestedHeaders, h); reason != "" {
+		p.printf("*  %s\n", p.format(color.FgRed, "denied: %s", reason))
+		return
+	}
+
+	p.printf("*  %s\n", p.format(color.FgGreen, "allowed"))
+}
+
+// corsDenied returns why a preflight request is denied, or an empty string if it is allowed.
+func corsDenied(origin, method string, requestedHeaders []string, h http.Header) (reason string) {
+	allowOrigin := h.Get("Access-Control-Allow-Origin")
+	credentials := h.Get("Access-Control-Allow-Credentials") == "true"
+
+	switch {
+	case allowOrigin == "":
+		return "origin not allowed (no Access-Control-Allow-Origin)"
+	case allowOrigin == "*" && credentials:
+		return "wildcard origin cannot be used with credentials"
+	case allowOrigin != "*" && allowOrigin != origin:
+		return "origin not allowed"
+	}
+
+	methods := splitList(h.Get("Access-Control-Allow-Methods"))
+
+	if !isCORSSafelistedMethod(method) && !containsToken(methods, method, !credentials) {
+		return "method " + method + " not allowed"
+	}
+
+	headers := splitList(h.Get("Access-Control-Allow-Headers"))
+
+	for _, requested := range requestedHeaders {
+		if !containsToken(headers, requested, !credentials) {
+			return "header " + requested + " not allowed"
+		}
+	}
+
+	return ""
+}
+
+func isCORSSafelistedMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPost
+}
+
+// containsToken checks if a token is on a list, case-insensitively.
+// The wildcard "*" matches any token if it is allowed (it is not when credentials are used).
+func containsToken(list []string, token string, wildcard bool) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, token) || (wildcard && v == "*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	var list []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,81 @@
+package httpretty
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCORSDenied(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		method  string
+		headers []string
+		h       http.Header
+		want    string
+	}{
+		{
+			name:   "no allow origin",
+			method: "PUT",
+			h:      http.Header{},
+			want:   "origin not allowed (no Access-Control-Allow-Origin)",
+		},
+		{
+			name:   "other origin",
+			method: "PUT",
+			h:      http.Header{"Access-Control-Allow-Origin": {"https://other.example.com"}},
+			want:   "origin not allowed",
+		},
+		{
+			name:   "wildcard with credentials",
+			method: "GET",
+			h: http.Header{
+				"Access-Control-Allow-Origin":      {"*"},
+				"Access-Control-Allow-Credentials": {"true"},
+			},
+			want: "wildcard origin cannot be used with credentials",
+		},
+		{
+			name:   "safelisted method",
+			method: "POST",
+			h:      http.Header{"Access-Control-Allow-Origin": {"*"}},
+		},
+		{
+			name:   "method not allowed",
+			method: "DELETE",
+			h: http.Header{
+				"Access-Control-Allow-Origin":  {"https://app.example.com"},
+				"Access-Control-Allow-Methods": {"GET, PUT"},
+			},
+			want: "method DELETE not allowed",
+		},
+		{
+			name:    "wildcards",
+			method:  "DELETE",
+			headers: []string{"X-Token"},
+			h: http.Header{
+				"Access-Control-Allow-Origin":  {"*"},
+				"Access-Control-Allow-Methods": {"*"},
+				"Access-Control-Allow-Headers": {"*"},
+			},
+		},
+		{
+			name:    "wildcard headers with credentials",
+			method:  "GET",
+			headers: []string{"X-Token"},
+			h: http.Header{
+				"Access-Control-Allow-Origin":      {"https://app.example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Allow-Headers":     {"*"},
+			},
+			want: "header X-Token not allowed",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := corsDenied("https://app.example.com", tc.method, tc.headers, tc.h); got != tc.want {
+			t.Errorf("%s: corsDenied() = %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
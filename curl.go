@@ -0,0 +1,114 @@
+package httpretty
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/header"
+)
+
+// curlCommand builds a curl command reproducing the request, with sanitized and redacted values kept masked.
+// Bodies that aren't too long to print are read fully and replaced. See Logger.CurlCommand.
+func (p *printer) curlCommand(req *http.Request, tlsConfig *tls.Config) (cmd, note string) {
+	args := []string{"curl"}
+
+	if tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+		args = append(args, "--insecure")
+	}
+
+	var body []byte
+
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case isStreamedBody(req.Body):
+		note = "body is streamed"
+	default:
+		var ok bool
+		body, ok, req.Body = readBodyLimit(req.Body, req.ContentLength, p.logger.MaxRequestBody)
+
+		switch {
+		case !ok:
+			note = "body is too long to print"
+		case isBinary(body):
+			body, note = nil, "body contains binary data"
+		}
+	}
+
+	if note != "" || req.Method != http.MethodGet && !(req.Method == http.MethodPost && len(body) != 0) {
+		args = append(args, "--request", shellQuote(req.Method))
+	}
+
+	to := req.URL.String()
+
+	if !p.logger.SkipSanitize {
+		to = sanitizeUserinfo(req.URL, p.logger.redact)
+	}
+
+	args = append(args, shellQuote(p.redactURL(to)))
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "--header", shellQuote("Host: "+req.Host))
+	}
+
+	skipped := p.config.skipHeader
+	keys := make([]string, 0, len(req.Header))
+
+	for key := range req.Header {
+		if _, skip := skipped[key]; !skip {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var sanitize header.SanitizeHeaderFunc
+
+		if !p.logger.SkipSanitize {
+			sanitize = p.logger.sanitizer(key)
+		}
+
+		if p.redaction.redactsHeader(key) {
+			sanitize = p.logger.redact
+		}
+
+		for _, v := range req.Header[key] {
+			if sanitize != nil {
+				v = sanitize(v)
+			}
+
+			args = append(args, "--header", shellQuote(key+": "+v))
+		}
+	}
+
+	if len(body) != 0 {
+		if patterns := p.secretPatterns(); len(patterns) != 0 {
+			body, _ = redactSecrets(patterns, body, p.logger.redact)
+		}
+
+		args = append(args, "--data-binary", shellQuote(string(body)))
+	}
+
+	return strings.Join(args, " "), note
+}
+
+func (p *printer) printCurlCommand(cmd, note string) {
+	p.printf("* curl command: %s\n", cmd)
+
+	if note != "" {
+		p.printf("* curl command doesn't include the request body: %s\n", note)
+	}
+}
+
+// shellQuote quotes a string for POSIX shells, unless it is safe to use as it is.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) == -1 {
+		return s
+	}
+
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
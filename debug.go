@@ -0,0 +1,255 @@
+package httpretty
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DebugPath is the conventional path for serving an ExchangeStore, following net/http/pprof,
+// such as with mux.Handle(httpretty.DebugPath, logger.Store).
+const DebugPath = "/debug/httpretty"
+
+// ServeHTTP dumps the recent exchanges kept by the store, from the oldest to the newest,
+// so the traffic of a running process can be fetched on demand without logging it continuously.
+//
+// The following query parameters are supported:
+// format=json to get a HAR (HTTP Archive) 1.2 document instead of text;
+// n to limit the number of exchanges to the most recent ones;
+// path, min_status, and max_status to filter the exchanges, as in a Query.
+//
+// Exchanges are redacted as they are in the log of the logger that saved them: headers skipped with SkipHeader
+// are left out, and headers, URLs, and bodies are sanitized and have their secrets redacted the same way.
+// Other content of the bodies is dumped as it is, so protect it as you would protect the net/http/pprof handlers.
+func (s *ExchangeStore) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+
+	q := Query{
+		Path: params.Get("path"),
+	}
+
+	n, err := debugParam(params.Get("n"))
+
+	if err == nil {
+		q.MinStatus, err = debugParam(params.Get("min_status"))
+	}
+
+	if err == nil {
+		q.MaxStatus, err = debugParam(params.Get("max_status"))
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exchanges := s.findDebug(q)
+
+	if n > 0 && len(exchanges) > n {
+		exchanges = exchanges[len(exchanges)-n:]
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	switch format := params.Get("format"); format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		writeDebugHAR(w, exchanges)
+	case "", "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeDebugText(w, exchanges)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+	}
+}
+
+func debugParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", v)
+	}
+
+	return n, nil
+}
+
+// debugExchange is an exchange to dump, with how the logger that saved it redacts it.
+type debugExchange struct {
+	Exchange
+
+	redaction exchangeRedaction
+	skipped   map[string]struct{}
+}
+
+// findDebug finds the exchanges to dump, as Find does.
+func (s *ExchangeStore) findDebug(q Query) []debugExchange {
+	s.mu.Lock()
+	s.evict()
+
+	var exchanges []debugExchange
+
+	for _, se := range s.exchanges {
+		if se.match(q) {
+			exchanges = append(exchanges, debugExchange{
+				Exchange:  se.exchange(),
+				redaction: se.redaction,
+			})
+		}
+	}
+
+	s.mu.Unlock()
+
+	for i, e := range exchanges {
+		if e.redaction.logger == nil {
+			exchanges[i].redaction.logger = &Logger{}
+		}
+
+		l := exchanges[i].redaction.logger
+		l.mu.Lock()
+		exchanges[i].skipped = l.getConfig().skipHeader
+		l.mu.Unlock()
+	}
+
+	return exchanges
+}
+
+// header returns a copy of the header without the skipped headers, and with sanitized values.
+func (e debugExchange) header(h http.Header) http.Header {
+	sanitized := e.redaction.header(h)
+
+	for key := range sanitized {
+		if _, skip := e.skipped[key]; skip {
+			delete(sanitized, key)
+		}
+	}
+
+	return sanitized
+}
+
+func writeDebugHAR(w io.Writer, exchanges []debugExchange) {
+	entries := []harEntry{}
+
+	for _, e := range exchanges {
+		er := e.redaction
+		reqBody, _ := ioutil.ReadAll(e.Request.Body)
+		req := e.Request.Clone(e.Request.Context())
+		req.Header = e.header(req.Header)
+
+		entry := harEntry{
+			StartedDateTime: e.StartedAt,
+			Time:            float64(e.Duration) / float64(time.Millisecond),
+			Request:         newHARRequest(req, er.body(reqBody)),
+			Response: harResponse{
+				HTTPVersion: req.Proto,
+				Cookies:     []harCookie{},
+				Headers:     []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+		}
+
+		entry.Timings.Wait = entry.Time
+
+		if len(reqBody) == 0 {
+			entry.Request.PostData = nil
+		}
+
+		if e.Response != nil {
+			respBody, _ := ioutil.ReadAll(e.Response.Body)
+			resp := *e.Response
+			resp.Header = e.header(resp.Header)
+			entry.Response = newHARResponse(&resp, er.body(respBody))
+		}
+
+		er.harEntry(&entry, req)
+		entries = append(entries, entry)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(harDocument{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{
+				Name:    "httpretty",
+				Version: "1",
+			},
+			Entries: entries,
+		},
+	})
+}
+
+func writeDebugText(w io.Writer, exchanges []debugExchange) {
+	if len(exchanges) == 0 {
+		io.WriteString(w, "* no exchanges\n")
+		return
+	}
+
+	for i, e := range exchanges {
+		if i != 0 {
+			io.WriteString(w, "\n")
+		}
+
+		fmt.Fprintf(w, "* exchange %d of %d at %s, took %v\n", i+1, len(exchanges), e.StartedAt.Format(time.RFC3339Nano), e.Duration)
+
+		req := e.Request
+		fmt.Fprintf(w, "> %s %s %s\n", req.Method, e.redaction.uri(req.URL.RequestURI()), req.Proto)
+		fmt.Fprintf(w, "> Host: %s\n", req.URL.Host)
+		writeDebugHeader(w, '>', e.header(req.Header))
+
+		if body, _ := ioutil.ReadAll(req.Body); len(body) != 0 {
+			writeDebugBody(w, e.redaction.body(body))
+		}
+
+		if e.Response == nil {
+			io.WriteString(w, "* no response\n")
+			continue
+		}
+
+		fmt.Fprintf(w, "< %s %s\n", e.Response.Proto, e.Response.Status)
+		writeDebugHeader(w, '<', e.header(e.Response.Header))
+
+		if body, _ := ioutil.ReadAll(e.Response.Body); len(body) != 0 {
+			writeDebugBody(w, e.redaction.body(body))
+		}
+	}
+}
+
+func writeDebugHeader(w io.Writer, prefix rune, h http.Header) {
+	keys := make([]string, 0, len(h))
+
+	for key := range h {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, v := range h[key] {
+			fmt.Fprintf(w, "%c %s: %s\n", prefix, key, v)
+		}
+	}
+
+	io.WriteString(w, "\n")
+}
+
+func writeDebugBody(w io.Writer, body []byte) {
+	if isBinary(body) {
+		fmt.Fprintf(w, "* body contains binary data (%s)\n", formatBytes(int64(len(body))))
+		return
+	}
+
+	w.Write(body)
+
+	if body[len(body)-1] != '\n' {
+		io.WriteString(w, "\n")
+	}
+}
@@ -0,0 +1,21 @@
+package httpretty
+
+// binaryDecoders for protocols carried over HTTP with binary bodies, which are printed
+// in a readable form instead of being skipped as binary data.
+var binaryDecoders = map[string]func(body []byte) (string, error){
+	"application/dns-message":   decodeDNSMessage,
+	"application/ocsp-request":  decodeOCSPRequest,
+	"application/ocsp-response": decodeOCSPResponse,
+}
+
+func (p *printer) printDecodedBody(mediatype string, decode func([]byte) (string, error), body []byte) {
+	s, err := decode(body)
+
+	if err != nil {
+		p.printf("* cannot decode %s body: %v\n", mediatype, err)
+		p.println("* body contains binary data")
+		return
+	}
+
+	p.println(s)
+}
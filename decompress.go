@@ -0,0 +1,50 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// printTransparentDecompression explains that the transport decompressed the response body,
+// removing the Content-Encoding and Content-Length headers.
+// The original size is not available because the transport doesn't expose it.
+func (p *printer) printTransparentDecompression(resp *http.Response) {
+	max := p.logger.MaxResponseBody
+
+	if max == 0 {
+		max = maxDefaultUnknownReadable
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, resp.Body, max+1)
+	resp.Body = newBodyReaderBuf(&buf, resp.Body)
+
+	switch {
+	case err != nil && err != io.EOF:
+		p.printf("* body was transparently gunzipped by the transport; cannot read it: %v\n", err)
+	case n > max:
+		p.printf("* body was transparently gunzipped by the transport; original size unknown, decoded more than %s\n", formatBytes(max))
+	default:
+		p.printf("* body was transparently gunzipped by the transport; original size unknown, decoded %s\n", formatBytes(n))
+	}
+}
+
+// formatBytes using binary prefixes, such as 1.5 KiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,25 @@
+package httpretty
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1126, want: "1.1 KiB"},
+		{n: 8602, want: "8.4 KiB"},
+		{n: 5 << 20, want: "5.0 MiB"},
+		{n: 3 << 30, want: "3.0 GiB"},
+	}
+
+	for _, tc := range testCases {
+		if got := formatBytes(tc.n); got != tc.want {
+			t.Errorf("formatBytes(%d) = %v, wanted %v", tc.n, got, tc.want)
+		}
+	}
+}
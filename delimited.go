@@ -0,0 +1,206 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// DelimitedStreamFormatter helps you read streams of custom-framed data, such as records separated by
+// blank lines or by the ASCII record separator, printing each frame on its own with its index.
+//
+// Responses received by the client are printed frame by frame as they are read, with the time each frame
+// was received, instead of after the body is read ahead. Frames are formatted using Logger.Formatters
+// when FrameMediatype is set.
+type DelimitedStreamFormatter struct {
+	// Mediatypes of the streams, such as "application/x-records".
+	Mediatypes []string
+
+	// Delimiter between frames, such as []byte("\n\n") or []byte("\x1e").
+	Delimiter []byte
+
+	// FrameMediatype is the media type of each frame, such as "application/json".
+	FrameMediatype string
+
+	now func() time.Time
+}
+
+// Match the stream media types.
+func (d *DelimitedStreamFormatter) Match(mediatype string) bool {
+	return len(d.Delimiter) != 0 && containsString(d.Mediatypes, mediatype)
+}
+
+// Format the stream, listing its frames.
+func (d *DelimitedStreamFormatter) Format(w io.Writer, src []byte) error {
+	if len(d.Delimiter) == 0 {
+		return errors.New("DelimitedStreamFormatter requires a delimiter")
+	}
+
+	frames := bytes.Split(src, d.Delimiter)
+
+	// a delimiter after the last frame doesn't start a new one.
+	if len(frames) > 1 && len(frames[len(frames)-1]) == 0 {
+		frames = frames[:len(frames)-1]
+	}
+
+	for i, frame := range frames {
+		if i != 0 {
+			io.WriteString(w, "\n")
+		}
+
+		if _, err := fmt.Fprintf(w, "[frame %d, %s]\n%s", i+1, formatBytes(int64(len(frame))), frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// delimitedStream returns the formatter for the response body if it is a delimited stream.
+func (p *printer) delimitedStream(h http.Header) *DelimitedStreamFormatter {
+	mediatype, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+
+	for _, f := range p.formatters {
+		if d, ok := f.(*DelimitedStreamFormatter); ok && p.safeBodyMatch(d, mediatype) {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// frameBody prints each frame of the body as it is read.
+func (p *printer) frameBody(d *DelimitedStreamFormatter, body io.ReadCloser) io.ReadCloser {
+	max := p.logger.MaxResponseBody
+
+	if max == 0 {
+		max = maxDefaultUnknownReadable
+	}
+
+	now := time.Now
+
+	if d.now != nil {
+		now = d.now
+	}
+
+	p.println("* body is a delimited stream, printing each frame as it is read")
+
+	return &frameReader{
+		ReadCloser: body,
+		logger:     p.logger,
+		redaction:  p.redaction,
+		format:     d,
+		max:        max,
+		now:        now,
+	}
+}
+
+// frameReader splits a body into frames as it is read.
+type frameReader struct {
+	io.ReadCloser
+
+	logger    *Logger
+	redaction *Redaction
+	format    *DelimitedStreamFormatter
+	max       int64
+	now       func() time.Time
+
+	buf      bytes.Buffer
+	frames   int
+	skipping bool // the current frame is too long and is discarded until the next delimiter
+	done     bool
+}
+
+func (fr *frameReader) Read(b []byte) (int, error) {
+	n, err := fr.ReadCloser.Read(b)
+	fr.buf.Write(b[:n])
+	fr.printFrames(err)
+	return n, err
+}
+
+func (fr *frameReader) printFrames(err error) {
+	if fr.done {
+		return
+	}
+
+	delimiter := fr.format.Delimiter
+
+	for {
+		i := bytes.Index(fr.buf.Bytes(), delimiter)
+
+		if i == -1 {
+			break
+		}
+
+		frame := fr.buf.Next(i)
+		fr.buf.Next(len(delimiter))
+
+		if fr.skipping {
+			fr.skipping = false
+			continue
+		}
+
+		fr.printFrame(frame)
+	}
+
+	if !fr.skipping && int64(fr.buf.Len()) > fr.max {
+		fr.frames++
+		fr.skipping = true
+
+		p := newPrinter(fr.logger)
+		p.printf("* frame %d is too long, skipping (longer than %d bytes)\n", fr.frames, fr.max)
+		p.flush()
+	}
+
+	// keep only what might be the beginning of a delimiter when discarding a frame.
+	if fr.skipping && fr.buf.Len() >= len(delimiter) {
+		fr.buf.Next(fr.buf.Len() - len(delimiter) + 1)
+	}
+
+	if err == nil {
+		return
+	}
+
+	fr.done = true
+
+	if err == io.EOF && fr.buf.Len() != 0 && !fr.skipping {
+		fr.printFrame(fr.buf.Bytes())
+	}
+
+	fr.buf.Reset()
+
+	p := newPrinter(fr.logger)
+	defer p.flush()
+
+	if err != io.EOF {
+		p.printf("* stream interrupted after %d frames: %v\n", fr.frames, err)
+		return
+	}
+
+	p.printf("* stream ended after %d frames\n", fr.frames)
+}
+
+func (fr *frameReader) printFrame(frame []byte) {
+	fr.frames++
+
+	p := newPrinter(fr.logger)
+	p.redaction = fr.redaction
+	defer p.flush()
+
+	p.printf("* frame %d received at %s (%s)\n", fr.frames, p.formatTime(fr.now()), formatBytes(int64(len(frame))))
+
+	if len(frame) != 0 {
+		p.printBody(fr.format.FrameMediatype, frame)
+	}
+}
+
+var errStreamClosed = errors.New("body closed before the end of the stream")
+
+func (fr *frameReader) Close() error {
+	fr.printFrames(errStreamClosed)
+	return fr.ReadCloser.Close()
+}
@@ -0,0 +1,180 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDelimitedStreamFormatter(t *testing.T) {
+	t.Parallel()
+
+	d := &DelimitedStreamFormatter{
+		Mediatypes: []string{"application/x-records"},
+		Delimiter:  []byte("\x1e"),
+	}
+
+	if !d.Match("application/x-records") {
+		t.Error("expected formatter to match stream media type")
+	}
+
+	if d.Match("text/plain") {
+		t.Error("expected formatter to not match other media types")
+	}
+
+	var buf bytes.Buffer
+
+	if err := d.Format(&buf, []byte("first\x1esecond record\x1e")); err != nil {
+		t.Fatalf("cannot format stream: %v", err)
+	}
+
+	want := `[frame 1, 5 B]
+first
+[frame 2, 13 B]
+second record`
+
+	if got := buf.String(); got != want {
+		t.Errorf("formatted stream = %q, wanted %q", got, want)
+	}
+}
+
+func TestOutgoingDelimitedStream(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/x-records")
+		w.Header()["Date"] = nil
+
+		for _, frame := range []string{`{"id":1}`, `{"id":2}`, `{"id":3}`} {
+			w.Write([]byte(frame + "\n\n"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+		TimeFormat:      "15:04:05.000",
+		Location:        time.UTC,
+		Formatters: []Formatter{
+			&DelimitedStreamFormatter{
+				Mediatypes:     []string{"application/x-records"},
+				Delimiter:      []byte("\n\n"),
+				FrameMediatype: "application/json",
+				now: func() time.Time {
+					return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+				},
+			},
+			&JSONFormatter{},
+		},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		t.Fatalf("cannot read body: %v", err)
+	}
+
+	resp.Body.Close()
+
+	if want := "{\"id\":1}\n\n{\"id\":2}\n\n{\"id\":3}\n\n"; string(body) != want {
+		t.Errorf("body = %q, wanted %q", body, want)
+	}
+
+	want := `< HTTP/1.1 200 OK
+< Content-Type: application/x-records
+
+* body is a delimited stream, printing each frame as it is read
+* frame 1 received at 12:00:00.000 (8 B)
+{
+    "id": 1
+}
+* frame 2 received at 12:00:00.000 (8 B)
+{
+    "id": 2
+}
+* frame 3 received at 12:00:00.000 (8 B)
+{
+    "id": 3
+}
+* stream ended after 3 frames
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingDelimitedStreamInterrupted(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/x-records")
+		w.Write([]byte("one\x1etwo\x1epartial"))
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+		Formatters: []Formatter{
+			&DelimitedStreamFormatter{
+				Mediatypes: []string{"application/x-records"},
+				Delimiter:  []byte("\x1e"),
+				now: func() time.Time {
+					return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+				},
+			},
+		},
+		TimeFormat: time.Kitchen,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	b := make([]byte, 7)
+
+	if _, err := resp.Body.Read(b); err != nil {
+		t.Fatalf("cannot read body: %v", err)
+	}
+
+	resp.Body.Close()
+
+	want := `* body is a delimited stream, printing each frame as it is read
+* frame 1 received at 12:00PM (3 B)
+one
+* stream interrupted after 1 frames: body closed before the end of the stream
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
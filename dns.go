@@ -0,0 +1,328 @@
+package httpretty
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DNS message, as used by DNS over HTTPS (application/dns-message).
+// See https://tools.ietf.org/html/rfc8484 and https://tools.ietf.org/html/rfc1035#section-4
+
+var errDNSTruncated = errors.New("message is truncated")
+
+var dnsOpcodes = map[uint16]string{
+	0: "QUERY",
+	1: "IQUERY",
+	2: "STATUS",
+	4: "NOTIFY",
+	5: "UPDATE",
+}
+
+var dnsRcodes = map[uint16]string{
+	0: "NOERROR",
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+const (
+	dnsTypeA     = 1
+	dnsTypeNS    = 2
+	dnsTypeCNAME = 5
+	dnsTypeSOA   = 6
+	dnsTypePTR   = 12
+	dnsTypeMX    = 15
+	dnsTypeTXT   = 16
+	dnsTypeAAAA  = 28
+	dnsTypeSRV   = 33
+	dnsTypeOPT   = 41
+)
+
+var dnsTypes = map[uint16]string{
+	dnsTypeA:     "A",
+	dnsTypeNS:    "NS",
+	dnsTypeCNAME: "CNAME",
+	dnsTypeSOA:   "SOA",
+	dnsTypePTR:   "PTR",
+	dnsTypeMX:    "MX",
+	dnsTypeTXT:   "TXT",
+	dnsTypeAAAA:  "AAAA",
+	dnsTypeSRV:   "SRV",
+	dnsTypeOPT:   "OPT",
+	64:           "SVCB",
+	65:           "HTTPS",
+	255:          "ANY",
+	257:          "CAA",
+}
+
+var dnsClasses = map[uint16]string{
+	1:   "IN",
+	3:   "CH",
+	4:   "HS",
+	255: "ANY",
+}
+
+func dnsName(names map[uint16]string, prefix string, v uint16) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+
+	return prefix + strconv.Itoa(int(v))
+}
+
+// decodeDNSMessage prints a DNS message in a format similar to the one used by dig.
+func decodeDNSMessage(msg []byte) (string, error) {
+	if len(msg) < 12 {
+		return "", errDNSTruncated
+	}
+
+	var (
+		id     = binary.BigEndian.Uint16(msg[0:])
+		flags  = binary.BigEndian.Uint16(msg[2:])
+		counts = [4]uint16{
+			binary.BigEndian.Uint16(msg[4:]),
+			binary.BigEndian.Uint16(msg[6:]),
+			binary.BigEndian.Uint16(msg[8:]),
+			binary.BigEndian.Uint16(msg[10:]),
+		}
+	)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ";; opcode: %s, status: %s, id: %d\n",
+		dnsName(dnsOpcodes, "", flags>>11&0xF), dnsName(dnsRcodes, "RCODE", flags&0xF), id)
+
+	var set []string
+
+	for _, f := range []struct {
+		bit  uint
+		name string
+	}{{15, "qr"}, {10, "aa"}, {9, "tc"}, {8, "rd"}, {7, "ra"}, {5, "ad"}, {4, "cd"}} {
+		if flags&(1<<f.bit) != 0 {
+			set = append(set, f.name)
+		}
+	}
+
+	fmt.Fprintf(&b, ";; flags: %s; QUERY: %d, ANSWER: %d, AUTHORITY: %d, ADDITIONAL: %d\n",
+		strings.Join(set, " "), counts[0], counts[1], counts[2], counts[3])
+
+	d := &dnsDecoder{msg: msg, off: 12}
+
+	if counts[0] != 0 {
+		b.WriteString("\n;; QUESTION SECTION:\n")
+	}
+
+	for i := 0; i < int(counts[0]); i++ {
+		name, err := d.name()
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(msg) < d.off+4 {
+			return "", errDNSTruncated
+		}
+
+		typ := binary.BigEndian.Uint16(msg[d.off:])
+		class := binary.BigEndian.Uint16(msg[d.off+2:])
+		d.off += 4
+
+		fmt.Fprintf(&b, ";%s\t%s\t%s\n", name, dnsName(dnsClasses, "CLASS", class), dnsName(dnsTypes, "TYPE", typ))
+	}
+
+	for i, section := range []string{"ANSWER", "AUTHORITY", "ADDITIONAL"} {
+		if counts[i+1] != 0 {
+			fmt.Fprintf(&b, "\n;; %s SECTION:\n", section)
+		}
+
+		for j := 0; j < int(counts[i+1]); j++ {
+			rr, err := d.resource()
+
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(rr)
+			b.WriteByte('\n')
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+type dnsDecoder struct {
+	msg []byte
+	off int
+}
+
+// name reads a domain name at the current offset.
+func (d *dnsDecoder) name() (string, error) {
+	name, off, err := d.nameAt(d.off)
+	d.off = off
+	return name, err
+}
+
+// nameAt reads a domain name at the given offset, returning the offset after it.
+// Compressed names are followed, but only backwards, so pointer loops aren't possible.
+func (d *dnsDecoder) nameAt(off int) (string, int, error) {
+	var (
+		labels []string
+		next   = -1
+		limit  = off
+	)
+
+	for {
+		if off >= len(d.msg) {
+			return "", 0, errDNSTruncated
+		}
+
+		c := int(d.msg[off])
+
+		switch c & 0xC0 {
+		case 0x00:
+			if c == 0 {
+				if next == -1 {
+					next = off + 1
+				}
+
+				return strings.Join(labels, ".") + ".", next, nil
+			}
+
+			if off+1+c > len(d.msg) {
+				return "", 0, errDNSTruncated
+			}
+
+			labels = append(labels, string(d.msg[off+1:off+1+c]))
+			off += 1 + c
+		case 0xC0:
+			if off+1 >= len(d.msg) {
+				return "", 0, errDNSTruncated
+			}
+
+			ptr := int(binary.BigEndian.Uint16(d.msg[off:]) & 0x3FFF)
+
+			if ptr >= limit {
+				return "", 0, errors.New("invalid compression pointer")
+			}
+
+			if next == -1 {
+				next = off + 2
+			}
+
+			off, limit = ptr, ptr
+		default:
+			return "", 0, errors.New("invalid label")
+		}
+	}
+}
+
+// resource reads a resource record at the current offset.
+func (d *dnsDecoder) resource() (string, error) {
+	name, err := d.name()
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(d.msg) < d.off+10 {
+		return "", errDNSTruncated
+	}
+
+	var (
+		typ    = binary.BigEndian.Uint16(d.msg[d.off:])
+		class  = binary.BigEndian.Uint16(d.msg[d.off+2:])
+		ttl    = binary.BigEndian.Uint32(d.msg[d.off+4:])
+		length = int(binary.BigEndian.Uint16(d.msg[d.off+8:]))
+		start  = d.off + 10
+	)
+
+	if len(d.msg) < start+length {
+		return "", errDNSTruncated
+	}
+
+	d.off = start + length
+	data := d.msg[start:d.off]
+
+	if typ == dnsTypeOPT {
+		// the class is the UDP payload size, and the TTL holds the extended flags.
+		var flags string
+
+		if ttl&0x8000 != 0 {
+			flags = " do"
+		}
+
+		return fmt.Sprintf("; EDNS: version: %d, flags:%s; udp: %d", ttl>>16&0xFF, flags, class), nil
+	}
+
+	rdata, err := d.rdata(typ, start, data)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s\t%d\t%s\t%s\t%s", name, ttl,
+		dnsName(dnsClasses, "CLASS", class), dnsName(dnsTypes, "TYPE", typ), rdata), nil
+}
+
+// rdata of a resource record, using the generic format of RFC 3597 for unknown types.
+func (d *dnsDecoder) rdata(typ uint16, start int, data []byte) (string, error) {
+	switch {
+	case typ == dnsTypeA && len(data) == net.IPv4len,
+		typ == dnsTypeAAAA && len(data) == net.IPv6len:
+		return net.IP(data).String(), nil
+	case typ == dnsTypeNS, typ == dnsTypeCNAME, typ == dnsTypePTR:
+		name, _, err := d.nameAt(start)
+		return name, err
+	case typ == dnsTypeMX && len(data) > 2:
+		name, _, err := d.nameAt(start + 2)
+		return fmt.Sprintf("%d %s", binary.BigEndian.Uint16(data), name), err
+	case typ == dnsTypeSRV && len(data) > 6:
+		name, _, err := d.nameAt(start + 6)
+		return fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(data), binary.BigEndian.Uint16(data[2:]),
+			binary.BigEndian.Uint16(data[4:]), name), err
+	case typ == dnsTypeTXT:
+		var txt []string
+
+		for len(data) != 0 {
+			n := int(data[0])
+
+			if len(data) < 1+n {
+				return "", errDNSTruncated
+			}
+
+			txt = append(txt, strconv.Quote(string(data[1:1+n])))
+			data = data[1+n:]
+		}
+
+		return strings.Join(txt, " "), nil
+	case typ == dnsTypeSOA:
+		mname, off, err := d.nameAt(start)
+
+		if err != nil {
+			return "", err
+		}
+
+		rname, off, err := d.nameAt(off)
+
+		if err != nil {
+			return "", err
+		}
+
+		if off+20 > start+len(data) {
+			return "", errDNSTruncated
+		}
+
+		v := d.msg[off:]
+		return fmt.Sprintf("%s %s %d %d %d %d %d", mname, rname,
+			binary.BigEndian.Uint32(v), binary.BigEndian.Uint32(v[4:]), binary.BigEndian.Uint32(v[8:]),
+			binary.BigEndian.Uint32(v[12:]), binary.BigEndian.Uint32(v[16:])), nil
+	}
+
+	return fmt.Sprintf(`\# %d %s`, len(data), hex.EncodeToString(data)), nil
+}
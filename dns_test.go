@@ -0,0 +1,107 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+// response to a query for the A records of example.com, using a compression pointer in the answer.
+var dnsResponse = []byte{
+	0x12, 0x34, // id
+	0x81, 0x80, // flags: qr rd ra
+	0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	// question
+	7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+	0x00, 0x01, 0x00, 0x01,
+	// answer
+	0xC0, 0x0C,
+	0x00, 0x01, 0x00, 0x01,
+	0x00, 0x00, 0x01, 0x2C, // TTL
+	0x00, 0x04, 93, 184, 216, 34,
+	// additional: OPT
+	0,
+	0x00, 0x29, 0x10, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00,
+}
+
+func TestDecodeDNSMessage(t *testing.T) {
+	t.Parallel()
+
+	got, err := decodeDNSMessage(dnsResponse)
+
+	if err != nil {
+		t.Fatalf("cannot decode message: %v", err)
+	}
+
+	want := `;; opcode: QUERY, status: NOERROR, id: 4660
+;; flags: qr rd ra; QUERY: 1, ANSWER: 1, AUTHORITY: 0, ADDITIONAL: 1
+
+;; QUESTION SECTION:
+;example.com.	IN	A
+
+;; ANSWER SECTION:
+example.com.	300	IN	A	93.184.216.34
+
+;; ADDITIONAL SECTION:
+; EDNS: version: 0, flags:; udp: 4096`
+
+	if got != want {
+		t.Errorf("decoded message = %q, wanted %q", got, want)
+	}
+}
+
+func TestDecodeDNSMessageInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		msg  []byte
+		want string
+	}{
+		{
+			name: "short header",
+			msg:  dnsResponse[:10],
+			want: "message is truncated",
+		},
+		{
+			name: "truncated answer",
+			msg:  dnsResponse[:40],
+			want: "message is truncated",
+		},
+		{
+			name: "pointer loop",
+			msg: []byte{
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0xC0, 0x0C, 0x00, 0x01, 0x00, 0x01,
+			},
+			want: "invalid compression pointer",
+		},
+	}
+
+	for _, tc := range testCases {
+		if _, err := decodeDNSMessage(tc.msg); err == nil || err.Error() != tc.want {
+			t.Errorf("decodeDNSMessage(%s) error = %v, wanted %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestPrintBodyDNSMessage(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	p := newPrinter(logger)
+	p.printBodyReader(http.Header{"Content-Type": []string{"application/dns-message"}}, bytes.NewReader(dnsResponse[:20]))
+	p.flush()
+
+	want := "* cannot decode application/dns-message body: message is truncated\n* body contains binary data\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged body = %q, wanted %q", got, want)
+	}
+}
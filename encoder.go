@@ -0,0 +1,439 @@
+package httpretty
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/henvic/httpretty/internal/header"
+)
+
+// Event describes an exchange for an Encoder.
+type Event struct {
+	// Time the request began.
+	Time time.Time `json:"time"`
+
+	// Sequence number of the exchange, if Logger.SequenceNumbers is set.
+	Sequence uint64 `json:"seq,omitempty"`
+
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Proto  string `json:"proto"`
+
+	// RemoteAddr of the client that sent the request. It is only known by the server.
+	RemoteAddr string `json:"remote_addr,omitempty"`
+
+	// Route, Fields, and Principal set with WithRoute, WithFields, and SetPrincipal.
+	Route     string            `json:"route,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Principal string            `json:"principal,omitempty"`
+
+	// RequestHeader and ResponseHeader are only set with Logger.RequestHeader and Logger.ResponseHeader.
+	// Values are sanitized as when they are printed.
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+
+	// RequestBody and ResponseBody are only set with Logger.RequestBody and Logger.ResponseBody,
+	// for bodies that aren't binary, streamed, or too long to print (see Logger.MaxRequestBody and Logger.MaxResponseBody).
+	// Secrets are redacted as when they are printed.
+	RequestBody  string `json:"request_body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+
+	// RequestBytes and ResponseBytes are the sizes of the bodies, or -1 if unknown.
+	RequestBytes  int64 `json:"request_bytes"`
+	ResponseBytes int64 `json:"response_bytes"`
+
+	// Status code of the response, or 0 if there was no response.
+	Status int `json:"status,omitempty"`
+
+	// Annotations attached to the exchange with Annotate.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Pagination links of the response from its Link headers, by relation (first, prev, next, and last).
+	// It is only set with Logger.ResponseHeader.
+	Pagination map[string]string `json:"pagination,omitempty"`
+
+	Duration time.Duration `json:"-"`
+
+	// Err is the error that interrupted the exchange, if any.
+	Err string `json:"error,omitempty"`
+}
+
+// Encoder writes events describing the exchanges, replacing the human-readable output. See Logger.SetEncoder.
+type Encoder interface {
+	Encode(w io.Writer, e *Event) error
+}
+
+// JSONEncoder writes each event as a JSON object on a line of its own, convenient for log pipelines such as ELK.
+// The duration is written as duration_ms, in milliseconds. Set it with SetEncoder(JSONEncoder{}).
+type JSONEncoder struct{}
+
+// Encode the event as a line of JSON.
+func (j JSONEncoder) Encode(w io.Writer, e *Event) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(struct {
+		*Event
+		DurationMS float64 `json:"duration_ms"`
+	}{
+		Event:      e,
+		DurationMS: float64(e.Duration) / float64(time.Millisecond),
+	})
+}
+
+// SetEncoder sets an encoder to write a single event for each exchange instead of the human-readable output,
+// with its method, URL, status, duration, and, depending on the printing options, its headers and bodies.
+// Other printing options are ignored.
+// Bodies are read before being passed along, unless they are streamed or declared too long to print.
+func (l *Logger) SetEncoder(e Encoder) {
+	l.updateConfig(func(c *config) {
+		c.encoder = e
+	})
+}
+
+// roundTripEncoded sends the request, printing an event for the exchange.
+func (p *printer) roundTripEncoded(enc Encoder, tripper http.RoundTripper, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	if _, ok := req.Context().Value(contextAnnotations{}).(*annotations); !ok {
+		req = req.WithContext(WithAnnotations(req.Context()))
+	}
+
+	var reqBody []byte
+	readReqBody := p.logger.RequestBody && req.Body != nil && req.Body != http.NoBody && !isStreamedBody(req.Body)
+
+	if readReqBody {
+		reqBody, readReqBody, req.Body = readBodyLimit(req.Body, req.ContentLength, p.logger.MaxRequestBody)
+	}
+
+	e := p.newEvent(req, start)
+	resp, err := tripper.RoundTrip(req)
+	e.Duration = time.Since(start)
+	e.Annotations = eventAnnotations(req)
+
+	switch {
+	case readReqBody:
+		e.RequestBytes = int64(len(reqBody))
+		e.RequestBody = p.eventBody(reqBody)
+	case req.ContentLength > 0 || req.Body == nil || req.Body == http.NoBody:
+		e.RequestBytes = req.ContentLength
+	}
+
+	if err != nil {
+		p.setErr(err)
+		e.Err = err.Error()
+	}
+
+	if resp != nil {
+		p.statusCode = resp.StatusCode
+		e.Status = resp.StatusCode
+		e.ResponseBytes = resp.ContentLength
+
+		if p.logger.ResponseHeader {
+			e.ResponseHeader = p.eventHeader(resp.Header)
+			e.Pagination = p.pagination(req.URL, resp.Header)
+		}
+
+		if p.logger.ResponseBody && resp.Body != nil && resp.Body != http.NoBody &&
+			!isStreamingResponse(resp.StatusCode, resp.Header) {
+			body, ok, newBody := readBodyLimit(resp.Body, resp.ContentLength, p.logger.MaxResponseBody)
+			resp.Body = newBody
+
+			if ok {
+				e.ResponseBytes = int64(len(body))
+				e.ResponseBody = p.eventBody(body)
+			}
+		}
+	}
+
+	p.printEvent(enc, e)
+	return resp, err
+}
+
+// serveEncoded serves the request, printing an event for the exchange.
+func (p *printer) serveEncoded(enc Encoder, next http.Handler, w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	req = req.WithContext(WithAnnotations(req.Context()))
+
+	var reqBody []byte
+	readReqBody := p.logger.RequestBody && req.Body != nil && req.Body != http.NoBody
+
+	if readReqBody {
+		reqBody, readReqBody, req.Body = readBodyLimit(req.Body, req.ContentLength, p.logger.MaxRequestBody)
+	}
+
+	body := &countingBody{ReadCloser: req.Body}
+	req.Body = body
+
+	rw := &eventResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+		keep:           p.logger.ResponseBody,
+		max:            p.logger.MaxResponseBody,
+	}
+
+	defer func() {
+		e := p.newEvent(req, start)
+		p.statusCode = rw.statusCode
+		e.Status = rw.statusCode
+		e.Duration = time.Since(start)
+		e.Annotations = eventAnnotations(req)
+		e.RequestBytes = body.size()
+		e.ResponseBytes = rw.size
+
+		if readReqBody {
+			e.RequestBytes = int64(len(reqBody))
+			e.RequestBody = p.eventBody(reqBody)
+		}
+
+		if p.logger.ResponseHeader {
+			e.ResponseHeader = p.eventHeader(w.Header())
+			e.Pagination = p.pagination(requestURL(req), w.Header())
+		}
+
+		if rw.keep {
+			e.ResponseBody = p.eventBody(rw.body.Bytes())
+		}
+
+		p.printEvent(enc, e)
+	}()
+
+	next.ServeHTTP(rw, req)
+}
+
+// newEvent with the fields describing the request.
+func (p *printer) newEvent(req *http.Request, start time.Time) *Event {
+	a := p.newAccessLogEntry(req, start)
+
+	scheme := req.URL.Scheme
+
+	switch {
+	case scheme != "":
+	case req.TLS != nil:
+		scheme = "https"
+	default:
+		scheme = "http"
+	}
+
+	e := &Event{
+		Time:       start,
+		Sequence:   a.Sequence,
+		Method:     a.Method,
+		URL:        p.redactURL(scheme + "://" + a.Host + p.maskURI(req.URL.RequestURI())),
+		Proto:      a.Proto,
+		RemoteAddr: a.RemoteAddr,
+		Route:      a.Route,
+		Fields:     a.Fields,
+		Principal:  a.Principal,
+
+		RequestBytes:  -1,
+		ResponseBytes: -1,
+	}
+
+	if p.logger.RequestHeader {
+		e.RequestHeader = p.eventHeader(req.Header)
+	}
+
+	return e
+}
+
+// eventHeader returns a copy of the header without the skipped headers, and with sanitized values.
+func (p *printer) eventHeader(h http.Header) http.Header {
+	skipped := p.config.skipHeader
+	sanitized := http.Header{}
+
+	for key, values := range h {
+		if _, skip := skipped[key]; skip {
+			continue
+		}
+
+		var sanitize header.SanitizeHeaderFunc
+
+		if !p.logger.SkipSanitize {
+			sanitize = p.logger.sanitizer(key)
+		}
+
+		if p.redaction.redactsHeader(key) {
+			sanitize = p.logger.redact
+		}
+
+		for _, v := range values {
+			if sanitize != nil {
+				v = sanitize(v)
+			}
+
+			sanitized.Add(key, v)
+		}
+	}
+
+	return sanitized
+}
+
+// eventBody returns the body to include in an event, with the secrets redacted, or an empty string if it is binary.
+func (p *printer) eventBody(body []byte) string {
+	if isBinary(body) {
+		return ""
+	}
+
+	if patterns := p.secretPatterns(); len(patterns) != 0 {
+		body, _ = redactSecrets(patterns, body, p.logger.redact)
+	}
+
+	return string(body)
+}
+
+func (p *printer) printEvent(enc Encoder, e *Event) {
+	var buf bytes.Buffer
+
+	if err := enc.Encode(&buf, e); err != nil {
+		p.printf("* cannot encode event: %v\n", err)
+		return
+	}
+
+	p.print(buf.String())
+}
+
+// eventAnnotations returns the annotations attached to the exchange of a request, if any.
+func eventAnnotations(req *http.Request) map[string]string {
+	a, _ := req.Context().Value(contextAnnotations{}).(*annotations)
+	keys, values := a.list()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(keys))
+
+	for i, key := range keys {
+		m[key] = values[i]
+	}
+
+	return m
+}
+
+// readBodyLimit reads a body to print it, with the same limits as the printer: a body declared longer than max
+// isn't read, and a body of unknown length is read up to max, or 4096 bytes if max isn't set.
+// ok is false if the body is too long to print, and newBody replaces the body in any case.
+func readBodyLimit(body io.ReadCloser, length, max int64) (b []byte, ok bool, newBody io.ReadCloser) {
+	if body == nil || body == http.NoBody {
+		return nil, true, body
+	}
+
+	if max > 0 && length > max {
+		return nil, false, body
+	}
+
+	if length > 0 {
+		b, newBody = readBody(body)
+		return b, true, newBody
+	}
+
+	if max <= 0 {
+		max = maxDefaultUnknownReadable
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(body, max+1))
+
+	switch {
+	case err != nil:
+		body.Close()
+		return b, true, ioutil.NopCloser(io.MultiReader(bytes.NewReader(b), errorReader{err}))
+	case int64(len(b)) > max:
+		return nil, false, newBodyReaderBuf(bytes.NewReader(b), body)
+	}
+
+	body.Close()
+	return b, true, ioutil.NopCloser(bytes.NewReader(b))
+}
+
+// eventResponseWriter records the status code and size of a response, keeping its body up to a maximum length:
+// max, or 4096 bytes for a body without a Content-Length if max isn't set.
+type eventResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	size       int64
+
+	keep bool
+	max  int64
+	body bytes.Buffer
+}
+
+func (rw *eventResponseWriter) Write(p []byte) (int, error) {
+	rw.limit()
+	n, err := rw.ResponseWriter.Write(p)
+	rw.size += int64(n)
+
+	if rw.keep && rw.max > 0 && rw.size > rw.max {
+		rw.keep = false
+		rw.body = bytes.Buffer{}
+	}
+
+	if rw.keep {
+		rw.body.Write(p[:n])
+	}
+
+	return n, err
+}
+
+func (rw *eventResponseWriter) WriteHeader(statusCode int) {
+	rw.ResponseWriter.WriteHeader(statusCode)
+	rw.statusCode = statusCode
+}
+
+// limit the body kept by the Content-Length set by the handler before writing the body.
+func (rw *eventResponseWriter) limit() {
+	if rw.size != 0 || !rw.keep {
+		return
+	}
+
+	length, err := strconv.ParseInt(rw.Header().Get("Content-Length"), 10, 64)
+
+	switch {
+	case err == nil && rw.max > 0 && length > rw.max:
+		rw.keep = false
+	case err != nil && rw.max <= 0:
+		rw.max = maxDefaultUnknownReadable
+	}
+}
+
+// Flush sends any buffered data to the client, if the underlying writer supports it.
+func (rw *eventResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the underlying ResponseWriter supports it.
+func (rw *eventResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, errors.New("httpretty: underlying ResponseWriter doesn't support hijacking")
+	}
+
+	return hj.Hijack()
+}
+
+// ReadFrom lets the underlying writer send files with sendfile once the body isn't kept.
+func (rw *eventResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	rw.limit()
+	rf, ok := rw.ResponseWriter.(io.ReaderFrom)
+
+	if !ok || rw.keep {
+		return io.Copy(writerOnly{rw}, src)
+	}
+
+	n, err := rf.ReadFrom(src)
+	rw.size += n
+	return n, err
+}
@@ -0,0 +1,300 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeEvent(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+
+	var event map[string]interface{}
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("cannot decode event %q: %v", data, err)
+	}
+
+	return event
+}
+
+func TestOutgoingJSONEncoder(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	logger.SetEncoder(JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/users?q=1", strings.NewReader("hello"))
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != "Hello, world!" {
+		t.Errorf("response body = %q, wanted %q", body, "Hello, world!")
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Fatalf("expected a single line, got %d:\n%s", n, buf.String())
+	}
+
+	event := decodeEvent(t, buf.Bytes())
+
+	for key, want := range map[string]interface{}{
+		"method":         "POST",
+		"url":            ts.URL + "/users?q=1",
+		"proto":          "HTTP/1.1",
+		"status":         float64(200),
+		"request_body":   "hello",
+		"request_bytes":  float64(5),
+		"response_body":  "Hello, world!",
+		"response_bytes": float64(13),
+	} {
+		if event[key] != want {
+			t.Errorf("event %s = %v, wanted %v", key, event[key], want)
+		}
+	}
+
+	if _, ok := event["duration_ms"].(float64); !ok {
+		t.Errorf("expected event to have a duration, got %v", event["duration_ms"])
+	}
+
+	if h, _ := event["request_header"].(map[string]interface{}); h == nil || h["Authorization"].([]interface{})[0] != "Bearer ████████████████████" {
+		t.Errorf("expected Authorization header to be sanitized, got %v", event["request_header"])
+	}
+
+	if h, _ := event["response_header"].(map[string]interface{}); h == nil || h["Content-Type"] == nil {
+		t.Errorf("expected response header to be included, got %v", event["response_header"])
+	}
+}
+
+func TestOutgoingJSONEncoderError(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	ts.Close()
+
+	logger := &Logger{}
+	logger.SetEncoder(JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Get(ts.URL); err == nil {
+		t.Fatal("expected request to fail")
+	}
+
+	event := decodeEvent(t, buf.Bytes())
+
+	if errMsg, _ := event["error"].(string); !strings.Contains(errMsg, "connection refused") {
+		t.Errorf("event error = %q, wanted connection refused", errMsg)
+	}
+
+	if _, ok := event["status"]; ok {
+		t.Errorf("expected no status for a failed exchange, got %v", event["status"])
+	}
+}
+
+func TestIncomingJSONEncoder(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestBody:     true,
+		ResponseBody:    true,
+		MaxResponseBody: 5,
+	}
+
+	logger.SetEncoder(JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if body, _ := ioutil.ReadAll(req.Body); string(body) != "hello" {
+			t.Errorf("handler got body %q, wanted %q", body, "hello")
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello, world!"))
+	}), WithRoute("/users"))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/users", strings.NewReader("hello"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := decodeEvent(t, buf.Bytes())
+
+	for key, want := range map[string]interface{}{
+		"method":         "POST",
+		"url":            "http://example.com/users",
+		"route":          "/users",
+		"remote_addr":    "192.0.2.1:1234",
+		"status":         float64(201),
+		"request_body":   "hello",
+		"request_bytes":  float64(5),
+		"response_bytes": float64(13),
+	} {
+		if event[key] != want {
+			t.Errorf("event %s = %v, wanted %v", key, event[key], want)
+		}
+	}
+
+	if _, ok := event["response_body"]; ok {
+		t.Errorf("expected response body longer than MaxResponseBody to be left out, got %v", event["response_body"])
+	}
+
+	if _, ok := event["request_header"]; ok {
+		t.Errorf("expected request header to be left out, got %v", event["request_header"])
+	}
+}
+
+func TestIncomingJSONEncoderLimits(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		max      int64
+		body     string
+		length   bool
+		wantBody bool
+	}{
+		{name: "chunked within max", max: 100, body: "hello", wantBody: true},
+		{name: "chunked over max", max: 3, body: "hello"},
+		{name: "chunked over default", body: strings.Repeat("x", 5000)},
+		{name: "declared over max", max: 3, body: "hello", length: true},
+		{name: "declared without max", body: strings.Repeat("x", 5000), length: true, wantBody: true},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{
+			RequestBody:     true,
+			ResponseBody:    true,
+			MaxRequestBody:  tc.max,
+			MaxResponseBody: tc.max,
+		}
+
+		logger.SetEncoder(JSONEncoder{})
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+
+			if string(body) != tc.body {
+				t.Errorf("%s: request body = %q, wanted %q", tc.name, body, tc.body)
+			}
+
+			if tc.length {
+				w.Header().Set("Content-Length", fmt.Sprint(len(tc.body)))
+			}
+
+			fmt.Fprint(w, tc.body)
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/", ioutil.NopCloser(strings.NewReader(tc.body)))
+
+		if tc.length {
+			req.ContentLength = int64(len(tc.body))
+		} else {
+			req.ContentLength = -1
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		event := decodeEvent(t, buf.Bytes())
+
+		for _, key := range []string{"request_body", "response_body"} {
+			if _, ok := event[key]; ok != tc.wantBody {
+				t.Errorf("%s: event has %s = %v, wanted %v", tc.name, key, ok, tc.wantBody)
+			}
+		}
+	}
+}
+
+func TestOutgoingJSONEncoderSanitizeAndAnnotations(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		RequestHeader: true,
+	}
+
+	logger.SetEncoder(JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(annotatingTransport{rt: newTransport()}),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	req.Header["authorization"] = []string{"Bearer topsecret"}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	if strings.Contains(buf.String(), "topsecret") {
+		t.Errorf("event contains the credentials: %s", buf.String())
+	}
+
+	event := decodeEvent(t, buf.Bytes())
+
+	if got := fmt.Sprint(event["annotations"]); got != "map[transport:inner]" {
+		t.Errorf("event annotations = %v, wanted map[transport:inner]", got)
+	}
+}
+
+func TestIncomingJSONEncoderResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{}
+	logger.SetOutput(ioutil.Discard)
+	logger.SetEncoder(JSONEncoder{})
+	testResponseWriterInterfaces(t, logger)
+}
@@ -0,0 +1,79 @@
+package httpretty
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// contentDecoders for the Content-Encoding values the logger can decode for printing.
+// See https://www.iana.org/assignments/http-parameters/http-parameters.xhtml#content-coding
+// Optional decoders (such as zstd) are added on init by files guarded by build tags.
+var contentDecoders = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip":   gzipDecoder,
+	"x-gzip": gzipDecoder,
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// maxDecodedBody is the maximum size of a decoded body, protecting the logger against decompression bombs.
+const maxDecodedBody = 10 << 20 // bytes
+
+// decodeContent decodes the body using the list of codings in the Content-Encoding, in the reverse order
+// they were applied. The body is returned unchanged if any of the codings is unknown.
+func decodeContent(encoding string, body []byte) ([]byte, error) {
+	codings := strings.Split(encoding, ",")
+
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+
+		if coding == "" || coding == "identity" {
+			continue
+		}
+
+		decoder, ok := contentDecoders[coding]
+
+		if !ok {
+			return body, nil
+		}
+
+		var err error
+
+		if body, err = decode(decoder, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+func decode(decoder func(r io.Reader) (io.ReadCloser, error), body []byte) ([]byte, error) {
+	rc, err := decoder(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+
+	decoded, err := ioutil.ReadAll(io.LimitReader(rc, maxDecodedBody+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) > maxDecodedBody {
+		return nil, fmt.Errorf("decoded body is longer than %d bytes", maxDecodedBody)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,48 @@
+package httpretty
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"testing"
+)
+
+func TestDecodeContent(t *testing.T) {
+	t.Parallel()
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte("gzipped"))
+	gw.Close()
+
+	var deflate bytes.Buffer
+	zw := zlib.NewWriter(&deflate)
+	zw.Write(gz.Bytes())
+	zw.Close()
+
+	testCases := []struct {
+		encoding string
+		body     []byte
+		want     string
+		err      bool
+	}{
+		{encoding: "gzip", body: gz.Bytes(), want: "gzipped"},
+		{encoding: "X-Gzip", body: gz.Bytes(), want: "gzipped"},
+		{encoding: "identity", body: []byte("plain"), want: "plain"},
+		{encoding: "gzip, deflate", body: deflate.Bytes(), want: "gzipped"},
+		{encoding: "unknown", body: []byte("raw"), want: "raw"},
+		{encoding: "gzip", body: []byte("not gzip"), err: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := decodeContent(tc.encoding, tc.body)
+
+		if tc.err != (err != nil) {
+			t.Errorf("decodeContent(%q) error = %v, wanted error: %v", tc.encoding, err, tc.err)
+		}
+
+		if string(got) != tc.want {
+			t.Errorf("decodeContent(%q) = %q, wanted %q", tc.encoding, got, tc.want)
+		}
+	}
+}
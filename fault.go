@@ -0,0 +1,160 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FaultKind is a kind of fault injected into the requests sent by the client. See Logger.Faults.
+type FaultKind int
+
+const (
+	// FaultDelay delays sending the request by Fault.Delay.
+	FaultDelay FaultKind = iota + 1
+
+	// FaultDrop fails the request without sending it, as if the connection was dropped.
+	FaultDrop
+
+	// FaultCorrupt corrupts random bytes of the response body.
+	FaultCorrupt
+
+	// FaultUnavailable responds with 503 Service Unavailable without sending the request.
+	FaultUnavailable
+)
+
+// Fault injected into the requests sent by the client, to test how it copes with them. See Logger.Faults.
+type Fault struct {
+	Kind FaultKind
+
+	// Path of the requests the fault is injected into. It can contain placeholder segments, such as /users/{id}.
+	// If value is not set, it is injected into requests to any path.
+	Path string
+
+	// Probability of injecting the fault into a request, from 0 to 1.
+	// If value is not set, it is always injected.
+	Probability float64
+
+	// Delay of a FaultDelay.
+	Delay time.Duration
+}
+
+// ErrInjectedFault is returned for requests failed by a FaultDrop.
+var ErrInjectedFault = errors.New("httpretty: injected fault: connection dropped")
+
+func (f Fault) inject(req *http.Request) bool {
+	if f.Path != "" && !newPathPattern(f.Path).match(req.URL.Path) {
+		return false
+	}
+
+	return f.Probability <= 0 || rand.Float64() < f.Probability
+}
+
+// faultRoundTripper injects faults into the requests sent by the client.
+type faultRoundTripper struct {
+	p      *printer
+	faults []Fault
+	next   http.RoundTripper
+}
+
+func (frt faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := frt.p
+	corrupt := false
+
+	for _, f := range frt.faults {
+		if !f.inject(req) {
+			continue
+		}
+
+		switch f.Kind {
+		case FaultDelay:
+			p.printf("* injected fault: delaying request by %v\n", f.Delay)
+
+			if err := sleep(req, f.Delay); err != nil {
+				return nil, err
+			}
+		case FaultDrop:
+			p.println("* injected fault: dropping request")
+			closeBody(req)
+			return nil, ErrInjectedFault
+		case FaultUnavailable:
+			p.println("* injected fault: responding with 503 Service Unavailable")
+			closeBody(req)
+			return unavailableResponse(req), nil
+		case FaultCorrupt:
+			corrupt = true
+		}
+	}
+
+	resp, err := frt.next.RoundTrip(req)
+
+	if err != nil || !corrupt || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	n := corruptBytes(body)
+	p.printf("* injected fault: corrupting %d bytes of the response body\n", n)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// sleep for d, unless the request is canceled.
+func sleep(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+func unavailableResponse(req *http.Request) *http.Response {
+	body := "service unavailable (fault injected by httpretty)\n"
+
+	return &http.Response{
+		Status:        "503 Service Unavailable",
+		StatusCode:    http.StatusServiceUnavailable,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// corruptBytes flips the bits of about 1% of the bytes of the body, at random positions.
+func corruptBytes(body []byte) int {
+	if len(body) == 0 {
+		return 0
+	}
+
+	n := len(body)/100 + 1
+
+	for i := 0; i < n; i++ {
+		body[rand.Intn(len(body))] ^= 0xFF
+	}
+
+	return n
+}
@@ -0,0 +1,185 @@
+package httpretty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutgoingFaults(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	testCases := []struct {
+		name   string
+		faults []Fault
+		path   string
+		want   string
+		status int
+		err    error
+	}{
+		{
+			name:   "unavailable",
+			faults: []Fault{{Kind: FaultUnavailable}},
+			want: `* injected fault: responding with 503 Service Unavailable
+< HTTP/1.1 503 Service Unavailable
+< Content-Type: text/plain; charset=utf-8
+
+`,
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "drop",
+			faults: []Fault{{Kind: FaultDrop}},
+			want: `* injected fault: dropping request
+* httpretty: injected fault: connection dropped
+`,
+			err: ErrInjectedFault,
+		},
+		{
+			name:   "delay",
+			faults: []Fault{{Kind: FaultDelay, Delay: time.Millisecond}},
+			want: `* injected fault: delaying request by 1ms
+< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+
+`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "other path",
+			faults: []Fault{{Kind: FaultDrop, Path: "/users/{id}"}},
+			path:   "/posts/1",
+			want: `< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+
+`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "never",
+			faults: []Fault{{Kind: FaultDrop, Probability: 0.0000001}},
+			want: `< HTTP/1.1 200 OK
+< Content-Length: 13
+< Content-Type: text/plain; charset=utf-8
+
+`,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &Logger{
+				SkipRequestInfo: true,
+				ResponseHeader:  true,
+				Faults:          tc.faults,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			client := &http.Client{
+				Transport: logger.RoundTripper(newTransport()),
+			}
+
+			resp, err := client.Get(ts.URL + tc.path)
+
+			if !errors.Is(err, tc.err) {
+				t.Errorf("got error %v, wanted %v", err, tc.err)
+			}
+
+			if resp != nil {
+				resp.Body.Close()
+
+				if resp.StatusCode != tc.status {
+					t.Errorf("got status %d, wanted %d", resp.StatusCode, tc.status)
+				}
+			}
+
+			if got := buf.String(); got != tc.want {
+				t.Errorf("logged HTTP request %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOutgoingFaultCorrupt(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		Faults:          []Fault{{Kind: FaultCorrupt}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		t.Fatalf("cannot read body: %v", err)
+	}
+
+	if len(body) != 13 || string(body) == "Hello, world!" {
+		t.Errorf("got body %q, wanted a corrupted one", body)
+	}
+
+	if got, want := buf.String(), "* injected fault: corrupting 1 bytes of the response body\n"; got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestOutgoingFaultDelayCanceled(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		Faults:          []Fault{{Kind: FaultDelay, Delay: time.Hour}},
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("got error %v, wanted deadline exceeded", err)
+	}
+}
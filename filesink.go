@@ -0,0 +1,100 @@
+package httpretty
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink writes the logs to files partitioned by time, so long captures remain manageable.
+// Use it as the output of a logger with Logger.SetOutput.
+//
+// The file name is generated from Pattern, which is a time layout (see time.Format), inside Dir.
+// A new file is opened whenever the generated name changes, so the layout controls the partitioning.
+// For example, "traffic-2006-01-02.log" creates a file per day,
+// and "traffic/2006-01-02/15.log" creates a file per hour, in a directory per day.
+// Existing files are appended to.
+type FileSink struct {
+	// Dir where the files are created. If value is not set, the current directory is used.
+	Dir string
+
+	// Pattern for the file names. It is required.
+	Pattern string
+
+	// Location used for generating the file names. If value is not set, UTC is used.
+	Location *time.Location
+
+	mu   sync.Mutex
+	f    *os.File
+	name string
+	now  func() time.Time
+}
+
+// Write to the file for the current period of time.
+func (fs *FileSink) Write(p []byte) (n int, err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.rotate(); err != nil {
+		return 0, err
+	}
+
+	return fs.f.Write(p)
+}
+
+// Close the current file.
+func (fs *FileSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.f == nil {
+		return nil
+	}
+
+	err := fs.f.Close()
+	fs.f = nil
+	fs.name = ""
+	return err
+}
+
+// rotate opens the file for the current period of time, if it is not open yet.
+func (fs *FileSink) rotate() error {
+	now := time.Now
+
+	if fs.now != nil {
+		now = fs.now
+	}
+
+	loc := fs.Location
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	name := filepath.Join(fs.Dir, now().In(loc).Format(fs.Pattern))
+
+	if fs.f != nil && name == fs.name {
+		return nil
+	}
+
+	if dir := filepath.Dir(name); dir != "" {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600) // #nosec G304
+
+	if err != nil {
+		return err
+	}
+
+	if fs.f != nil {
+		fs.f.Close()
+	}
+
+	fs.f = f
+	fs.name = name
+	return nil
+}
@@ -0,0 +1,85 @@
+package httpretty
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "httpretty")
+
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	fs := &FileSink{
+		Dir:     dir,
+		Pattern: filepath.Join("2006-01-02", "15.log"),
+		now: func() time.Time {
+			return now
+		},
+	}
+
+	defer fs.Close()
+
+	fmt.Fprint(fs, "first\n")
+	fmt.Fprint(fs, "second\n")
+
+	now = now.Add(time.Hour)
+	fmt.Fprint(fs, "third\n")
+
+	now = now.Add(24 * time.Hour)
+	fmt.Fprint(fs, "fourth\n")
+
+	if err := fs.Close(); err != nil {
+		t.Errorf("cannot close file sink: %v", err)
+	}
+
+	// reopening appends to the existing file.
+	fmt.Fprint(fs, "fifth\n")
+
+	if err := fs.Close(); err != nil {
+		t.Errorf("cannot close file sink: %v", err)
+	}
+
+	files := map[string]string{
+		"2020-01-02/15.log": "first\nsecond\n",
+		"2020-01-02/16.log": "third\n",
+		"2020-01-03/16.log": "fourth\nfifth\n",
+	}
+
+	for name, want := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+
+		if err != nil {
+			t.Errorf("cannot read %s: %v", name, err)
+		}
+
+		if got := string(b); got != want {
+			t.Errorf("got %s content = %q, wanted %q", name, got, want)
+		}
+	}
+}
+
+func TestFileSinkError(t *testing.T) {
+	t.Parallel()
+
+	fs := &FileSink{
+		Dir:     filepath.Join("testdata", "cert.pem"),
+		Pattern: "2006.log",
+	}
+
+	if _, err := fs.Write([]byte("fail")); err == nil {
+		t.Errorf("expected error writing to a file inside a regular file")
+	}
+}
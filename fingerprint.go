@@ -0,0 +1,48 @@
+package httpretty
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// printFingerprint prints a stable fingerprint of the request. See Logger.Fingerprint.
+func (p *printer) printFingerprint(req *http.Request) {
+	p.printf("* Request fingerprint: %s\n", p.format(color.FgBlue, requestFingerprint(req)))
+}
+
+// requestFingerprint hashes the method, the normalized path, the sorted header names, and the hash of the body.
+// The body is read fully and replaced, unless it is streamed.
+func requestFingerprint(req *http.Request) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\n%s\n", req.Method, cleanPath(req.URL.Path))
+
+	names := make([]string, 0, len(req.Header))
+
+	for name := range req.Header {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\n", name)
+	}
+
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case isStreamedBody(req.Body):
+		io.WriteString(h, "streamed body\n")
+	default:
+		var body []byte
+		body, req.Body = readBody(req.Body)
+		fmt.Fprintf(h, "%x\n", sha256.Sum256(body))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
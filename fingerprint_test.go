@@ -0,0 +1,130 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fingerprintLine = regexp.MustCompile(`\* Request fingerprint: ([0-9a-f]{16})\n`)
+
+func TestRequestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(method, target, body string, header http.Header) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header = header
+		return req
+	}
+
+	a := requestFingerprint(newRequest(http.MethodPost, "/users/", `{"name":"gopher"}`, http.Header{"Accept": {"a"}, "Content-Type": {"b"}}))
+
+	for _, same := range []*http.Request{
+		newRequest(http.MethodPost, "/users/?page=2", `{"name":"gopher"}`, http.Header{"Content-Type": {"c"}, "Accept": {"d"}}),
+		newRequest(http.MethodPost, "//users/./", `{"name":"gopher"}`, http.Header{"Accept": {"a"}, "Content-Type": {"b"}}),
+	} {
+		if got := requestFingerprint(same); got != a {
+			t.Errorf("fingerprint of %s = %s, wanted %s", same.URL, got, a)
+		}
+	}
+
+	for _, different := range []*http.Request{
+		newRequest(http.MethodPut, "/users/", `{"name":"gopher"}`, http.Header{"Accept": {"a"}, "Content-Type": {"b"}}),
+		newRequest(http.MethodPost, "/users", `{"name":"gopher"}`, http.Header{"Accept": {"a"}, "Content-Type": {"b"}}),
+		newRequest(http.MethodPost, "/users/", `{"name":"gopher"}`, http.Header{"Accept": {"a"}}),
+		newRequest(http.MethodPost, "/users/", `{"name":"henvic"}`, http.Header{"Accept": {"a"}, "Content-Type": {"b"}}),
+	} {
+		if got := requestFingerprint(different); got == a {
+			t.Errorf("fingerprint of %s %s matches the original request", different.Method, different.URL)
+		}
+	}
+}
+
+func TestOutgoingFingerprint(t *testing.T) {
+	t.Parallel()
+
+	var received bytes.Buffer
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received.ReadFrom(req.Body)
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		Fingerprint: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(ts.URL+"/upload", "text/plain", strings.NewReader("Hi"))
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if received.String() != "HiHi" {
+		t.Errorf("server received %q, wanted %q", received.String(), "HiHi")
+	}
+
+	matches := fingerprintLine.FindAllStringSubmatch(buf.String(), -1)
+
+	if len(matches) != 2 || matches[0][1] != matches[1][1] {
+		t.Errorf("expected identical requests to have the same fingerprint:\n%s", buf.String())
+	}
+}
+
+func TestIncomingFingerprint(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		Fingerprint: true,
+		RequestBody: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(helloHandler{}), 2)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	client := newServerClient()
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("Hi"))
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	is.Wait()
+
+	got := buf.String()
+
+	if matches := fingerprintLine.FindAllStringSubmatch(got, -1); len(matches) != 2 || matches[0][1] != matches[1][1] {
+		t.Errorf("expected identical requests to have the same fingerprint:\n%s", got)
+	}
+
+	if strings.Count(got, "\nHi\n") != 2 {
+		t.Errorf("expected request bodies to be printed after computing the fingerprint:\n%s", got)
+	}
+}
@@ -0,0 +1,80 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FormFormatter helps you read URL-encoded forms, such as login requests,
+// printing each field decoded on its own line, like "email: root@example.com", in the order they were sent.
+type FormFormatter struct {
+	// Redact the values of the fields with these names, such as "password".
+	Redact []string
+}
+
+// Match URL-encoded form media type.
+func (f *FormFormatter) Match(mediatype string) bool {
+	return mediatype == "application/x-www-form-urlencoded"
+}
+
+// Format URL-encoded form content.
+func (f *FormFormatter) Format(w io.Writer, src []byte) error {
+	dst, ok := w.(*bytes.Buffer)
+	if !ok {
+		return errors.New("underlying writer for FormFormatter must be *bytes.Buffer")
+	}
+
+	for _, pair := range strings.Split(strings.TrimSpace(string(src)), "&") {
+		if pair == "" {
+			continue
+		}
+
+		name, value := pair, ""
+
+		if i := strings.IndexByte(pair, '='); i != -1 {
+			name, value = pair[:i], pair[i+1:]
+		}
+
+		key, err := url.QueryUnescape(name)
+
+		if err != nil {
+			return fmt.Errorf("invalid form field name %q: %v", name, err)
+		}
+
+		if value, err = url.QueryUnescape(value); err != nil {
+			return fmt.Errorf("invalid value for form field %q: %v", key, err)
+		}
+
+		if f.redacts(key) {
+			value = redactedSecret
+		}
+
+		// keep each field on a single line.
+		if strings.ContainsAny(value, "\r\n") {
+			value = strconv.Quote(value)
+		}
+
+		if dst.Len() != 0 {
+			dst.WriteByte('\n')
+		}
+
+		dst.WriteString(strings.TrimRight(key+": "+value, " "))
+	}
+
+	return nil
+}
+
+func (f *FormFormatter) redacts(key string) bool {
+	for _, name := range f.Redact {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+
+	return false
+}
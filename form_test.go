@@ -0,0 +1,92 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormFormatter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		f    FormFormatter
+		src  string
+		want string
+	}{
+		{
+			name: "login",
+			f:    FormFormatter{Redact: []string{"Password"}},
+			src:  "email=root%40example.com&password=hunter2&remember",
+			want: "email: root@example.com\npassword: " + redactedSecret + "\nremember:",
+		},
+		{
+			name: "order kept, repeated fields, and spaces",
+			src:  "z=1&a=hello+world&a=caf%C3%A9&note=line1%0Aline2&&empty=",
+			want: "z: 1\na: hello world\na: café\nnote: \"line1\\nline2\"\nempty:",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+
+		if err := tc.f.Format(&buf, []byte(tc.src)); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: got %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFormFormatterInvalid(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	var f FormFormatter
+
+	err := f.Format(&buf, []byte("a=%zz"))
+
+	if want := `invalid value for form field "a": invalid URL escape "%zz"`; err == nil || err.Error() != want {
+		t.Errorf("got error %v, wanted %v", err, want)
+	}
+}
+
+func TestOutgoingFormFormatter(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+		Formatters:      []Formatter{&FormFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Post(ts.URL, "application/x-www-form-urlencoded", strings.NewReader("email=root%40example.com&foo=bar"))
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	want := "email: root@example.com\nfoo: bar\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
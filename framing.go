@@ -0,0 +1,153 @@
+package httpretty
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// framing describes a protocol that wraps messages in length-prefixed envelopes,
+// such as gRPC-Web and the Connect streaming protocol.
+type framing struct {
+	name      string
+	mediatype string // media type of the enveloped messages
+	text      bool   // body is base64 encoded (application/grpc-web-text)
+
+	trailerFlag byte
+	connect     bool
+}
+
+const (
+	frameCompressedFlag = 0x01
+	frameHeaderLength   = 5
+)
+
+// matchFraming checks if a media type uses framed messages.
+// See https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md
+// and https://connectrpc.com/docs/protocol/#streaming-request
+func matchFraming(mediatype string) (f framing, ok bool) {
+	base, subtype := mediatype, ""
+
+	if i := strings.IndexByte(mediatype, '+'); i != -1 {
+		base, subtype = mediatype[:i], mediatype[i+1:]
+	}
+
+	switch base {
+	case "application/grpc-web":
+		f = framing{name: "gRPC-Web", trailerFlag: 0x80}
+	case "application/grpc-web-text":
+		f = framing{name: "gRPC-Web", trailerFlag: 0x80, text: true}
+	case "application/connect":
+		f = framing{name: "Connect", trailerFlag: 0x02, connect: true}
+	default:
+		return f, false
+	}
+
+	if subtype == "" {
+		subtype = "proto"
+	}
+
+	f.mediatype = "application/" + subtype
+	return f, true
+}
+
+func (p *printer) printFramedBody(f framing, body []byte) {
+	if f.text {
+		decoded, err := decodeGRPCWebText(body)
+
+		if err != nil {
+			p.printf("* cannot decode %s text body: %v\n", f.name, err)
+			p.printBody("", body)
+			return
+		}
+
+		body = decoded
+	}
+
+	for len(body) > 0 {
+		if len(body) < frameHeaderLength {
+			p.printf("* %s frame is truncated (%d bytes left)\n", f.name, len(body))
+			return
+		}
+
+		flags := body[0]
+		length := binary.BigEndian.Uint32(body[1:frameHeaderLength])
+		body = body[frameHeaderLength:]
+
+		if uint64(length) > uint64(len(body)) {
+			p.printf("* %s frame is truncated (want %d bytes, got %d)\n", f.name, length, len(body))
+			return
+		}
+
+		msg := body[:length]
+		body = body[length:]
+
+		switch {
+		case flags&f.trailerFlag != 0 && f.connect:
+			p.printf("* %s end of stream (%d bytes)\n", f.name, length)
+			p.printBody("application/json", msg)
+		case flags&f.trailerFlag != 0:
+			p.printf("* %s trailers (%d bytes)\n", f.name, length)
+			p.printFrameTrailers(msg)
+		case flags&frameCompressedFlag != 0:
+			p.printf("* %s message (%d bytes) is compressed\n", f.name, length)
+		default:
+			p.printf("* %s message (%d bytes)\n", f.name, length)
+			p.printBody(f.mediatype, msg)
+		}
+	}
+}
+
+func (p *printer) printFrameTrailers(msg []byte) {
+	// trailers are encoded like HTTP/1 headers, but the last line might be missing its terminator.
+	if !bytes.HasSuffix(msg, []byte("\r\n\r\n")) {
+		trimmed := bytes.TrimRight(msg, "\r\n")
+		msg = append(append([]byte{}, trimmed...), "\r\n\r\n"...)
+	}
+
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(msg)))
+	h, err := tp.ReadMIMEHeader()
+
+	if err != nil {
+		p.printf("* cannot parse trailers: %v\n", err)
+		return
+	}
+
+	p.printHeaders('*', http.Header(h))
+}
+
+// decodeGRPCWebText decodes a body that might contain multiple concatenated base64 chunks.
+func decodeGRPCWebText(body []byte) ([]byte, error) {
+	var decoded []byte
+	body = bytes.TrimSpace(body)
+
+	for len(body) > 0 {
+		end := bytes.IndexByte(body, '=')
+
+		switch {
+		case end == -1:
+			end = len(body)
+		default:
+			for end < len(body) && body[end] == '=' {
+				end++
+			}
+		}
+
+		chunk := make([]byte, base64.StdEncoding.DecodedLen(end))
+		n, err := base64.StdEncoding.Decode(chunk, body[:end])
+
+		if err != nil {
+			return nil, fmt.Errorf("illegal base64 data: %v", err)
+		}
+
+		decoded = append(decoded, chunk[:n]...)
+		body = body[end:]
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func frame(flags byte, msg string) []byte {
+	b := make([]byte, frameHeaderLength, frameHeaderLength+len(msg))
+	b[0] = flags
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+func TestPrintResponseFramed(t *testing.T) {
+	t.Parallel()
+
+	grpcWeb := append(frame(0x00, `{"name":"gopher"}`), frame(0x80, "grpc-status: 0\r\ngrpc-message: OK")...)
+	connect := append(frame(0x00, `{"name":"gopher"}`), frame(0x02, `{"metadata":{"x":["y"]}}`)...)
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{
+			name:        "grpc-web",
+			contentType: "application/grpc-web+json",
+			body:        grpcWeb,
+			want: `* gRPC-Web message (17 bytes)
+{
+    "name": "gopher"
+}
+* gRPC-Web trailers (32 bytes)
+* Grpc-Message: OK
+* Grpc-Status: 0
+`,
+		},
+		{
+			name:        "grpc-web-text",
+			contentType: "application/grpc-web-text+json",
+			body: []byte(base64.StdEncoding.EncodeToString(frame(0x00, `{"a":1}`)) +
+				base64.StdEncoding.EncodeToString(frame(0x80, "grpc-status: 0"))),
+			want: `* gRPC-Web message (7 bytes)
+{
+    "a": 1
+}
+* gRPC-Web trailers (14 bytes)
+* Grpc-Status: 0
+`,
+		},
+		{
+			name:        "grpc-web-compressed",
+			contentType: "application/grpc-web",
+			body:        frame(0x01, "xyz"),
+			want:        "* gRPC-Web message (3 bytes) is compressed\n",
+		},
+		{
+			name:        "grpc-web-truncated",
+			contentType: "application/grpc-web",
+			body:        frame(0x00, "xyz")[:6],
+			want:        "* gRPC-Web frame is truncated (want 3 bytes, got 1)\n",
+		},
+		{
+			name:        "connect",
+			contentType: "application/connect+json",
+			body:        connect,
+			want: `* Connect message (17 bytes)
+{
+    "name": "gopher"
+}
+* Connect end of stream (24 bytes)
+{
+    "metadata": {
+        "x": [
+            "y"
+        ]
+    }
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &Logger{
+				ResponseBody: true,
+				Formatters:   []Formatter{&JSONFormatter{}},
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			logger.PrintResponse(&http.Response{
+				Header: http.Header{
+					"Content-Type": []string{tc.contentType},
+				},
+				ContentLength: int64(len(tc.body)),
+				Body:          ioutil.NopCloser(bytes.NewReader(tc.body)),
+			})
+
+			if got := buf.String(); got != tc.want {
+				t.Errorf("PrintResponse(resp) = %v, wanted %v", got, tc.want)
+			}
+		})
+	}
+}
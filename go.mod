@@ -1,3 +1,5 @@
 module github.com/henvic/httpretty
 
 go 1.13
+
+require github.com/klauspost/compress v1.11.13
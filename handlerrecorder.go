@@ -0,0 +1,34 @@
+package httpretty
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// HandlerRecorder drives a handler with synthetic requests, without a server, and logs them like Logger.Handler.
+// It is meant for unit tests that want the log output of a handler without starting a listener.
+type HandlerRecorder struct {
+	handler http.Handler
+}
+
+// RecordHandler returns a HandlerRecorder for the handler wrapped with the logger, using the given options.
+func (l *Logger) RecordHandler(h http.Handler, opts ...MiddlewareOption) *HandlerRecorder {
+	return &HandlerRecorder{
+		handler: l.Handler(h, opts...),
+	}
+}
+
+// Do sends the request to the handler, returning the recorded response once the handler returns.
+// The exchange is printed before Do returns.
+func (hr *HandlerRecorder) Do(req *http.Request) *http.Response {
+	rec := httptest.NewRecorder()
+	hr.handler.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+// Request sends a synthetic request created with httptest.NewRequest to the handler.
+// The request is sent to example.com from 192.0.2.1:1234, unless target is an absolute URL.
+func (hr *HandlerRecorder) Request(method, target string, body io.Reader) *http.Response {
+	return hr.Do(httptest.NewRequest(method, target, body))
+}
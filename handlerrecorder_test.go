@@ -0,0 +1,55 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandlerRecorder(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	hr := logger.RecordHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(bytes.ToUpper(b))
+	}), WithRoute("shout"))
+
+	resp := hr.Request(http.MethodPost, "/shout", strings.NewReader("hello"))
+	defer resp.Body.Close()
+
+	testBody(t, resp.Body, []byte("HELLO"))
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status code %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+
+	want := `* Request to http://example.com/shout
+* Request from 192.0.2.1:1234
+* Route: shout
+> POST /shout HTTP/1.1
+> Host: example.com
+
+hello
+< HTTP/1.1 200 OK
+< Content-Type: text/plain
+
+HELLO
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
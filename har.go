@@ -0,0 +1,238 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Exchange is a HTTP request paired with the response it got.
+//
+// The Request is ready to be sent again with a *http.Client or passed to a http.Handler
+// (for example, using net/http/httptest), so captured traffic can be replayed.
+type Exchange struct {
+	// StartedAt is when the request began.
+	StartedAt time.Time
+
+	// Duration of the whole exchange.
+	Duration time.Duration
+
+	Request  *http.Request
+	Response *http.Response
+}
+
+// HAR (HTTP Archive) 1.2 format.
+// See http://www.softwareishard.com/blog/har-12-spec/
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// ReadHAR loads the entries of a HAR (HTTP Archive) file, such as the ones exported by browsers.
+func ReadHAR(r io.Reader) ([]Exchange, error) {
+	var doc harDocument
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("cannot decode HAR: %v", err)
+	}
+
+	exchanges := make([]Exchange, 0, len(doc.Log.Entries))
+
+	for i, entry := range doc.Log.Entries {
+		e, err := entry.exchange()
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot read HAR entry %d: %v", i, err)
+		}
+
+		exchanges = append(exchanges, e)
+	}
+
+	return exchanges, nil
+}
+
+func (entry harEntry) exchange() (Exchange, error) {
+	req, err := entry.Request.httpRequest()
+
+	if err != nil {
+		return Exchange{}, err
+	}
+
+	resp, err := entry.Response.httpResponse(req)
+
+	if err != nil {
+		return Exchange{}, err
+	}
+
+	return Exchange{
+		StartedAt: entry.StartedDateTime,
+		Duration:  time.Duration(entry.Time * float64(time.Millisecond)),
+		Request:   req,
+		Response:  resp,
+	}, nil
+}
+
+func (hr harRequest) httpRequest() (*http.Request, error) {
+	var body io.Reader
+
+	if hr.PostData != nil {
+		body = strings.NewReader(hr.PostData.Text)
+	}
+
+	req, err := http.NewRequest(hr.Method, hr.URL, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = harHeader(hr.Headers)
+
+	if hr.PostData != nil && hr.PostData.MimeType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", hr.PostData.MimeType)
+	}
+
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+
+	setProto(hr.HTTPVersion, &req.Proto, &req.ProtoMajor, &req.ProtoMinor)
+	return req, nil
+}
+
+func (hr harResponse) httpResponse(req *http.Request) (*http.Response, error) {
+	body := []byte(hr.Content.Text)
+
+	if hr.Content.Encoding == "base64" {
+		var err error
+
+		if body, err = base64.StdEncoding.DecodeString(hr.Content.Text); err != nil {
+			return nil, fmt.Errorf("cannot decode response content: %v", err)
+		}
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", hr.Status, hr.StatusText),
+		StatusCode:    hr.Status,
+		Header:        harHeader(hr.Headers),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+
+	if hr.Content.MimeType != "" && resp.Header.Get("Content-Type") == "" {
+		resp.Header.Set("Content-Type", hr.Content.MimeType)
+	}
+
+	setProto(hr.HTTPVersion, &resp.Proto, &resp.ProtoMajor, &resp.ProtoMinor)
+	return resp, nil
+}
+
+func harHeader(list []harNameValue) http.Header {
+	h := http.Header{}
+
+	for _, nv := range list {
+		// HTTP/2 pseudo-headers such as :authority are not real headers.
+		if strings.HasPrefix(nv.Name, ":") {
+			continue
+		}
+
+		h.Add(nv.Name, nv.Value)
+	}
+
+	return h
+}
+
+func setProto(version string, proto *string, major, minor *int) {
+	switch strings.ToUpper(version) {
+	case "", "HTTP/1.1":
+		*proto, *major, *minor = "HTTP/1.1", 1, 1
+	case "HTTP/1.0":
+		*proto, *major, *minor = "HTTP/1.0", 1, 0
+	case "HTTP/2", "HTTP/2.0", "H2":
+		*proto, *major, *minor = "HTTP/2.0", 2, 0
+	default:
+		if ma, mi, ok := http.ParseHTTPVersion(version); ok {
+			*proto, *major, *minor = version, ma, mi
+		}
+	}
+}
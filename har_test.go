@@ -0,0 +1,87 @@
+package httpretty
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadHAR(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/session.har")
+
+	if err != nil {
+		t.Fatalf("cannot open HAR file: %v", err)
+	}
+
+	defer f.Close()
+
+	exchanges, err := ReadHAR(f)
+
+	if err != nil {
+		t.Fatalf("ReadHAR() error = %v", err)
+	}
+
+	if len(exchanges) != 2 {
+		t.Fatalf("got %d exchanges, wanted 2", len(exchanges))
+	}
+
+	e := exchanges[0]
+
+	if want := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC); !e.StartedAt.Equal(want) {
+		t.Errorf("got StartedAt = %v, wanted %v", e.StartedAt, want)
+	}
+
+	if want := 42500 * time.Microsecond; e.Duration != want {
+		t.Errorf("got Duration = %v, wanted %v", e.Duration, want)
+	}
+
+	if e.Request.Method != http.MethodPost || e.Request.URL.String() != "https://www.example.com/api/petitions?lang=en" {
+		t.Errorf("got request %s %s, wanted POST https://www.example.com/api/petitions?lang=en", e.Request.Method, e.Request.URL)
+	}
+
+	if e.Request.ProtoMajor != 2 {
+		t.Errorf("got request protocol %s, wanted HTTP/2.0", e.Request.Proto)
+	}
+
+	if _, ok := e.Request.Header[":authority"]; ok {
+		t.Errorf("pseudo-header :authority should not be imported as a header")
+	}
+
+	if got := e.Request.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got request Content-Type = %v, wanted application/json", got)
+	}
+
+	testBody(t, e.Request.Body, []byte(`{"name":"gopher"}`))
+
+	if e.Response.StatusCode != http.StatusCreated || e.Response.Status != "201 Created" {
+		t.Errorf("got response status %v, wanted 201 Created", e.Response.Status)
+	}
+
+	if e.Response.Request != e.Request {
+		t.Errorf("response should point to its request")
+	}
+
+	testBody(t, e.Response.Body, []byte("hello world"))
+
+	if got := exchanges[1].Response.Header.Get("Content-Type"); got != "text/html" {
+		t.Errorf("got response Content-Type = %v, wanted text/html", got)
+	}
+}
+
+func TestReadHARInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ReadHAR(strings.NewReader("{")); err == nil {
+		t.Errorf("expected error decoding invalid HAR")
+	}
+
+	bad := `{"log":{"entries":[{"request":{"method":"GET","url":":"},"response":{}}]}}`
+
+	if _, err := ReadHAR(strings.NewReader(bad)); err == nil || !strings.Contains(err.Error(), "HAR entry 0") {
+		t.Errorf("expected error reading HAR entry 0, got %v instead", err)
+	}
+}
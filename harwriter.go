@@ -0,0 +1,352 @@
+package httpretty
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HARWriter writes the exchanges into a HAR (HTTP Archive) 1.2 file, which can be opened in the developer tools
+// of browsers and in HAR analyzers. Use it with Logger.HAR.
+//
+// Each entry is written as soon as its exchange ends, rather than kept until the end of the capture,
+// but a HAR file is a single JSON document, so the archive is only complete once it is closed.
+// The exchanges of a client end once their response body is read to the end or closed.
+// Headers, cookies, and URLs are sanitized as they are in the log, unless Logger.SkipSanitize is set,
+// and bodies have the secrets of Logger.RedactSecrets redacted.
+// Bodies are copied as they are read, and the ones longer than Logger.MaxRequestBody and Logger.MaxResponseBody,
+// or than 4096 bytes if the limits aren't set, are left out of the entries.
+type HARWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	n      int
+	closed bool
+	err    error
+}
+
+// harPrefix starts a HAR document, as encoded by json.Encoder with an indentation of two spaces,
+// up to the entries.
+const harPrefix = `{
+  "log": {
+    "version": "1.2",
+    "creator": {
+      "name": "httpretty",
+      "version": "1"
+    },
+    "entries": [`
+
+// NewHARWriter creates a HAR writer writing to w. Call Close to write the archive.
+func NewHARWriter(w io.Writer) *HARWriter {
+	return &HARWriter{
+		w: w,
+	}
+}
+
+// Close writes the archive with the exchanges logged so far. It doesn't close the underlying writer.
+// Exchanges ending after it is closed are not saved.
+func (hw *HARWriter) Close() error {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	if hw.closed {
+		return nil
+	}
+
+	hw.closed = true
+
+	if hw.err != nil {
+		return hw.err
+	}
+
+	suffix := "\n    ]\n  }\n}\n"
+
+	if hw.n == 0 {
+		suffix = harPrefix + "]\n  }\n}\n"
+	}
+
+	_, err := io.WriteString(hw.w, suffix)
+	return err
+}
+
+// write an entry to the archive. The mutex must be held.
+func (hw *HARWriter) write(entry harEntry) error {
+	b, err := json.MarshalIndent(entry, "      ", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n      "
+
+	if hw.n == 0 {
+		sep = harPrefix + "\n      "
+	}
+
+	hw.n++
+	_, err = io.WriteString(hw.w, sep+string(b))
+	return err
+}
+
+// add an entry to the archive.
+func (hw *HARWriter) add(entry harEntry) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	if !hw.closed && hw.err == nil {
+		hw.err = hw.write(entry)
+	}
+}
+
+func newHAREntry(startedAt time.Time, duration time.Duration, req *http.Request, reqBody []byte,
+	resp *http.Response, respBody []byte, err error) harEntry {
+	ms := float64(duration) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: startedAt,
+		Time:            ms,
+		Request:         newHARRequest(req, reqBody),
+		Timings: harTimings{
+			Wait: ms,
+		},
+	}
+
+	if resp != nil {
+		entry.Response = newHARResponse(resp, respBody)
+	} else {
+		// HAR has no place for failed exchanges, so they get an empty response, as browsers do.
+		entry.Response = harResponse{
+			HTTPVersion: req.Proto,
+			Cookies:     []harCookie{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+
+	if err != nil {
+		entry.Comment = fmt.Sprintf("error: %v", err)
+	}
+
+	return entry
+}
+
+// harEntry redacts an entry built from the exchange of req, as the log is redacted. The cookies are sanitized
+// on their own, as sanitized headers can't be parsed, and bodies are expected to have had their secrets redacted.
+func (er exchangeRedaction) harEntry(entry *harEntry, req *http.Request) {
+	entry.Request.URL = er.url(req.URL)
+
+	for i, q := range entry.Request.QueryString {
+		entry.Request.QueryString[i].Value = er.queryValue(q.Name, q.Value)
+	}
+
+	for _, headers := range [][]harNameValue{entry.Request.Headers, entry.Response.Headers} {
+		for i, h := range headers {
+			headers[i].Value = er.headerValue(h.Name, h.Value)
+		}
+	}
+
+	if er.logger.SkipSanitize {
+		return
+	}
+
+	for _, cookies := range [][]harCookie{entry.Request.Cookies, entry.Response.Cookies} {
+		for i := range cookies {
+			cookies[i].Value = er.logger.redact(cookies[i].Value)
+		}
+	}
+}
+
+func newHARRequest(req *http.Request, body []byte) harRequest {
+	hr := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harNameValues(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		hr.Headers = append([]harNameValue{{Name: "Host", Value: req.Host}}, hr.Headers...)
+	}
+
+	for _, c := range req.Cookies() {
+		hr.Cookies = append(hr.Cookies, harCookie{Name: c.Name, Value: c.Value})
+	}
+
+	query := req.URL.Query()
+	names := make([]string, 0, len(query))
+
+	for name := range query {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range query[name] {
+			hr.QueryString = append(hr.QueryString, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	if body != nil {
+		hr.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(body),
+		}
+	}
+
+	return hr
+}
+
+func newHARResponse(resp *http.Response, body []byte) harResponse {
+	hr := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harNameValues(resp.Header),
+		Content: harContent{
+			Size:     int64(len(body)),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+
+	for _, c := range resp.Cookies() {
+		hr.Cookies = append(hr.Cookies, harCookie{Name: c.Name, Value: c.Value})
+	}
+
+	mediatype, _, _ := mime.ParseMediaType(hr.Content.MimeType)
+
+	switch {
+	case len(body) == 0:
+	case utf8.Valid(body) && !isBinary(body) && !isBinaryMediatype(mediatype):
+		hr.Content.Text = string(body)
+	default:
+		hr.Content.Text = base64.StdEncoding.EncodeToString(body)
+		hr.Content.Encoding = "base64"
+	}
+
+	return hr
+}
+
+func harNameValues(h http.Header) []harNameValue {
+	names := make([]string, 0, len(h))
+
+	for name := range h {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	list := []harNameValue{}
+
+	for _, name := range names {
+		for _, v := range h[name] {
+			list = append(list, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	return list
+}
+
+// harRoundTripper saves the exchanges of a client to a HAR archive.
+type harRoundTripper struct {
+	logger *Logger
+	har    *HARWriter
+	next   http.RoundTripper
+}
+
+func (hrt harRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+
+	// bodies too long aren't read, and are left out of the entry.
+	reqBody, req.Body = readSinkBody(req.Body, req.ContentLength, hrt.logger.MaxRequestBody)
+	started := time.Now()
+	resp, err := hrt.next.RoundTrip(req)
+	duration := time.Since(started)
+
+	er := hrt.logger.exchangeRedaction(req)
+
+	add := func(respBody []byte) {
+		entry := newHAREntry(started, duration, req, er.body(reqBody), resp, er.body(respBody), err)
+		er.harEntry(&entry, req)
+		hrt.har.add(entry)
+	}
+
+	if resp == nil {
+		add(nil)
+		return resp, err
+	}
+
+	// the entry is added once the response body is read or closed.
+	resp.Body = teeBody(resp.Body, resp.ContentLength, hrt.logger.MaxResponseBody, add)
+	return resp, err
+}
+
+// harHandler saves the exchanges of a server to a HAR archive.
+type harHandler struct {
+	logger *Logger
+	har    *HARWriter
+	next   http.Handler
+}
+
+func (hh harHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var reqBody []byte
+
+	reqBody, req.Body = readSinkBody(req.Body, req.ContentLength, hh.logger.MaxRequestBody)
+
+	// entries need an absolute URL.
+	archived := *req
+	u := *req.URL
+	u.Scheme, u.Host = "http", req.Host
+
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+
+	archived.URL = &u
+
+	cw := &copyingResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+		max:            sinkLimit(hh.logger.MaxResponseBody),
+	}
+
+	started := time.Now()
+
+	defer func() {
+		resp := &http.Response{
+			StatusCode: cw.statusCode,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     w.Header().Clone(),
+		}
+
+		var respBody []byte
+
+		if !cw.tooLong {
+			respBody = cw.body.Bytes()
+		}
+
+		er := hh.logger.exchangeRedaction(req)
+		entry := newHAREntry(started, time.Since(started), &archived, er.body(reqBody), resp, er.body(respBody), nil)
+		er.harEntry(&entry, &archived)
+		hh.har.add(entry)
+	}()
+
+	hh.next.ServeHTTP(cw, req)
+}
@@ -0,0 +1,51 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+)
+
+// healthCheckPaths are the gRPC services for health checking and reflection.
+var healthCheckPaths = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// healthCheckAgents are the user agents of probes and load balancers health checking servers.
+var healthCheckAgents = []string{
+	"kube-probe/",         // Kubernetes
+	"ELB-HealthChecker/",  // AWS Elastic Load Balancing
+	"GoogleHC/",           // Google Cloud Load Balancing
+	"Envoy/HC",            // Envoy
+	"Consul Health Check", // Consul
+	"Load Balancer Agent", // Azure Load Balancer
+}
+
+// SkipHealthChecks is a Filter skipping the incoming requests of gRPC health checks and reflection calls,
+// Kubernetes probes, and load balancer health checks, which dominate the logs of most deployments.
+// Outgoing requests aren't skipped. ProfileProdSafe uses it by default.
+//
+// Use it with Logger.SetFilter, calling it from your own filter if you have one.
+func SkipHealthChecks(req *http.Request) (skip bool, err error) {
+	// only incoming requests have the request URI set.
+	if req.RequestURI == "" {
+		return false, nil
+	}
+
+	for _, prefix := range healthCheckPaths {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true, nil
+		}
+	}
+
+	ua := req.Header.Get("User-Agent")
+
+	for _, agent := range healthCheckAgents {
+		if strings.HasPrefix(ua, agent) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,70 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSkipHealthChecks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		path      string
+		userAgent string
+		outgoing  bool
+		want      bool
+	}{
+		{name: "gRPC health check", path: "/grpc.health.v1.Health/Check", want: true},
+		{name: "gRPC health watch", path: "/grpc.health.v1.Health/Watch", want: true},
+		{name: "gRPC reflection", path: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", want: true},
+		{name: "kubernetes probe", path: "/healthz", userAgent: "kube-probe/1.29", want: true},
+		{name: "AWS load balancer", path: "/", userAgent: "ELB-HealthChecker/2.0", want: true},
+		{name: "regular request", path: "/users", userAgent: "curl/8.4.0"},
+		{name: "gRPC call", path: "/helloworld.Greeter/SayHello"},
+		{name: "outgoing health check", path: "/grpc.health.v1.Health/Check", outgoing: true},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+tc.path, nil)
+
+		if tc.outgoing {
+			req.RequestURI = ""
+		}
+
+		if tc.userAgent != "" {
+			req.Header.Set("User-Agent", tc.userAgent)
+		}
+
+		if skip, err := SkipHealthChecks(req); skip != tc.want || err != nil {
+			t.Errorf("SkipHealthChecks(%s) = %v, %v, wanted %v", tc.name, skip, err, tc.want)
+		}
+	}
+}
+
+func TestIncomingProfileProdSafeSkipsHealthChecks(t *testing.T) {
+	t.Parallel()
+
+	logger := ProfileProdSafe()
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(&helloHandler{})
+
+	probe := httptest.NewRequest(http.MethodGet, "http://example.com/healthz", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.29")
+	handler.ServeHTTP(httptest.NewRecorder(), probe)
+
+	if buf.Len() != 0 {
+		t.Errorf("expected probe to be skipped, got %s", buf.String())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	if buf.Len() == 0 {
+		t.Error("expected request to be logged")
+	}
+}
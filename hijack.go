@@ -0,0 +1,75 @@
+package httpretty
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// Hijack lets the handler take over the connection, such as for WebSocket or h2c (cleartext HTTP/2) upgrades,
+// if the underlying ResponseWriter supports it.
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rr.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, errors.New("httpretty: underlying ResponseWriter doesn't support hijacking")
+	}
+
+	conn, rw, err := hj.Hijack()
+
+	if err == nil {
+		rr.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// isH2CUpgrade checks if the client asks to upgrade the connection to HTTP/2 over cleartext.
+// See https://tools.ietf.org/html/rfc7540#section-3.2
+func isH2CUpgrade(req *http.Request) bool {
+	if req.Header.Get("HTTP2-Settings") == "" {
+		return false
+	}
+
+	for _, v := range splitList(req.Header.Get("Upgrade")) {
+		if strings.EqualFold(v, "h2c") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isH2CPriorKnowledge checks if the request is the connection preface of HTTP/2 sent by a client with prior knowledge,
+// which net/http parses as a PRI request.
+// See https://tools.ietf.org/html/rfc7540#section-3.4
+func isH2CPriorKnowledge(req *http.Request) bool {
+	return req.Method == "PRI" && req.ProtoMajor == 2 && (req.RequestURI == "*" || req.URL.Path == "*")
+}
+
+// printH2C prints how a request received by the server relates to h2c (cleartext HTTP/2).
+func (p *printer) printH2C(req *http.Request) {
+	switch {
+	case isH2CPriorKnowledge(req):
+		p.println("* h2c connection preface: the client starts HTTP/2 over cleartext with prior knowledge")
+	case isH2CUpgrade(req):
+		p.println("* h2c upgrade requested: the client offers to switch the connection to HTTP/2 over cleartext")
+	case req.ProtoMajor == 2 && req.TLS == nil:
+		p.println("* HTTP/2 over cleartext (h2c)")
+	}
+}
+
+// printHijacked prints that the handler took over the connection, so the response isn't known.
+func (p *printer) printHijacked(req *http.Request) {
+	if isH2CPriorKnowledge(req) || isH2CUpgrade(req) {
+		p.printf("* %s: the HTTP/2 streams that follow aren't logged; wrap the handler passed to h2c.NewHandler instead\n",
+			p.format(color.FgYellow, "connection taken over for h2c"))
+		return
+	}
+
+	p.println("* connection hijacked by the handler, the response isn't logged")
+}
@@ -0,0 +1,187 @@
+package httpretty
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// h2cUpgradeHandler accepts h2c upgrades by taking over the connection, as golang.org/x/net/http2/h2c does.
+type h2cUpgradeHandler struct{}
+
+func (h2cUpgradeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	hj, ok := w.(http.Hijacker)
+
+	if !ok {
+		http.Error(w, "cannot hijack", http.StatusInternalServerError)
+		return
+	}
+
+	conn, rw, err := hj.Hijack()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	defer conn.Close()
+
+	if req.Method != "PRI" {
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n")
+	}
+
+	rw.WriteString("hijacked")
+	rw.Flush()
+}
+
+func sendRaw(t *testing.T, addr, request string) string {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatalf("cannot write request: %v", err)
+	}
+
+	resp, _ := ioutil.ReadAll(bufio.NewReader(conn))
+	return string(resp)
+}
+
+func TestIncomingH2CUpgrade(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(h2cUpgradeHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	resp := sendRaw(t, ts.Listener.Addr().String(),
+		"GET / HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAARAAAAAAAIAAAAA\r\n\r\n")
+
+	is.Wait()
+
+	if !strings.HasPrefix(resp, "HTTP/1.1 101 Switching Protocols\r\n") || !strings.HasSuffix(resp, "hijacked") {
+		t.Errorf("expected connection to be upgraded, got %q", resp)
+	}
+
+	got := buf.String()
+
+	for _, want := range []string{
+		"* h2c upgrade requested: the client offers to switch the connection to HTTP/2 over cleartext\n",
+		"> Upgrade: h2c\n",
+		"* connection taken over for h2c: the HTTP/2 streams that follow aren't logged; wrap the handler passed to h2c.NewHandler instead\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logged output doesn't contain %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "< HTTP/1.1") {
+		t.Errorf("expected no response to be printed for a hijacked connection:\n%s", got)
+	}
+}
+
+func TestIncomingH2CPriorKnowledge(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(h2cUpgradeHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	if resp := sendRaw(t, ts.Listener.Addr().String(), "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"); resp != "hijacked" {
+		t.Errorf("expected connection to be taken over, got %q", resp)
+	}
+
+	is.Wait()
+
+	got := buf.String()
+
+	for _, want := range []string{
+		"* h2c connection preface: the client starts HTTP/2 over cleartext with prior knowledge\n",
+		"> PRI * HTTP/2.0\n",
+		"* connection taken over for h2c",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logged output doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestIncomingH2CStream(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	// requests served by golang.org/x/net/http2/h2c reach the handler as HTTP/2 without TLS.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+
+	logger.Middleware(helloHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	got := buf.String()
+
+	for _, want := range []string{
+		"* HTTP/2 over cleartext (h2c)\n",
+		"< HTTP/2.0 200 OK\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logged output doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestIncomingHijackUnsupported(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	w := httptest.NewRecorder()
+	logger.Middleware(h2cUpgradeHandler{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "doesn't support hijacking") {
+		t.Errorf("response = %d %q, wanted hijacking error", w.Code, w.Body.String())
+	}
+
+	if !strings.Contains(buf.String(), "< HTTP/1.1 500 Internal Server Error\n") {
+		t.Errorf("expected response to be printed:\n%s", buf.String())
+	}
+}
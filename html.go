@@ -0,0 +1,396 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// HTMLFormatter helps you read HTML pages, such as server-rendered or scraped ones, by printing each element on its own line.
+//
+// Whitespace between words is collapsed and tag names are lowercased, but attributes are kept as they are.
+// The content of pre and textarea elements is kept as it is, and the content of scripts and styles is re-indented.
+type HTMLFormatter struct {
+	// MaxRawText truncates inline scripts and styles longer than it, in bytes, so the markup is easier to follow.
+	// If value is not set, they are printed in full.
+	MaxRawText int
+}
+
+// Match HTML media type.
+func (h *HTMLFormatter) Match(mediatype string) bool {
+	return mediatype == "text/html"
+}
+
+// Format HTML content.
+func (h *HTMLFormatter) Format(w io.Writer, src []byte) error {
+	dst, ok := w.(*bytes.Buffer)
+	if !ok {
+		return errors.New("underlying writer for HTMLFormatter must be *bytes.Buffer")
+	}
+
+	tokens, err := htmlTokens(string(src))
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		depth int
+		open  []string
+	)
+
+	line := func(depth int, s string) {
+		if dst.Len() != 0 {
+			dst.WriteByte('\n')
+		}
+
+		dst.WriteString(strings.Repeat("    ", depth))
+		dst.WriteString(s)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		switch t.kind {
+		case htmlStartTag:
+			for len(open) != 0 && htmlClosedBy(open[len(open)-1], t.name) {
+				open = open[:len(open)-1]
+				depth--
+			}
+
+			// keep elements with only text on a single line.
+			if !t.void && i+2 < len(tokens) && tokens[i+1].kind == htmlText && tokens[i+2].kind == htmlEndTag && tokens[i+2].name == t.name {
+				line(depth, t.text+tokens[i+1].text+tokens[i+2].text)
+				i += 2
+				continue
+			}
+
+			line(depth, t.text)
+
+			if !t.void {
+				open = append(open, t.name)
+				depth++
+			}
+		case htmlEndTag:
+			// elements such as p and li can be left open, so close everything up to the matching element.
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] == t.name {
+					open = open[:j]
+					depth = j
+					break
+				}
+			}
+
+			line(depth, t.text)
+		case htmlRawText:
+			for _, l := range h.rawText(t.text) {
+				line(depth, l)
+			}
+		default:
+			line(depth, t.text)
+		}
+	}
+
+	return nil
+}
+
+// rawText returns the lines of the content of a script or style, without their common indentation.
+func (h *HTMLFormatter) rawText(s string) []string {
+	s = strings.Trim(s, "\r\n")
+	var note string
+
+	if h.MaxRawText > 0 && len(s) > h.MaxRawText {
+		cut := h.MaxRawText
+
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+
+		note = fmt.Sprintf("/* %d bytes truncated */", len(s)-cut)
+		s = s[:cut]
+	}
+
+	lines := strings.Split(s, "\n")
+	indent := -1
+
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		if n := len(l) - len(strings.TrimLeft(l, " \t")); indent == -1 || n < indent {
+			indent = n
+		}
+	}
+
+	var out []string
+
+	for _, l := range lines {
+		l = strings.TrimRight(l, " \t\r")
+
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		}
+
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+
+	if note != "" {
+		out = append(out, note)
+	}
+
+	return out
+}
+
+type htmlTokenKind int
+
+const (
+	htmlText htmlTokenKind = iota
+	htmlStartTag
+	htmlEndTag
+	htmlComment
+	htmlDirective
+	htmlRawText      // content of script and style elements
+	htmlPreformatted // content of pre and textarea elements, start and end tags included
+)
+
+type htmlToken struct {
+	kind htmlTokenKind
+	name string
+	text string
+	void bool
+}
+
+// htmlClosedBy checks if an element left open is closed by the start of another, such as a li by the next one.
+func htmlClosedBy(open, next string) bool {
+	switch open {
+	case "li", "option", "tr":
+		return next == open
+	case "dt", "dd":
+		return next == "dt" || next == "dd"
+	case "td", "th":
+		return next == "td" || next == "th" || next == "tr"
+	case "p":
+		switch next {
+		case "p", "div", "ul", "ol", "dl", "table", "pre", "form", "blockquote", "section", "article",
+			"header", "footer", "nav", "aside", "h1", "h2", "h3", "h4", "h5", "h6", "hr":
+			return true
+		}
+	}
+
+	return false
+}
+
+// htmlVoidElements have no content nor end tag.
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlTokens splits a HTML document into tags, text, and comments, dropping the whitespace between them.
+func htmlTokens(src string) ([]htmlToken, error) {
+	var tokens []htmlToken
+
+	for len(src) != 0 {
+		i := htmlMarkupStart(src)
+
+		if text := strings.Join(strings.Fields(src[:i]), " "); text != "" {
+			tokens = append(tokens, htmlToken{kind: htmlText, text: text})
+		}
+
+		src = src[i:]
+
+		if src == "" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(src, "<!--"):
+			end := strings.Index(src[4:], "-->")
+
+			if end == -1 {
+				return nil, errors.New("HTML syntax error: unexpected EOF in comment")
+			}
+
+			tokens = append(tokens, htmlToken{kind: htmlComment, text: src[:end+7]})
+			src = src[end+7:]
+		case strings.HasPrefix(src, "<!") || strings.HasPrefix(src, "<?"):
+			end := strings.IndexByte(src, '>')
+
+			if end == -1 {
+				return nil, errors.New("HTML syntax error: unexpected EOF in directive")
+			}
+
+			tokens = append(tokens, htmlToken{kind: htmlDirective, text: src[:end+1]})
+			src = src[end+1:]
+		case len(src) > 2 && src[1] == '/' && isASCIILetter(src[2]):
+			end := strings.IndexByte(src, '>')
+
+			if end == -1 {
+				return nil, errors.New("HTML syntax error: unexpected EOF in tag")
+			}
+
+			name := htmlTagName(src[2:end])
+			tokens = append(tokens, htmlToken{kind: htmlEndTag, name: name, text: "</" + name + ">"})
+			src = src[end+1:]
+		default:
+			t, n, err := htmlStartTagToken(src)
+
+			if err != nil {
+				return nil, err
+			}
+
+			src = src[n:]
+
+			switch t.name {
+			case "script", "style", "pre", "textarea":
+				if t.void {
+					break
+				}
+
+				end := indexFold(src, "</"+t.name)
+
+				if end == -1 {
+					end = len(src)
+				}
+
+				content := src[:end]
+				src = src[end:]
+
+				if t.name == "script" || t.name == "style" {
+					tokens = append(tokens, t)
+
+					if strings.TrimSpace(content) != "" {
+						tokens = append(tokens, htmlToken{kind: htmlRawText, text: content})
+					}
+
+					continue
+				}
+
+				// the content is printed as it is, along with its tags.
+				closing := ""
+
+				if gt := strings.IndexByte(src, '>'); gt != -1 {
+					closing, src = "</"+t.name+">", src[gt+1:]
+				}
+
+				t.kind, t.text = htmlPreformatted, t.text+content+closing
+				tokens = append(tokens, t)
+				continue
+			}
+
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, nil
+}
+
+// htmlStartTagToken reads a start tag, normalizing the whitespace between its attributes.
+// It returns the token and its length in the source.
+func htmlStartTagToken(src string) (t htmlToken, n int, err error) {
+	var (
+		b     strings.Builder
+		quote byte
+		space bool
+	)
+
+	for i := 1; i < len(src); i++ {
+		c := src[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			attrs := strings.TrimSpace(b.String())
+			selfClosing := strings.HasSuffix(attrs, "/")
+			attrs = strings.TrimSpace(strings.TrimSuffix(attrs, "/"))
+
+			end := htmlTagNameEnd(attrs)
+			name := strings.ToLower(attrs[:end])
+			text := "<" + name + attrs[end:]
+
+			if selfClosing {
+				text += " /"
+			}
+
+			return htmlToken{
+				kind: htmlStartTag,
+				name: name,
+				text: text + ">",
+				void: selfClosing || htmlVoidElements[name],
+			}, i + 1, nil
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+			space = true
+			continue
+		}
+
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+
+		b.WriteByte(c)
+	}
+
+	return htmlToken{}, 0, errors.New("HTML syntax error: unexpected EOF in tag")
+}
+
+// htmlMarkupStart returns the position of the first tag, comment, or directive, or the length of s if there is none.
+// A "<" not followed by a letter, "/", "!", or "?" is text.
+func htmlMarkupStart(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '<' || i+1 == len(s) {
+			continue
+		}
+
+		switch c := s[i+1]; {
+		case isASCIILetter(c), c == '!', c == '?':
+			return i
+		case c == '/' && i+2 < len(s) && isASCIILetter(s[i+2]):
+			return i
+		}
+	}
+
+	return len(s)
+}
+
+// htmlTagNameEnd returns where the name of a tag ends, given its content.
+func htmlTagNameEnd(s string) int {
+	end := strings.IndexAny(s, " \t\r\n\f/>")
+
+	if end == -1 {
+		end = len(s)
+	}
+
+	return end
+}
+
+// htmlTagName returns the lowercased name of a tag, given its content.
+func htmlTagName(s string) string {
+	return strings.ToLower(s[:htmlTagNameEnd(s)])
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// indexFold is like strings.Index, but ASCII case-insensitive.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+
+	return -1
+}
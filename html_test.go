@@ -0,0 +1,152 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTMLFormatter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		f    HTMLFormatter
+		src  string
+		want string
+	}{
+		{
+			name: "page",
+			src: `<!DOCTYPE html><HTML lang="en"><head><meta charset="utf-8"><title>Hello,
+      world</title></head><body><!-- main --><div   class="a  b"><p>1 < 2<p>Second<br/></div>` +
+				`<pre>  keep
+    this</pre></body></html>`,
+			want: `<!DOCTYPE html>
+<html lang="en">
+    <head>
+        <meta charset="utf-8">
+        <title>Hello, world</title>
+    </head>
+    <body>
+        <!-- main -->
+        <div class="a  b">
+            <p>
+                1 < 2
+            <p>
+                Second
+                <br />
+        </div>
+        <pre>  keep
+    this</pre>
+    </body>
+</html>`,
+		},
+		{
+			name: "script",
+			src: `<script>
+        function hello() {
+            return "</div>";
+        }
+    </script><style></style>`,
+			want: `<script>
+    function hello() {
+        return "</div>";
+    }
+</script>
+<style>
+</style>`,
+		},
+		{
+			name: "truncated script",
+			f:    HTMLFormatter{MaxRawText: 10},
+			src:  `<script type="module">console.log("a long script")</script>`,
+			want: `<script type="module">
+    console.lo
+    /* 18 bytes truncated */
+</script>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+
+		if err := tc.f.Format(&buf, []byte(tc.src)); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: got %s, wanted %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHTMLFormatterInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		`<div class="a`:  "HTML syntax error: unexpected EOF in tag",
+		`<p>hi <!-- no`:  "HTML syntax error: unexpected EOF in comment",
+		`<p>hi</p`:       "HTML syntax error: unexpected EOF in tag",
+		`<!DOCTYPE html`: "HTML syntax error: unexpected EOF in directive",
+	}
+
+	for src, want := range testCases {
+		var buf bytes.Buffer
+		var h HTMLFormatter
+
+		if err := h.Format(&buf, []byte(src)); err == nil || err.Error() != want {
+			t.Errorf("Format(%q) error = %v, wanted %v", src, err, want)
+		}
+	}
+}
+
+func TestOutgoingHTML(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><h1>Hello</h1><ul><li>a<li>b</ul></body></html>`)
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&JSONFormatter{}, &HTMLFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	want := `<html>
+    <body>
+        <h1>Hello</h1>
+        <ul>
+            <li>
+                a
+            <li>
+                b
+        </ul>
+    </body>
+</html>
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
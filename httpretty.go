@@ -56,14 +56,20 @@ package httpretty
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/textproto"
 	"os"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/henvic/httpretty/internal/color"
 )
@@ -88,17 +94,84 @@ type Logger struct {
 	// containing the remote address on server-side requests.
 	SkipRequestInfo bool
 
+	// Fingerprint prints a stable fingerprint of each request on a line such as "* Request fingerprint: 5d41402abc4b2a76",
+	// hashing its method, normalized path, sorted header names, and the SHA-256 checksum of its body,
+	// so identical requests can be grouped across log files and replays.
+	// Request bodies are read fully to compute it, except when they are streamed.
+	Fingerprint bool
+
+	// CurlCommand prints a curl command reproducing each request sent by the client, on a line such as
+	// "* curl command: curl https://example.com/users --header 'Accept: application/json'", with sanitized and
+	// redacted values kept masked. Request bodies are read fully to include them, unless they are streamed,
+	// binary, or too long to print.
+	CurlCommand bool
+
+	// MountedPaths prints the original request URI received by the server along with the path seen by
+	// the handler when a router rewrote it, such as when the logger middleware is used inside http.StripPrefix.
+	MountedPaths bool
+
+	// Archive saves the exchanges to a tar.gz archive, in addition to printing them.
+	Archive *Archive
+
+	// Store keeps the recent exchanges in memory, so they can be searched, in addition to printing them.
+	Store *ExchangeStore
+
+	// HAR saves the exchanges to a HAR (HTTP Archive) file, in addition to printing them.
+	HAR *HARWriter
+
+	// Faults injected into the requests sent by the client, such as delays and errors, to test how it copes
+	// with them. Every fault injected is printed. Requests that aren't logged are left alone. Avoid it in production.
+	Faults []Fault
+
+	// Mirror duplicates requests sent by the client to a secondary target, printing the outcome of both together.
+	// Requests that aren't logged aren't mirrored.
+	Mirror *Mirror
+
+	// Logfmt prints a single logfmt line for each request instead, with its method, host, path,
+	// status, duration, and body sizes, for use with logfmt-based pipelines. Other printing options are ignored.
+	// For example: method=GET host=example.com path=/users status=200 dur=12ms req_bytes=0 resp_bytes=532
+	Logfmt bool
+
+	// AccessLogTemplate formats the line printed for each request when Logfmt is set, instead of logfmt,
+	// such as to replace an existing access log middleware. Create it with NewAccessLogTemplate.
+	// For example, for the Combined Log Format used by Apache and NGINX:
+	// 	logger.AccessLogTemplate, err = httpretty.NewAccessLogTemplate(httpretty.CombinedLogFormat)
+	AccessLogTemplate *template.Template
+
 	// Time the request began and its duration.
+	// When the request context has a deadline, how much of it was used is printed too,
+	// with a warning if the exchange completes within 10% of it.
 	Time bool
 
+	// ServerTimings prints how long the server spent reading the request body, running the handler,
+	// and writing the response, clarifying where server-side latency goes. It is only used by the middleware.
+	ServerTimings bool
+
+	// TimeFormat is the layout used to print the time the request began (see time.Format).
+	// Use TimeFormatUnixMilli to print the number of milliseconds since the Unix epoch.
+	// If value is not set, the time is printed using its default string representation.
+	TimeFormat string
+
+	// Location used to print the time the request began, such as time.UTC.
+	// If value is not set, the time is printed in local time.
+	Location *time.Location
+
 	// TLS information, such as certificates and ciphers.
 	// BUG(henvic): Currently, the TLS information prints after the response header, although it
 	// should be printed before the request header.
 	TLS bool
 
+	// DebugCaller prints a short stack trace of the code that initiated outgoing requests.
+	DebugCaller bool
+
 	// RequestHeader set by the client or received from the server.
 	RequestHeader bool
 
+	// AnnotateAutoHeaders prints the headers the HTTP transport adds to outgoing requests automatically
+	// (such as Accept-Encoding and Content-Length), marking them and the Host header with "(auto)".
+	// Only headers added by a *http.Transport are known.
+	AnnotateAutoHeaders bool
+
 	// RequestBody sent by the client or received by the server.
 	RequestBody bool
 
@@ -108,17 +181,96 @@ type Logger struct {
 	// ResponseBody received by the client or set by the server.
 	ResponseBody bool
 
-	// SkipSanitize bypasses sanitizing headers containing credentials (such as Authorization).
+	// AuditSecurityHeaders prints a scorecard of the security headers of responses, useful during security reviews:
+	// Strict-Transport-Security (over TLS only), Content-Security-Policy, X-Content-Type-Options, and Referrer-Policy.
+	AuditSecurityHeaders bool
+
+	// JoinHeaderValues prints the values of a header sent multiple times on a single line, separated by commas
+	// (or semicolons, for Cookie), instead of on one line for each value, as they are sent on the wire.
+	// Set-Cookie is always printed on one line for each value, as its values cannot be combined.
+	// Headers are printed in alphabetical order, and their values in the order they were sent,
+	// in both the request and the response.
+	JoinHeaderValues bool
+
+	// SkipSanitize bypasses sanitizing headers containing credentials (such as Authorization),
+	// and credentials in URLs: the password of the userinfo, and query parameters such as access_token.
 	SkipSanitize bool
 
+	// PartRedaction sets how the parts of multipart bodies are redacted when they are printed part by part:
+	// which parts have their content skipped, whether file names are hidden, and extra part header sanitizers.
+	// If value is not set, part headers are sanitized like regular headers (unless SkipSanitize is set).
+	PartRedaction *PartRedaction
+
+	// RedactSecrets replaces secrets matching the patterns in the printed bodies, and prints how many were redacted,
+	// protecting captures shared in tickets. Bodies are redacted before being formatted.
+	// Built-in patterns are AWSKeys, GitHubTokens, SlackWebhooks, and PrivateKeys. For example:
+	// 	RedactSecrets: append(httpretty.AWSKeys, httpretty.PrivateKeys...)
+	RedactSecrets []SecretPattern
+
+	// RedactionStyle sets how sanitized headers, credentials in URLs, and secrets (see RedactSecrets) are redacted.
+	// If value is not set, RedactBlocks is used.
+	RedactionStyle RedactionStyle
+
 	// Colors set ANSI escape codes that terminals use to print text in different colors.
 	Colors bool
 
 	// Formatters for the request and response bodies.
 	// No standard formatters are used. You need to add what you want to use explicitly.
 	// We provide a JSONFormatter for convenience (add it manually).
+	// Formatters registered with RegisterFormatter can be created by name with NewFormatters.
+	// Use SetFormatters to change them while the logger is in use.
 	Formatters []Formatter
 
+	// CaptureHeader restricts printing bodies to exchanges whose response carries this header,
+	// such as X-Debug-Capture, with a value other than 0 or false. It allows servers to opt specific
+	// responses into deep logging while most traffic stays summarized.
+	// The request body is held until the response headers are known, and is printed after them.
+	CaptureHeader string
+
+	// ErrorBodiesOnly restricts printing bodies to exchanges whose response has an error status code (400 or higher),
+	// or failed without a response, so headers are the only thing printed for successful exchanges.
+	// The request body is held until the response status code is known, and is printed after the response headers.
+	ErrorBodiesOnly bool
+
+	// ChecksumLongBodies prints the SHA-256 checksum of bodies too long to print (see MaxRequestBody and MaxResponseBody).
+	// By default, bodies declared too long by their Content-Length aren't read or buffered by the logger at all.
+	// Responses written by a server are hashed as they are written, but other bodies are read fully into memory
+	// to compute it.
+	ChecksumLongBodies bool
+
+	// TruncateLongJSON prints the beginning of JSON bodies too long to print (see MaxRequestBody and MaxResponseBody)
+	// instead of skipping them. They are cut at the end of the last complete value within the limit, and the arrays
+	// and objects left open are closed, so the output is still valid JSON and can be parsed by tools such as jq.
+	// Truncated objects get a "_truncated": true field, and truncated arrays a {"_truncated": true} element.
+	TruncateLongJSON bool
+
+	// CountBodyBytes counts the bytes of the request and response bodies that aren't printed, such as when
+	// RequestBody or ResponseBody aren't set, as they are sent and received, without keeping them.
+	// Sizes are printed on lines such as "* response body: 5.2 KiB", and used for the req_bytes and resp_bytes
+	// fields of Logfmt. The size of a response received by the client is printed once it is read or closed.
+	CountBodyBytes bool
+
+	// CapturedResponseBody makes the client return responses whose Body is a *CapturedBody when the body
+	// was fully read for printing it, so callers that also parse the body can get it without reading it again.
+	CapturedResponseBody bool
+
+	// SpillDir is a directory where bodies too long to print (see MaxRequestBody and MaxResponseBody)
+	// are saved to temporary files, instead of being skipped. The file path and a preview are printed.
+	// Bodies are saved as they are read, so they are only complete once fully consumed.
+	// Use os.TempDir() for the default directory for temporary files.
+	SpillDir string
+
+	// OffloadBodies uploads request bodies and client response bodies too long to print
+	// (see MaxRequestBody and MaxResponseBody) to an S3-compatible bucket, instead of being skipped.
+	// The URL of the object and a preview are printed. It takes precedence over SpillDir.
+	// Bodies of responses written by handlers aren't uploaded.
+	OffloadBodies *S3Sink
+
+	// FormatterTimeout limits how long a formatter can take to format a body.
+	// If it takes longer, it is abandoned and the body is printed verbatim after a warning.
+	// If value is not set, formatters run without a time limit.
+	FormatterTimeout time.Duration
+
 	// MaxRequestBody the logger can print.
 	// If value is not set and Content-Length is not sent, 4096 bytes is considered.
 	MaxRequestBody int64
@@ -127,14 +279,68 @@ type Logger struct {
 	// If value is not set and Content-Length is not sent, 4096 bytes is considered.
 	MaxResponseBody int64
 
-	mu         sync.Mutex // ensures atomic writes; protects the following fields
-	w          io.Writer
-	filter     Filter
-	skipHeader map[string]struct{}
-	bodyFilter BodyFilter
-	flusher    Flusher
+	// MaxExchangeBytes caps the output of a single request and response, including headers and bodies.
+	// Output past the limit is dropped and a truncation marker is printed instead.
+	// There is no limit if value is not set.
+	MaxExchangeBytes int64
+
+	// MaxBufferedBytes caps the memory used for the output of all exchanges in progress with the OnEnd flusher,
+	// so many slow concurrent requests don't run the process out of memory.
+	// Once it is reached, the largest bodies buffered are replaced by a summary first,
+	// and if it isn't enough, the exchange is printed before its end.
+	// See Logger.Stats for the memory in use. There is no limit if value is not set.
+	MaxBufferedBytes int64
+
+	// WriteTimeout limits how long the logger waits for writing to the output.
+	// If a write takes longer, the rest of the exchange is dropped (the write itself still finishes
+	// in the background) and a warning is printed once the output is writable again.
+	// Use it so a blocked pipe or a slow file system cannot stall your requests.
+	WriteTimeout time.Duration
+
+	// OrderedOutput keeps the output of concurrent exchanges from interleaving when using NoBuffer or OnReady.
+	// The first exchange to print something holds the output until it ends, while the output of the others
+	// is held back, and written in order once it is released.
+	// Long-lived exchanges, such as streams, hold back the others for as long as they last.
+	OrderedOutput bool
+
+	// SkipUnchangedResponseBody avoids printing a response body identical to the one
+	// last printed for the same method and path, which is useful for polling endpoints.
+	// A line saying how long ago the body was printed is shown instead.
+	SkipUnchangedResponseBody bool
+
+	// SequenceNumbers prints a strictly increasing number for each exchange logged, such as "* Exchange #42",
+	// and includes it in the Logfmt line and in the events of an Encoder, so the order of the exchanges
+	// can be reconstructed even when their timestamps collide or the clock jumps.
+	// Exchanges skipped by a filter don't get a number.
+	SequenceNumbers bool
+
+	// ContextOutput keeps what is printed for each exchange on the request context,
+	// so handlers and inner transports can read it with LoggedOutput.
+	ContextOutput bool
+
+	pendingWrite int32 // set while a write bound by WriteTimeout is in progress; accessed atomically
+
+	mu        sync.Mutex // ensures atomic writes; protects the following fields
+	w         io.Writer
+	config    *config
+	flusher   Flusher
+	stream    *Flusher
+	bodies    map[string]bodyDigest
+	proxyAuth map[string]*proxyAuthFlow
+	sequence  uint64         // of the last exchange, when SequenceNumbers is set
+	owner     *printer       // exchange holding the output when OrderedOutput is set
+	queued    []queuedOutput // output of exchanges that ended while another held the output
+
+	// memory used by exchanges in progress, when MaxBufferedBytes is set.
+	buffered         int64
+	inflight         map[*printer]struct{}
+	summarizedBodies uint64
+	earlyFlushes     uint64
 }
 
+// TimeFormatUnixMilli can be used as the Logger.TimeFormat to print the number of milliseconds since the Unix epoch.
+const TimeFormatUnixMilli = "UnixMilli"
+
 // Filter allows you to skip requests.
 //
 // If an error happens and you want to log it, you can pass a not-null error value.
@@ -150,6 +356,11 @@ type Filter func(req *http.Request) (skip bool, err error)
 type BodyFilter func(h http.Header) (skip bool, err error)
 
 // Flusher defines how logger prints requests.
+//
+// Whatever the Flusher, each write to the output happens in a single Write call, under the logger mutex,
+// so text is never cut in the middle of a line: with NoBuffer, it holds a single line or body;
+// with OnReady, a step; and with OnEnd, the whole exchange. Set Logger.OrderedOutput to avoid
+// interleaving the steps of concurrent exchanges with NoBuffer and OnReady.
 type Flusher int
 
 // Logger can print without flushing, when they are available, or when the request is done.
@@ -169,29 +380,101 @@ const (
 // SetFilter allows you to set a function to skip requests.
 // Pass nil to remove the filter. This method is concurrency safe.
 func (l *Logger) SetFilter(f Filter) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.filter = f
+	l.updateConfig(func(c *config) {
+		c.filter = f
+	})
 }
 
 // SkipHeader allows you to skip printing specific headers.
 // This method is concurrency safe.
 func (l *Logger) SkipHeader(headers []string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
 	m := map[string]struct{}{}
 	for _, h := range headers {
 		m[textproto.CanonicalMIMEHeaderKey(h)] = struct{}{}
 	}
-	l.skipHeader = m
+
+	l.updateConfig(func(c *config) {
+		c.skipHeader = m
+	})
+}
+
+// Methods allows you to restrict full logging to specific HTTP methods.
+// Requests using other methods only get the request line summary.
+// Pass nil to log all methods. This method is concurrency safe.
+func (l *Logger) Methods(methods []string) {
+	var m map[string]struct{}
+
+	if len(methods) != 0 {
+		m = map[string]struct{}{}
+		for _, method := range methods {
+			m[strings.ToUpper(method)] = struct{}{}
+		}
+	}
+
+	l.updateConfig(func(c *config) {
+		c.methods = m
+	})
+}
+
+// OnlyHosts restricts logging outgoing requests to the given hosts, such as "api.example.com".
+// Hostnames are matched case-insensitively, ignoring ports.
+// Call it without arguments to log requests to any host. This method is concurrency safe.
+func (l *Logger) OnlyHosts(hosts ...string) {
+	m := hostSet(hosts)
+
+	l.updateConfig(func(c *config) {
+		c.onlyHosts = m
+	})
+}
+
+// SkipHosts skips logging outgoing requests to the given hosts, such as telemetry endpoints.
+// Hostnames are matched case-insensitively, ignoring ports.
+// Call it without arguments to stop skipping hosts. This method is concurrency safe.
+func (l *Logger) SkipHosts(hosts ...string) {
+	m := hostSet(hosts)
+
+	l.updateConfig(func(c *config) {
+		c.skipHosts = m
+	})
+}
+
+func hostSet(hosts []string) map[string]struct{} {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	m := map[string]struct{}{}
+	for _, host := range hosts {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		m[strings.ToLower(host)] = struct{}{}
+	}
+	return m
+}
+
+// PathPatterns replaces the path of requests matching a pattern with the pattern itself when printing them,
+// such as /users/123 with /users/{id}. Segments between braces are placeholders matching any non-empty segment.
+// The first matching pattern is used. This produces logs safe to aggregate, and reduces accidental
+// personal information in URLs, but queries are printed as they are.
+// Call it without arguments to print paths as they are. This method is concurrency safe.
+func (l *Logger) PathPatterns(patterns ...string) {
+	var paths []pathPattern
+	for _, pattern := range patterns {
+		paths = append(paths, newPathPattern(pattern))
+	}
+
+	l.updateConfig(func(c *config) {
+		c.paths = paths
+	})
 }
 
 // SetBodyFilter allows you to set a function to skip printing a body.
 // Pass nil to remove the body filter. This method is concurrency safe.
 func (l *Logger) SetBodyFilter(f BodyFilter) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.bodyFilter = f
+	l.updateConfig(func(c *config) {
+		c.bodyFilter = f
+	})
 }
 
 // SetOutput sets the output destination for the logger.
@@ -208,39 +491,21 @@ func (l *Logger) SetFlusher(f Flusher) {
 	l.flusher = f
 }
 
-func (l *Logger) getWriter() io.Writer {
-	if l.w == nil {
-		return os.Stdout
-	}
-
-	return l.w
-}
-
-func (l *Logger) getFilter() Filter {
+// SetStreamFlusher sets the flush strategy for streaming exchanges, such as server-sent events
+// and protocol upgrades like WebSocket, overriding the one set with SetFlusher.
+// Use it to print long-lived exchanges as they happen, for example, with NoBuffer or OnReady.
+func (l *Logger) SetStreamFlusher(f Flusher) {
 	l.mu.Lock()
-	f := l.filter
 	defer l.mu.Unlock()
-	return f
+	l.stream = &f
 }
 
-func (l *Logger) getBodyFilter() BodyFilter {
-	l.mu.Lock()
-	f := l.bodyFilter
-	defer l.mu.Unlock()
-	return f
-}
-
-func (l *Logger) cloneSkipHeader() map[string]struct{} {
-	l.mu.Lock()
-	skipped := l.skipHeader
-	l.mu.Unlock()
-
-	m := map[string]struct{}{}
-	for h := range skipped {
-		m[h] = struct{}{}
+func (l *Logger) getWriter() io.Writer {
+	if l.w == nil {
+		return os.Stdout
 	}
 
-	return m
+	return l.w
 }
 
 type contextHide struct{}
@@ -273,22 +538,94 @@ func (r roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err err
 	p := newPrinter(l)
 	defer p.flush()
 
+	if l.ContextOutput {
+		p.logged = &loggedOutput{}
+	}
+
 	if hide := req.Context().Value(contextHide{}); hide != nil || p.checkFilter(req) {
 		return tripper.RoundTrip(req)
 	}
 
+	p.startSummary(req)
+	p.redaction = getRedaction(req.Context())
+
+	if !p.config.isHostLogged(req.URL) {
+		return tripper.RoundTrip(req)
+	}
+
+	p.nextSequence()
+
+	// transport is the round tripper before any wrapping, used for inspecting its configuration.
+	transport := unwrapTransport(tripper)
+
+	if len(l.Faults) != 0 {
+		tripper = faultRoundTripper{p: &p, faults: l.Faults, next: tripper}
+	}
+
+	if l.Mirror != nil {
+		tripper = mirrorRoundTripper{logger: l, mirror: l.Mirror, next: tripper}
+	}
+
+	if l.Archive != nil {
+		tripper = archiveRoundTripper{logger: l, archive: l.Archive, next: tripper}
+	}
+
+	if l.Store != nil {
+		tripper = storeRoundTripper{logger: l, store: l.Store, next: tripper}
+	}
+
+	if l.HAR != nil {
+		tripper = harRoundTripper{logger: l, har: l.HAR, next: tripper}
+	}
+
+	if isStreamingRequest(req) {
+		p.streaming()
+	}
+
+	if enc := p.config.encoder; enc != nil {
+		return p.roundTripEncoded(enc, tripper, req)
+	}
+
+	if l.Logfmt {
+		return p.roundTripLogfmt(tripper, req)
+	}
+
+	p.printSequence()
+
+	if !p.config.isFullyLogged(req.Method) {
+		if !l.SkipRequestInfo {
+			p.printRequestInfo(req)
+		}
+
+		return tripper.RoundTrip(req)
+	}
+
 	var tlsClientConfig *tls.Config
 
 	if l.Time {
-		defer p.printTimeRequest()()
+		defer p.printTimeRequest(req.Context())()
 	}
 
 	if !l.SkipRequestInfo {
 		p.printRequestInfo(req)
 	}
 
-	if transport, ok := tripper.(*http.Transport); ok && transport.TLSClientConfig != nil {
-		tlsClientConfig = transport.TLSClientConfig
+	if _, ok := req.Context().Value(contextAnnotations{}).(*annotations); !ok {
+		req = req.WithContext(WithAnnotations(req.Context()))
+	}
+
+	p.annotations = req.Context().Value(contextAnnotations{}).(*annotations)
+
+	if l.AnnotateAutoHeaders {
+		p.autoHeaders = transportHeaders(req, transport)
+	}
+
+	if l.DebugCaller {
+		p.printCaller(callerFrames(1))
+	}
+
+	if t, ok := transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		tlsClientConfig = t.TLSClientConfig
 
 		if tlsClientConfig.InsecureSkipVerify {
 			p.printf("* Skipping TLS verification: %s\n",
@@ -301,13 +638,44 @@ func (r roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err err
 		p.printOutgoingClientTLS(tlsClientConfig)
 	}
 
+	if l.RequestHeader {
+		p.printRedirectCookies(req)
+	}
+
+	var curlCmd, curlNote string
+
+	if l.CurlCommand {
+		curlCmd, curlNote = p.curlCommand(req, tlsClientConfig)
+	}
+
 	p.printRequest(req)
 
+	if l.CurlCommand {
+		p.printCurlCommand(curlCmd, curlNote)
+	}
+
+	p.printProxyAuthRetry(req)
+
+	if l.ResponseHeader {
+		req = req.WithContext(p.traceInformational(req.Context(), req.Proto))
+	}
+
+	req = req.WithContext(p.withLoggedOutput(p.traceRetries(req.Context())))
+
+	defer p.printAnnotations()
+
 	defer func() {
+		p.printStreamedRequestBody(req.Header)
+		p.printRequestBodySize()
+		p.printPrincipal()
+		p.printProxyAuth(req, resp, err, transport)
+
 		if err != nil {
+			p.setErr(err)
 			p.printf("* %s\n", p.format(color.FgRed, err))
 
 			if resp == nil {
+				p.releaseRequestBody(p.isCaptured(0, nil))
 				return
 			}
 		}
@@ -317,6 +685,8 @@ func (r roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err err
 			p.printTLSServer(req.Host, resp.TLS)
 		}
 
+		p.printProtocolNegotiation(offeredProtocols(transport), resp)
+
 		p.printResponse(resp)
 	}()
 
@@ -325,34 +695,103 @@ func (r roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err err
 
 // Middleware for logging incoming requests to a HTTP server.
 func (l *Logger) Middleware(next http.Handler) http.Handler {
-	return httpHandler{
+	return l.Handler(next)
+}
+
+// Handler wraps a http.Handler for logging its incoming requests, like Middleware.
+// Options such as the route name can be attached when wrapping it.
+func (l *Logger) Handler(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	h := httpHandler{
 		logger: l,
 		next:   next,
 	}
+
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+
+	return h
 }
 
 type httpHandler struct {
 	logger *Logger
 	next   http.Handler
+	opts   middlewareOptions
 }
 
 // ServeHTTP is a middleware for logging incoming requests to a HTTP server.
 func (h httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	l := h.logger
 	p := newPrinter(l)
+	p.route = h.opts.route
+	p.fields = h.opts.fields
+	p.skipBodies = h.opts.verbosity == VerbosityHeaders
 	defer p.flush()
 
+	if l.ContextOutput {
+		p.logged = &loggedOutput{}
+	}
+
 	if hide := req.Context().Value(contextHide{}); hide != nil || p.checkFilter(req) {
 		h.next.ServeHTTP(w, req)
 		return
 	}
 
+	p.startSummary(req)
+	p.redaction = getRedaction(req.Context())
+	p.nextSequence()
+
+	if isStreamingRequest(req) {
+		p.streaming()
+	}
+
+	if l.Archive != nil {
+		h.next = archiveHandler{logger: l, archive: l.Archive, next: h.next}
+	}
+
+	if l.Store != nil {
+		h.next = storeHandler{logger: l, store: l.Store, next: h.next}
+	}
+
+	if l.HAR != nil {
+		h.next = harHandler{logger: l, har: l.HAR, next: h.next}
+	}
+
+	if enc := p.config.encoder; enc != nil {
+		p.serveEncoded(enc, h.next, w, req)
+		return
+	}
+
+	if l.Logfmt {
+		p.serveLogfmt(h.next, w, req)
+		return
+	}
+
+	p.printSequence()
+
+	if !p.config.isFullyLogged(req.Method) || h.opts.verbosity == VerbositySummary || !h.opts.trigger.triggered(req) {
+		if !l.SkipRequestInfo {
+			p.printRequestInfo(req)
+		}
+
+		h.next.ServeHTTP(w, req)
+		return
+	}
+
 	if p.logger.Time {
-		defer p.printTimeRequest()()
+		defer p.printTimeRequest(req.Context())()
+	}
+
+	var timings *serverTimings
+
+	if p.logger.ServerTimings {
+		timings = &serverTimings{start: time.Now()}
+		req.Body = &timedBody{ReadCloser: req.Body, timings: timings}
 	}
 
 	if !p.logger.SkipRequestInfo {
 		p.printRequestInfo(req)
+		p.printH2C(req)
 	}
 
 	if p.logger.TLS {
@@ -369,9 +808,43 @@ func (h httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 		maxReadableBody: l.MaxResponseBody,
 		buf:             &bytes.Buffer{},
+		spillDir:        l.SpillDir,
+		timings:         timings,
+	}
+
+	if l.ChecksumLongBodies {
+		rec.checksum = sha256.New()
+	}
+
+	if l.TruncateLongJSON {
+		rec.keepHead = true
+	}
+
+	if !l.ResponseBody || p.skipBodies {
+		rec.skipBody = true
+	}
+
+	rec.startEvents = func() *eventStream {
+		return p.startEventStream(req, rec, h.opts.resolveFile)
+	}
+
+	req = req.WithContext(p.withLoggedOutput(WithAnnotations(req.Context())))
+	p.annotations = req.Context().Value(contextAnnotations{}).(*annotations)
+
+	defer rec.closeSpill()
+	defer p.printAnnotations()
+
+	if timings != nil {
+		defer p.printServerTimings(timings)
+	}
+
+	defer p.printWriteAborted(req, rec)
+	defer p.printServerResponse(req, rec, h.opts.resolveFile)
+
+	if timings != nil {
+		defer timings.stop()
 	}
 
-	defer p.printServerResponse(req, rec)
 	h.next.ServeHTTP(rec, req)
 }
 
@@ -379,7 +852,8 @@ func (h httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 //
 // It doesn't log TLS connection details or request duration.
 func (l *Logger) PrintRequest(req *http.Request) {
-	var p = printer{logger: l}
+	var p = newPrinter(l)
+	p.flusher, p.streamFlusher = NoBuffer, nil
 
 	if skip := p.checkFilter(req); skip {
 		return
@@ -390,7 +864,8 @@ func (l *Logger) PrintRequest(req *http.Request) {
 
 // PrintResponse prints a response.
 func (l *Logger) PrintResponse(resp *http.Response) {
-	var p = printer{logger: l}
+	var p = newPrinter(l)
+	p.flusher, p.streamFlusher = NoBuffer, nil
 	p.printResponse(resp)
 }
 
@@ -399,7 +874,18 @@ func (l *Logger) PrintResponse(resp *http.Response) {
 // github.com/tidwall/pretty could be used to add colors to it.
 // However, it would add an external dependency. If you want, you can define
 // your own formatter using it or anything else. See Formatter.
-type JSONFormatter struct{}
+type JSONFormatter struct {
+	// ArraySample limits how many elements of long arrays are printed.
+	// Arrays with more than twice this number of elements are printed with only their first and last
+	// ArraySample elements, and a count of the elements left out.
+	// If value is not set, all elements are printed.
+	ArraySample int
+
+	// MaxDepth limits how deeply nested arrays and objects are printed, protecting against adversarial documents.
+	// Arrays and objects nested deeper are replaced with a truncation notice.
+	// If value is not set, documents are printed regardless of their depth.
+	MaxDepth int
+}
 
 // Match JSON media type.
 func (j *JSONFormatter) Match(mediatype string) bool {
@@ -422,5 +908,58 @@ func (j *JSONFormatter) Format(w io.Writer, src []byte) error {
 		// mitigating panic to avoid upsetting anyone who uses this directly
 		return errors.New("underlying writer for JSONFormatter must be *bytes.Buffer")
 	}
+
+	if j.ArraySample > 0 || j.MaxDepth > 0 {
+		s := jsonSampler{
+			arraySample: j.ArraySample,
+			maxDepth:    j.MaxDepth,
+		}
+		return s.format(dst, src, "", 0)
+	}
 	return json.Indent(dst, src, "", "    ")
 }
+
+// NDJSONFormatter helps you read newline delimited JSON streams, also known as JSON Lines,
+// formatting each JSON value on its own, separated by an empty line.
+// See http://ndjson.org/ and https://jsonlines.org/
+type NDJSONFormatter struct{}
+
+// Match NDJSON and JSON Lines media types.
+func (n *NDJSONFormatter) Match(mediatype string) bool {
+	switch mediatype {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonl",
+		"application/jsonlines", "application/x-jsonlines":
+		return true
+	}
+
+	return false
+}
+
+// Format NDJSON content.
+func (n *NDJSONFormatter) Format(w io.Writer, src []byte) error {
+	dst, ok := w.(*bytes.Buffer)
+	if !ok {
+		return errors.New("underlying writer for NDJSONFormatter must be *bytes.Buffer")
+	}
+
+	var j JSONFormatter
+	first := true
+
+	for i, line := range bytes.Split(src, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		if !first {
+			dst.WriteString("\n\n")
+		}
+
+		first = false
+
+		if err := j.Format(dst, line); err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -2,10 +2,12 @@ package httpretty
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
@@ -151,6 +153,34 @@ func TestPrintResponseNil(t *testing.T) {
 	}
 }
 
+// testResponseWriterInterfaces checks if the handlers wrapped by the middleware of the logger can still flush,
+// hijack the connection, and send files with sendfile.
+func testResponseWriterInterfaces(t *testing.T, logger *Logger) {
+	t.Helper()
+
+	var flusher, hijacker, readerFrom bool
+
+	ts := httptest.NewServer(logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		_, readerFrom = w.(io.ReaderFrom)
+	})))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	if !flusher || !hijacker || !readerFrom {
+		t.Errorf("response writer implements http.Flusher = %v, http.Hijacker = %v, io.ReaderFrom = %v, wanted all",
+			flusher, hijacker, readerFrom)
+	}
+}
+
 func testBody(t *testing.T, r io.Reader, want []byte) {
 	t.Helper()
 
@@ -194,3 +224,276 @@ func newTransport() *http.Transport {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 }
+
+func TestFormatTime(t *testing.T) {
+	t.Parallel()
+
+	tm := time.Date(2020, 1, 2, 15, 4, 5, 678000000, time.FixedZone("BRT", -3*60*60))
+
+	testCases := []struct {
+		format   string
+		location *time.Location
+		want     string
+	}{
+		{want: "2020-01-02 15:04:05.678 -0300 BRT"},
+		{location: time.UTC, want: "2020-01-02 18:04:05.678 +0000 UTC"},
+		{format: time.RFC3339Nano, location: time.UTC, want: "2020-01-02T18:04:05.678Z"},
+		{format: time.RFC3339, want: "2020-01-02T15:04:05-03:00"},
+		{format: TimeFormatUnixMilli, want: "1577988245678"},
+	}
+
+	for _, tc := range testCases {
+		p := printer{logger: &Logger{TimeFormat: tc.format, Location: tc.location}}
+
+		if got := p.formatTime(tm); got != tc.want {
+			t.Errorf("formatTime(%q, %v) = %v, wanted %v", tc.format, tc.location, got, tc.want)
+		}
+	}
+}
+
+func TestPrintDeadlineBudget(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		took   time.Duration
+		budget time.Duration
+		want   string
+	}{
+		{
+			took:   500 * time.Millisecond,
+			budget: 2 * time.Second,
+			want:   "* used 500ms of 2s deadline\n",
+		},
+		{
+			took:   1700*time.Millisecond + 100*time.Microsecond,
+			budget: 2 * time.Second,
+			want:   "* used 1.7s of 2s deadline\n",
+		},
+		{
+			took:   1850 * time.Millisecond,
+			budget: 2 * time.Second,
+			want:   "* used 1.85s of 2s deadline\n* completed within 10% of its deadline\n",
+		},
+		{
+			took:   3 * time.Second,
+			budget: 2 * time.Second,
+			want:   "* used 3s of 2s deadline\n* completed within 10% of its deadline\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printDeadlineBudget(tc.took, tc.budget)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("printDeadlineBudget(%v, %v) = %q, wanted %q", tc.took, tc.budget, got, tc.want)
+		}
+	}
+}
+
+func TestNDJSONFormatter(t *testing.T) {
+	t.Parallel()
+
+	f := &NDJSONFormatter{}
+
+	for _, mediatype := range []string{"application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines"} {
+		if !f.Match(mediatype) {
+			t.Errorf("NDJSONFormatter doesn't match %s", mediatype)
+		}
+	}
+
+	if f.Match("application/json") {
+		t.Errorf("NDJSONFormatter shouldn't match application/json")
+	}
+
+	var buf bytes.Buffer
+
+	if err := f.Format(&buf, []byte("{\"a\":1}\r\n\n[true, null]\n")); err != nil {
+		t.Errorf("NDJSONFormatter.Format() error = %v", err)
+	}
+
+	want := `{
+    "a": 1
+}
+
+[
+    true,
+    null
+]`
+
+	if got := buf.String(); got != want {
+		t.Errorf("NDJSONFormatter.Format() = %v, wanted %v", got, want)
+	}
+
+	buf.Reset()
+
+	if err := f.Format(&buf, []byte("{}\n{\n")); err == nil || err.Error() != "line 2: unexpected end of JSON input" {
+		t.Errorf("NDJSONFormatter.Format() error = %v, wanted line 2 error", err)
+	}
+}
+
+func TestPrintHeaders(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{
+		"Authorization": []string{"Bearer secret"},
+		"X-Discount":    []string{"50%d off"},
+		"X-Skipped":     []string{"skip me"},
+	}
+
+	auto := http.Header{
+		"Accept-Encoding": []string{"gzip"},
+	}
+
+	for _, colors := range []bool{false, true} {
+		logger := &Logger{
+			Colors: colors,
+		}
+
+		logger.SkipHeader([]string{"X-Skipped"})
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printHeadersMarked('>', h, auto)
+
+		want := `> Accept-Encoding: gzip (auto)
+> Authorization: Bearer ████████████████████
+> X-Discount: 50%d off
+`
+
+		if colors {
+			want = "> \x1b[34;1mAccept-Encoding\x1b[0m\x1b[31m:\x1b[0m \x1b[33mgzip\x1b[0m \x1b[2m(auto)\x1b[0m\n" +
+				"> \x1b[34;1mAuthorization\x1b[0m\x1b[31m:\x1b[0m \x1b[33mBearer ████████████████████\x1b[0m\n" +
+				"> \x1b[34;1mX-Discount\x1b[0m\x1b[31m:\x1b[0m \x1b[33m50%d off\x1b[0m\n"
+		}
+
+		if got := buf.String(); got != want {
+			t.Errorf("printed headers (colors=%v) %q; want %q", colors, got, want)
+		}
+	}
+}
+
+func TestPrintHeadersNotCanonical(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{
+		"authorization":       []string{"Bearer topsecret"},
+		"cookie":              []string{"session=topsecret"},
+		"x-tenant-key":        []string{"topsecret"},
+		"proxy-authorization": []string{"Basic topsecret"},
+	}
+
+	logger := &Logger{}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	p := newPrinter(logger)
+	p.redaction = &Redaction{Headers: []string{"X-Tenant-Key"}}
+	p.printHeaders('>', h)
+
+	want := `> authorization: Bearer ████████████████████
+> cookie: session=████████████████████
+> proxy-authorization: Basic ████████████████████
+> x-tenant-key: ████████████████████
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("printed headers %q; want %q", got, want)
+	}
+}
+
+func TestPrintHeadersJoinValues(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{
+		"Accept":     []string{"text/html", "application/json"},
+		"Cookie":     []string{"a=1", "b=2"},
+		"Set-Cookie": []string{"a=1", "b=2"},
+		"Via":        []string{"1.1 proxy"},
+	}
+
+	testCases := []struct {
+		join bool
+		want string
+	}{
+		{
+			join: false,
+			want: `< Accept: text/html
+< Accept: application/json
+< Cookie: a=████████████████████
+< Cookie: b=████████████████████
+< Set-Cookie: a=████████████████████
+< Set-Cookie: b=████████████████████
+< Via: 1.1 proxy
+`,
+		},
+		{
+			join: true,
+			want: `< Accept: text/html, application/json
+< Cookie: a=████████████████████; b=████████████████████
+< Set-Cookie: a=████████████████████
+< Set-Cookie: b=████████████████████
+< Via: 1.1 proxy
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{
+			JoinHeaderValues: tc.join,
+		}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printHeaders('<', h)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("printed headers (join=%v) %s; want %s", tc.join, got, tc.want)
+		}
+	}
+}
+
+func benchmarkHeaders() http.Header {
+	h := http.Header{
+		"Authorization": []string{"Bearer abcdefghijklmnopqrstuvwxyz"},
+		"Cookie":        []string{"session=abc; theme=dark"},
+	}
+
+	for i := 0; i < 60; i++ {
+		h.Add(fmt.Sprintf("X-Custom-Header-%02d", i), fmt.Sprintf("value %d", i))
+	}
+
+	return h
+}
+
+func BenchmarkPrintHeaders(b *testing.B) {
+	for _, colors := range []bool{false, true} {
+		b.Run(fmt.Sprintf("colors=%v", colors), func(b *testing.B) {
+			logger := &Logger{
+				Colors: colors,
+			}
+
+			logger.SetOutput(ioutil.Discard)
+			logger.SkipHeader([]string{"X-Custom-Header-00"})
+			h := benchmarkHeaders()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				p := newPrinter(logger)
+				p.printHeaders('<', h)
+			}
+		})
+	}
+}
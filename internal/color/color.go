@@ -128,6 +128,15 @@ func StripAttributes(s ...interface{}) (raw string) {
 	return
 }
 
+// Start returns the escape sequence formatting the text following it with the attributes, until End.
+// Use it to write formatted text without allocating, such as in a loop.
+func Start(params ...Attribute) string {
+	return fmt.Sprintf("%s[%sm", escape, sequence(params))
+}
+
+// End is the escape sequence resetting the attributes set by Start.
+var End = fmt.Sprintf("%s[%dm", escape, Reset)
+
 // Escape text for terminal.
 func Escape(s string) string {
 	return strings.Replace(s, escape, unescape, -1)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"unicode"
 )
 
 // Sanitize list of headers.
@@ -42,73 +43,102 @@ var DefaultSanitizers = map[string]SanitizeHeaderFunc{
 	"Proxy-Authorization": AuthorizationSanitizer,
 }
 
+// ShapeSanitizers sanitizes the same headers as DefaultSanitizers, but masks values with Shape.
+var ShapeSanitizers = map[string]SanitizeHeaderFunc{
+	"Authorization":       func(unsafe string) string { return authorization(unsafe, Shape) },
+	"Set-Cookie":          func(unsafe string) string { return setCookie(unsafe, Shape) },
+	"Cookie":              func(unsafe string) string { return cookie(unsafe, Shape) },
+	"Proxy-Authorization": func(unsafe string) string { return authorization(unsafe, Shape) },
+}
+
 // SanitizeHeaderFunc implements sanitization for a header value.
 type SanitizeHeaderFunc func(string) string
 
 // AuthorizationSanitizer is used to sanitize Authorization and Proxy-Authorization headers.
 func AuthorizationSanitizer(unsafe string) string {
+	return authorization(unsafe, redact)
+}
+
+func authorization(unsafe string, mask SanitizeHeaderFunc) string {
 	if unsafe == "" {
 		return ""
 	}
 
 	directives := strings.SplitN(unsafe, " ", 2)
 
-	l := 0
-
-	if len(directives) > 1 {
-		l = len(directives[1])
-	}
-
-	if l == 0 {
+	if len(directives) < 2 || directives[1] == "" {
 		return directives[0]
 	}
 
-	return directives[0] + " " + redact(l)
+	return directives[0] + " " + mask(directives[1])
 }
 
 // SetCookieSanitizer is used to sanitize Set-Cookie header.
 func SetCookieSanitizer(unsafe string) string {
+	return setCookie(unsafe, redact)
+}
+
+func setCookie(unsafe string, mask SanitizeHeaderFunc) string {
 	directives := strings.SplitN(unsafe, ";", 2)
 
 	cookie := strings.SplitN(directives[0], "=", 2)
 
-	l := 0
+	v := ""
 
 	if len(cookie) > 1 {
-		l = len(cookie[1])
+		v = mask(cookie[1])
 	}
 
 	if len(directives) == 2 {
-		return fmt.Sprintf("%s=%s; %s", cookie[0], redact(l), strings.TrimPrefix(directives[1], " "))
+		return fmt.Sprintf("%s=%s; %s", cookie[0], v, strings.TrimPrefix(directives[1], " "))
 	}
 
-	return fmt.Sprintf("%s=%s", cookie[0], redact(l))
+	return fmt.Sprintf("%s=%s", cookie[0], v)
 }
 
 // CookieSanitizer is used to sanitize Cookie header.
 func CookieSanitizer(unsafe string) string {
+	return cookie(unsafe, redact)
+}
+
+func cookie(unsafe string, mask SanitizeHeaderFunc) string {
 	cookies := strings.Split(unsafe, ";")
 
 	var list []string
 
 	for _, unsafeCookie := range cookies {
 		cookie := strings.SplitN(unsafeCookie, "=", 2)
-		l := 0
+		v := ""
 
 		if len(cookie) > 1 {
-			l = len(cookie[1])
+			v = mask(cookie[1])
 		}
 
-		list = append(list, fmt.Sprintf("%s=%s", cookie[0], redact(l)))
+		list = append(list, fmt.Sprintf("%s=%s", cookie[0], v))
 	}
 
 	return strings.Join(list, "; ")
 }
 
-func redact(count int) string {
-	if count == 0 {
+func redact(v string) string {
+	if v == "" {
 		return ""
 	}
 
 	return "████████████████████"
 }
+
+// Shape masks a value preserving its length and character classes: digits are replaced by 0, letters by x,
+// and other characters, such as whitespace and punctuation, are kept.
+func Shape(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsDigit(r):
+			return '0'
+		case unicode.IsLetter(r):
+			return 'x'
+		}
+
+		return r
+	}, v)
+}
@@ -42,3 +42,23 @@ func TestSanitize(t *testing.T) {
 		t.Errorf("Sanitized headers doesn't match expected value: wanted %+v, got %+v instead", want, got)
 	}
 }
+
+func TestSanitizeShape(t *testing.T) {
+	var headers = http.Header{}
+
+	headers.Add("Cookie", "abcd=Secret 12; xyz=")
+	headers.Add("Set-Cookie", "id=a3fWa-9; Secure; HttpOnly")
+	headers.Add("Authorization", "Bearer sk_live_4eC39Hq ")
+	headers.Set("Content-Type", "application/json")
+
+	want := http.Header{
+		"Cookie":        []string{"abcd=xxxxxx 00;  xyz="},
+		"Set-Cookie":    []string{"id=x0xxx-0; Secure; HttpOnly"},
+		"Authorization": []string{"Bearer xx_xxxx_0xx00xx "},
+		"Content-Type":  []string{"application/json"},
+	}
+
+	if got := Sanitize(ShapeSanitizers, headers); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sanitized headers doesn't match expected value: wanted %+v, got %+v instead", want, got)
+	}
+}
@@ -0,0 +1,111 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournaldSink writes the logs to the systemd journal, using its native protocol.
+// Use it as the output of a logger with Logger.SetOutput, with the OnEnd flusher so each exchange is a single entry.
+//
+// The priority of an entry depends on the class of the status code of the response:
+// server errors (5xx) and failed exchanges are errors, client errors (4xx) are warnings,
+// and everything else is informational. The status code is also saved in the HTTP_STATUS field.
+// Entries written to it directly, rather than by a logger, are informational.
+//
+// Entries are sent as datagrams, so they are limited by the size of the socket send buffer.
+type JournaldSink struct {
+	// Identifier saved as the SYSLOG_IDENTIFIER field, such as "api-server".
+	// If value is not set, the program name is used.
+	Identifier string
+
+	// Socket of the journal. If value is not set, /run/systemd/journal/socket is used.
+	Socket string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Write an entry with informational priority.
+func (j *JournaldSink) Write(p []byte) (n int, err error) {
+	if err := j.writeExchange(string(p), 0, false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (j *JournaldSink) writeExchange(msg string, statusCode int, failed bool) error {
+	msg = strings.TrimSuffix(msg, "\n")
+
+	if msg == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		socket := j.Socket
+
+		if socket == "" {
+			socket = "/run/systemd/journal/socket"
+		}
+
+		conn, err := net.Dial("unixgram", socket)
+
+		if err != nil {
+			return err
+		}
+
+		j.conn = conn
+	}
+
+	var b bytes.Buffer
+	journalField(&b, "MESSAGE", msg)
+	journalField(&b, "PRIORITY", strconv.Itoa(severity(statusCode, failed)))
+	journalField(&b, "SYSLOG_IDENTIFIER", appName(j.Identifier))
+
+	if statusCode != 0 {
+		journalField(&b, "HTTP_STATUS", strconv.Itoa(statusCode))
+	}
+
+	if _, err := j.conn.Write(b.Bytes()); err != nil {
+		j.conn.Close()
+		j.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// journalField adds a field to an entry of the journal native protocol.
+// Values with line breaks are written with their length, as the protocol requires.
+func journalField(b *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(key + "=" + value + "\n")
+		return
+	}
+
+	b.WriteString(key + "\n")
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+// Close the connection to the journal.
+func (j *JournaldSink) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
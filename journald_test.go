@@ -0,0 +1,75 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournaldSink(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "httpretty")
+
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "journal.sock")
+	conn, err := net.ListenPacket("unixgram", socket)
+
+	if err != nil {
+		t.Skipf("cannot listen on unix datagram socket: %v", err)
+	}
+
+	defer conn.Close()
+
+	sink := &JournaldSink{
+		Identifier: "api",
+		Socket:     socket,
+	}
+
+	defer sink.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+	}
+
+	logger.SetOutput(sink)
+	logger.SetFlusher(OnEnd)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "Internal Server Error\nretry later", http.StatusInternalServerError)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	var want bytes.Buffer
+	msg := "Internal Server Error\nretry later\n"
+	want.WriteString("MESSAGE\n")
+	binary.Write(&want, binary.LittleEndian, uint64(len(msg)))
+	want.WriteString(msg + "\n")
+	want.WriteString("PRIORITY=3\nSYSLOG_IDENTIFIER=api\nHTTP_STATUS=500\n")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+
+	if err != nil {
+		t.Fatalf("cannot read entry: %v", err)
+	}
+
+	if got := buf[:n]; !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("got entry %q; want %q", got, want.Bytes())
+	}
+}
@@ -0,0 +1,206 @@
+package httpretty
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// logfmtLine builds a line of logfmt key=value pairs.
+// See https://brandur.org/logfmt
+type logfmtLine struct {
+	b strings.Builder
+}
+
+func (l *logfmtLine) add(key, value string) {
+	if l.b.Len() != 0 {
+		l.b.WriteByte(' ')
+	}
+
+	l.b.WriteString(key)
+	l.b.WriteByte('=')
+
+	if needsLogfmtQuoting(value) {
+		value = strconv.Quote(value)
+	}
+
+	l.b.WriteString(value)
+}
+
+func (l *logfmtLine) String() string {
+	return l.b.String() + "\n"
+}
+
+func needsLogfmtQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// roundTripLogfmt sends the request, printing a single logfmt line for the exchange.
+// The bodies aren't read, so their sizes are only known when the Content-Length is set,
+// unless Logger.CountBodyBytes is set. In this case, the line is printed once the response body is read or closed.
+func (p *printer) roundTripLogfmt(tripper http.RoundTripper, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	p.countRequestBody(req)
+	resp, err := tripper.RoundTrip(req)
+
+	e := p.newAccessLogEntry(req, start)
+	e.Duration = time.Since(start)
+
+	if resp != nil {
+		p.statusCode = resp.StatusCode
+		e.Status = resp.StatusCode
+	}
+
+	if err != nil {
+		p.setErr(err)
+		e.Err = err.Error()
+	}
+
+	if req.ContentLength > 0 || req.Body == nil || req.Body == http.NoBody {
+		e.RequestBytes = req.ContentLength
+	}
+
+	if err == nil && p.logger.CountBodyBytes && resp.Body != nil && resp.Body != http.NoBody {
+		l, statusCode, counted := p.logger, p.statusCode, p.counted
+		p.counted = nil
+
+		resp.Body = &doneBody{
+			ReadCloser: resp.Body,
+			done: func(n int64) {
+				if counted != nil {
+					e.RequestBytes = counted.size()
+				}
+
+				e.ResponseBytes = n
+
+				rp := newPrinter(l)
+				rp.statusCode = statusCode
+				rp.printAccessLog(e)
+				rp.flush()
+			},
+		}
+
+		return resp, err
+	}
+
+	if p.counted != nil {
+		e.RequestBytes = p.counted.size()
+	}
+
+	if resp != nil && resp.ContentLength >= 0 {
+		e.ResponseBytes = resp.ContentLength
+	}
+
+	p.printAccessLog(e)
+	return resp, err
+}
+
+// serveLogfmt serves the request, printing a single logfmt line for the exchange.
+func (p *printer) serveLogfmt(next http.Handler, w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	req = req.WithContext(WithAnnotations(req.Context()))
+	body := &countingBody{ReadCloser: req.Body}
+	req.Body = body
+
+	rw := &countingResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+
+	defer func() {
+		e := p.newAccessLogEntry(req, start)
+		p.statusCode = rw.statusCode
+		e.Status = rw.statusCode
+		e.Duration = time.Since(start)
+		e.RequestBytes = body.size()
+		e.ResponseBytes = rw.size
+		p.printAccessLog(e)
+	}()
+
+	next.ServeHTTP(rw, req)
+}
+
+// countingBody is a request body counting the bytes read from it.
+type countingBody struct {
+	n int64 // accessed atomically, as the transport might still be reading the body after the response is received
+
+	io.ReadCloser
+}
+
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	atomic.AddInt64(&cb.n, int64(n))
+	return n, err
+}
+
+func (cb *countingBody) size() int64 {
+	return atomic.LoadInt64(&cb.n)
+}
+
+// countingResponseWriter records the status code and size of a response without keeping its body.
+type countingResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	size       int64
+}
+
+func (rw *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.size += int64(n)
+	return n, err
+}
+
+func (rw *countingResponseWriter) WriteHeader(statusCode int) {
+	rw.ResponseWriter.WriteHeader(statusCode)
+	rw.statusCode = statusCode
+}
+
+// Flush sends any buffered data to the client, if the underlying writer supports it.
+func (rw *countingResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the underlying ResponseWriter supports it.
+func (rw *countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, errors.New("httpretty: underlying ResponseWriter doesn't support hijacking")
+	}
+
+	return hj.Hijack()
+}
+
+// ReadFrom lets the underlying writer send files with sendfile, as the body isn't kept.
+func (rw *countingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	rf, ok := rw.ResponseWriter.(io.ReaderFrom)
+
+	if !ok {
+		return io.Copy(writerOnly{rw}, src)
+	}
+
+	n, err := rf.ReadFrom(src)
+	rw.size += n
+	return n, err
+}
@@ -0,0 +1,98 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtLine(t *testing.T) {
+	t.Parallel()
+
+	var line logfmtLine
+	line.add("method", "GET")
+	line.add("path", "/a b")
+	line.add("empty", "")
+	line.add("query", `x="y"`)
+
+	want := `method=GET path="/a b" empty="" query="x=\"y\""` + "\n"
+
+	if got := line.String(); got != want {
+		t.Errorf("logfmt line = %q, wanted %q", got, want)
+	}
+}
+
+func TestOutgoingLogfmt(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		Logfmt:         true,
+		RequestHeader:  true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Post(ts.URL+"/users", "text/plain", strings.NewReader("hello")); err != nil {
+		t.Errorf("cannot connect to the server: %v", err)
+	}
+
+	want := regexp.MustCompile(`^method=POST host=127\.0\.0\.1:\d+ path=/users status=200 dur=\S+ req_bytes=5 resp_bytes=13\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
+
+func TestIncomingLogfmt(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		Logfmt:         true,
+		RequestHeader:  true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ioutil.ReadAll(req.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello, world!"))
+	}), WithRoute("/users"))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/users", strings.NewReader("hello"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := regexp.MustCompile(`^method=POST host=example\.com path=/users route=/users status=201 dur=\S+ req_bytes=5 resp_bytes=13\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
+
+func TestIncomingLogfmtResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		Logfmt: true,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+	testResponseWriterInterfaces(t, logger)
+}
@@ -0,0 +1,54 @@
+package httpretty
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type contextLogged struct{}
+
+// loggedOutput keeps a copy of what is printed for an exchange. See Logger.ContextOutput.
+type loggedOutput struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (lo *loggedOutput) write(s string) {
+	lo.mu.Lock()
+	defer lo.mu.Unlock()
+	lo.buf.WriteString(s)
+}
+
+// colorSequence matches the SGR escape sequences added with Logger.Colors.
+var colorSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// LoggedOutput returns what the logger printed so far for the exchange the context belongs to, without colors.
+// On the server-side, handlers get the request as it was printed, so they can include it in an error report
+// without reading the request body again. On the client-side, it is available to the transports wrapped
+// by Logger.RoundTripper.
+//
+// It only works with Logger.ContextOutput set, and returns an empty string otherwise, or if the context doesn't
+// belong to a logged exchange. Output dropped by a filter, such as a post filter, is still included.
+// This function is concurrency safe.
+func LoggedOutput(ctx context.Context) string {
+	lo, ok := ctx.Value(contextLogged{}).(*loggedOutput)
+
+	if !ok {
+		return ""
+	}
+
+	lo.mu.Lock()
+	defer lo.mu.Unlock()
+	return colorSequence.ReplaceAllString(lo.buf.String(), "")
+}
+
+// withLoggedOutput returns a context giving access to what is printed for the exchange with LoggedOutput.
+func (p *printer) withLoggedOutput(ctx context.Context) context.Context {
+	if p.logged == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, contextLogged{}, p.logged)
+}
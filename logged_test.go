@@ -0,0 +1,137 @@
+package httpretty
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIncomingLoggedOutput(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		Colors:         true,
+		ContextOutput:  true,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	var got string
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = LoggedOutput(req.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/orders", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `* Request to http://example.com/orders
+* Request from 192.0.2.1:1234
+> POST /orders HTTP/1.1
+> Host: example.com
+> Content-Type: application/json
+
+{"id":1}
+`
+
+	if got != want {
+		t.Errorf("got logged output %q, wanted %q", got, want)
+	}
+}
+
+func TestLoggedOutputDisabled(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader: true,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	var called bool
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+
+		if got := LoggedOutput(req.Context()); got != "" {
+			t.Errorf("expected no logged output without ContextOutput, got %q", got)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	if !called {
+		t.Error("handler wasn't called")
+	}
+
+	if got := LoggedOutput(context.Background()); got != "" {
+		t.Errorf("expected no logged output for a context of no exchange, got %q", got)
+	}
+}
+
+type loggedOutputTransport struct {
+	got  *string
+	next http.RoundTripper
+}
+
+func (lt loggedOutputTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	*lt.got = LoggedOutput(req.Context())
+	return lt.next.RoundTrip(req)
+}
+
+func TestOutgoingLoggedOutput(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+		ContextOutput:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	var got string
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(loggedOutputTransport{got: &got, next: newTransport()}),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	req.Header.Set("User-Agent", "Robot/0.1 crawler@example.com")
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	want := "> GET / HTTP/1.1\n> Host: " + ts.Listener.Addr().String() + "\n> User-Agent: Robot/0.1 crawler@example.com\n\n"
+
+	if got != want {
+		t.Errorf("got logged output %q, wanted %q", got, want)
+	}
+
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("logged output %q doesn't start with %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,160 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Stats of a logger, for monitoring it.
+type Stats struct {
+	// BufferedBytes is the output buffered by exchanges in progress using the OnEnd flusher.
+	BufferedBytes int64
+
+	// SummarizedBodies is how many bodies were replaced by a summary to stay within Logger.MaxBufferedBytes.
+	SummarizedBodies uint64
+
+	// EarlyFlushes is how many times an exchange was printed before its end to stay within Logger.MaxBufferedBytes.
+	EarlyFlushes uint64
+}
+
+// Stats returns the current stats of the logger. This method is concurrency safe.
+func (l *Logger) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return Stats{
+		BufferedBytes:    l.buffered,
+		SummarizedBodies: l.summarizedBodies,
+		EarlyFlushes:     l.earlyFlushes,
+	}
+}
+
+// bufferedBody is where a body is in the buffered output of an exchange.
+type bufferedBody struct {
+	start, end int
+}
+
+// startBody marks the start of a body in the buffered output.
+func (p *printer) startBody() {
+	p.logger.mu.Lock()
+	defer p.logger.mu.Unlock()
+	p.bodyStart = p.buf.Len()
+	p.inBody = true
+}
+
+// endBody marks the end of a body in the buffered output, so it can be summarized if memory runs short.
+func (p *printer) endBody() {
+	p.logger.mu.Lock()
+	defer p.logger.mu.Unlock()
+
+	if p.inBody && p.flusher == OnEnd && p.logger.MaxBufferedBytes > 0 && p.buf.Len() > p.bodyStart {
+		p.bodies = append(p.bodies, bufferedBody{start: p.bodyStart, end: p.buf.Len()})
+	}
+
+	p.inBody = false
+}
+
+// account updates the memory used by the exchanges in progress after the buffer changes,
+// freeing memory if it is over Logger.MaxBufferedBytes. The logger mutex must be held.
+func (p *printer) account() {
+	p.updateBuffered()
+
+	if max := p.logger.MaxBufferedBytes; max > 0 && p.logger.buffered > max {
+		p.logger.freeBuffered(p)
+	}
+}
+
+// updateBuffered updates the memory used by the exchange. The logger mutex must be held.
+func (p *printer) updateBuffered() {
+	l := p.logger
+	n := int64(p.buf.Len())
+	l.buffered += n - p.accounted
+	p.accounted = n
+
+	if n == 0 {
+		p.bodies, p.inBody = nil, false
+		delete(l.inflight, p)
+		return
+	}
+
+	if l.inflight == nil {
+		l.inflight = map[*printer]struct{}{}
+	}
+
+	l.inflight[p] = struct{}{}
+}
+
+// freeBuffered summarizes the largest bodies buffered until the memory used is within Logger.MaxBufferedBytes.
+// If it is not enough, what the exchange p buffered is printed early. The logger mutex must be held.
+func (l *Logger) freeBuffered(p *printer) {
+	for l.buffered > l.MaxBufferedBytes {
+		q, i := l.largestBody()
+
+		if q == nil {
+			break
+		}
+
+		q.summarizeBody(i)
+		l.summarizedBodies++
+	}
+
+	if l.buffered <= l.MaxBufferedBytes || p.buf.Len() == 0 {
+		return
+	}
+
+	s := p.buf.String() + fmt.Sprintf("* exchange printed before its end to stay within %s of buffered output\n",
+		formatBytes(l.MaxBufferedBytes))
+	p.buf.Reset()
+	p.updateBuffered()
+	p.emit(s, false)
+	l.earlyFlushes++
+}
+
+// largestBody buffered by the exchanges in progress that is longer than its summary.
+func (l *Logger) largestBody() (p *printer, i int) {
+	var size int
+
+	for q := range l.inflight {
+		for j, b := range q.bodies {
+			if n := b.end - b.start; n > size && n > len(bodySummary(n)) {
+				p, i, size = q, j, n
+			}
+		}
+	}
+
+	return p, i
+}
+
+func bodySummary(n int) string {
+	return fmt.Sprintf("* body of %s summarized to save memory\n", formatBytes(int64(n)))
+}
+
+// summarizeBody replaces the i-th body in the buffered output with a summary. The logger mutex must be held.
+func (p *printer) summarizeBody(i int) {
+	b := p.bodies[i]
+	summary := bodySummary(b.end - b.start)
+	out := p.buf.Bytes()
+
+	var buf bytes.Buffer
+	buf.Grow(len(out) - (b.end - b.start) + len(summary))
+	buf.Write(out[:b.start])
+	buf.WriteString(summary)
+	buf.Write(out[b.end:])
+	p.buf = buf
+
+	shift := len(summary) - (b.end - b.start)
+	p.bodies = append(p.bodies[:i], p.bodies[i+1:]...)
+
+	for j := range p.bodies {
+		if p.bodies[j].start >= b.end {
+			p.bodies[j].start += shift
+			p.bodies[j].end += shift
+		}
+	}
+
+	if p.inBody && p.bodyStart >= b.end {
+		p.bodyStart += shift
+	}
+
+	p.updateBuffered()
+}
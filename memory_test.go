@@ -0,0 +1,103 @@
+package httpretty
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMaxBufferedBytes(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		MaxBufferedBytes: 100,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+
+	first := newPrinter(logger)
+	first.println("* first")
+	first.printBody("text/plain", []byte(strings.Repeat("a", 80)))
+
+	if got := logger.Stats(); got.BufferedBytes != 89 {
+		t.Errorf("stats = %+v, wanted 89 bytes buffered", got)
+	}
+
+	second := newPrinter(logger)
+	second.printBody("text/plain", []byte(strings.Repeat("b", 20)))
+	second.println(strings.Repeat("c", 20))
+
+	// the largest body is summarized first.
+	if got := logger.Stats(); got.BufferedBytes > 100 || got.SummarizedBodies != 1 || got.EarlyFlushes != 0 {
+		t.Errorf("stats = %+v, wanted a body summarized", got)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("output printed before the end of the exchanges: %q", buf.String())
+	}
+
+	first.flush()
+
+	if want := "* first\n* body of 81 B summarized to save memory\n"; buf.String() != want {
+		t.Errorf("logged output = %q, wanted %q", buf.String(), want)
+	}
+
+	buf.Reset()
+
+	// with no body left to summarize, the exchange is printed early.
+	second.println(strings.Repeat("d", 60))
+
+	if got := logger.Stats(); got.BufferedBytes != 0 || got.EarlyFlushes != 1 {
+		t.Errorf("stats = %+v, wanted an early flush", got)
+	}
+
+	want := strings.Repeat("b", 20) + "\n" + strings.Repeat("c", 20) + "\n" + strings.Repeat("d", 60) + "\n" +
+		"* exchange printed before its end to stay within 100 B of buffered output\n"
+
+	if buf.String() != want {
+		t.Errorf("logged output = %q, wanted %q", buf.String(), want)
+	}
+
+	second.flush()
+
+	if got := logger.Stats(); got.BufferedBytes != 0 {
+		t.Errorf("stats = %+v, wanted no bytes buffered", got)
+	}
+}
+
+func TestMaxBufferedBytesConcurrent(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		MaxBufferedBytes: 1000,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			p := newPrinter(logger)
+			defer p.flush()
+
+			for j := 0; j < 5; j++ {
+				p.printBody("text/plain", []byte(strings.Repeat("x", 200)))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := logger.Stats(); got.BufferedBytes != 0 || got.SummarizedBodies == 0 {
+		t.Errorf("stats = %+v, wanted bodies summarized and no bytes buffered", got)
+	}
+}
@@ -0,0 +1,71 @@
+package httpretty
+
+import (
+	"net/http"
+	"sort"
+)
+
+// MiddlewareOption configures a handler wrapped with Logger.Handler.
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	route     string
+	fields    map[string]string
+	verbosity Verbosity
+	trigger   *CaptureTrigger
+
+	resolveFile func(req *http.Request) string
+}
+
+// Verbosity limits what a handler wrapped with Logger.Handler prints.
+// It never prints more than what is enabled on the Logger.
+type Verbosity int
+
+const (
+	// VerbosityDefault prints whatever is enabled on the Logger.
+	VerbosityDefault Verbosity = iota
+
+	// VerbositySummary only prints the request line summary.
+	VerbositySummary
+
+	// VerbosityHeaders prints everything but the request and response bodies.
+	VerbosityHeaders
+)
+
+// WithRoute sets a route name to identify the handler on the logs.
+func WithRoute(name string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.route = name
+	}
+}
+
+// WithFields adds static fields to be printed with every request the handler receives.
+func WithFields(fields map[string]string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		if o.fields == nil {
+			o.fields = map[string]string{}
+		}
+
+		for k, v := range fields {
+			o.fields[k] = v
+		}
+	}
+}
+
+// WithVerbosity limits what is printed for the handler.
+func WithVerbosity(v Verbosity) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.verbosity = v
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
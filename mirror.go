@@ -0,0 +1,258 @@
+package httpretty
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Mirror duplicates requests sent by the client to a secondary target, such as for shadow-testing a new backend
+// with real traffic. See Logger.Mirror.
+//
+// Mirrored requests are sent asynchronously, and their responses are discarded. Once both the original
+// and the mirrored exchanges are done, the logger prints their outcome paired together,
+// saying if their status codes and bodies differ.
+type Mirror struct {
+	// Target the requests are mirrored to, such as https://shadow.example.com.
+	// Its scheme and host replace the ones of the original request, and its path, if any, is prepended to the path.
+	Target string
+
+	// Filter selects the requests to mirror. If value is not set, all requests are mirrored.
+	Filter func(req *http.Request) bool
+
+	// Transport used for sending the mirrored requests. If value is not set, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	wg sync.WaitGroup
+}
+
+// Wait for the pending mirrored requests.
+func (m *Mirror) Wait() {
+	m.wg.Wait()
+}
+
+// mirrorOutcome of an exchange, for comparing the original and the mirrored ones.
+type mirrorOutcome struct {
+	status   string
+	code     int
+	err      error
+	duration time.Duration
+	size     int64
+	digest   []byte
+	partial  bool // body closed before it was fully read
+}
+
+func (o mirrorOutcome) String() string {
+	if o.err != nil {
+		return "failed after " + o.duration.String() + ": " + o.err.Error()
+	}
+
+	return o.status + " in " + o.duration.String() + " (" + formatBytes(o.size) + ")"
+}
+
+// mirrorRoundTripper sends a copy of the requests to the mirror target.
+type mirrorRoundTripper struct {
+	logger *Logger
+	mirror *Mirror
+	next   http.RoundTripper
+}
+
+func (mrt mirrorRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m := mrt.mirror
+
+	if m.Filter != nil && !m.Filter(req) {
+		return mrt.next.RoundTrip(req)
+	}
+
+	mreq, err := mrt.newRequest(req)
+
+	if err != nil {
+		p := newPrinter(mrt.logger)
+		p.printf("* cannot mirror request: %v\n", err)
+		p.flush()
+		return mrt.next.RoundTrip(req)
+	}
+
+	mirrored := make(chan mirrorOutcome, 1)
+	original := make(chan mirrorOutcome, 1)
+
+	m.wg.Add(1)
+
+	go mrt.send(mreq, mirrored)
+
+	go func() {
+		defer m.wg.Done()
+		mrt.print(req.Method, mreq.URL.String(), <-original, <-mirrored)
+	}()
+
+	start := time.Now()
+	resp, err := mrt.next.RoundTrip(req)
+
+	if err != nil {
+		original <- mirrorOutcome{err: err, duration: time.Since(start)}
+		return resp, err
+	}
+
+	resp.Body = &mirrorBody{
+		ReadCloser: resp.Body,
+		outcome:    mirrorOutcome{status: resp.Status, code: resp.StatusCode},
+		start:      start,
+		hash:       sha256.New(),
+		done:       original,
+	}
+
+	return resp, err
+}
+
+// newRequest copies the request to the mirror target, buffering the body of the original request to send it twice.
+func (mrt mirrorRoundTripper) newRequest(req *http.Request) (*http.Request, error) {
+	target, err := url.Parse(mrt.mirror.Target)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	u := *req.URL
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	u.User = target.User
+	u.Path = singleJoiningSlash(target.Path, u.Path)
+	u.RawPath = ""
+
+	// the mirrored request isn't canceled with the original one, so it can finish on its own.
+	mreq, err := http.NewRequest(req.Method, u.String(), bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	mreq = mreq.WithContext(context.WithValue(context.Background(), contextHide{}, struct{}{}))
+	mreq.Header = req.Header.Clone()
+	mreq.ContentLength = int64(len(body))
+	return mreq, nil
+}
+
+func singleJoiningSlash(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case a[len(a)-1] == '/' && len(b) != 0 && b[0] == '/':
+		return a + b[1:]
+	case a[len(a)-1] != '/' && (len(b) == 0 || b[0] != '/'):
+		return a + "/" + b
+	}
+
+	return a + b
+}
+
+// send the mirrored request, reading its response body for comparison.
+func (mrt mirrorRoundTripper) send(req *http.Request, done chan<- mirrorOutcome) {
+	transport := mrt.mirror.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+
+	if err != nil {
+		done <- mirrorOutcome{err: err, duration: time.Since(start)}
+		return
+	}
+
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, resp.Body)
+
+	done <- mirrorOutcome{
+		status:   resp.Status,
+		code:     resp.StatusCode,
+		err:      err,
+		duration: time.Since(start),
+		size:     n,
+		digest:   h.Sum(nil),
+	}
+}
+
+func (mrt mirrorRoundTripper) print(method, target string, original, mirrored mirrorOutcome) {
+	p := newPrinter(mrt.logger)
+	defer p.flush()
+
+	p.printf("* mirrored %s request to %s\n", method, p.redactURL(target))
+	p.printf("*  original: %v\n", original)
+	p.printf("*  mirror: %v\n", mirrored)
+
+	switch {
+	case original.err != nil || mirrored.err != nil:
+	case original.code != mirrored.code:
+		p.println("*  responses differ: status code")
+	case original.partial:
+		p.println("*  bodies not compared: the original body was closed before it was fully read")
+	case !bytes.Equal(original.digest, mirrored.digest):
+		p.println("*  responses differ: body")
+	default:
+		p.println("*  responses match")
+	}
+}
+
+// mirrorBody hashes the response body to the original request as it is read,
+// to compare it with the one of the mirrored request once it is read or closed.
+type mirrorBody struct {
+	io.ReadCloser
+
+	outcome mirrorOutcome
+	start   time.Time
+	hash    hash.Hash
+	done    chan<- mirrorOutcome
+	once    sync.Once
+}
+
+func (mb *mirrorBody) Read(p []byte) (int, error) {
+	n, err := mb.ReadCloser.Read(p)
+	mb.hash.Write(p[:n])
+	mb.outcome.size += int64(n)
+
+	switch {
+	case err == io.EOF:
+		mb.finish(nil, false)
+	case err != nil:
+		mb.finish(err, false)
+	}
+
+	return n, err
+}
+
+func (mb *mirrorBody) Close() error {
+	mb.finish(nil, true)
+	return mb.ReadCloser.Close()
+}
+
+func (mb *mirrorBody) finish(err error, partial bool) {
+	mb.once.Do(func() {
+		mb.outcome.err = err
+		mb.outcome.partial = partial
+		mb.outcome.duration = time.Since(mb.start)
+		mb.outcome.digest = mb.hash.Sum(nil)
+		mb.done <- mb.outcome
+	})
+}
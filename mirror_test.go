@@ -0,0 +1,114 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingMirror(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	var shadowPaths []string
+
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowPaths = append(shadowPaths, r.URL.Path)
+
+		if r.URL.Path == "/v2/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write(b)
+	}))
+	defer shadow.Close()
+
+	mirror := &Mirror{
+		Target: shadow.URL + "/v2",
+		Filter: func(req *http.Request) bool {
+			return req.Method == http.MethodPost
+		},
+	}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		Mirror:          mirror,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for _, path := range []string{"/same", "/broken"} {
+		resp, err := client.Post(ts.URL+path, "text/plain", strings.NewReader("hello"))
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		testBody(t, resp.Body, []byte("hello"))
+		resp.Body.Close()
+		mirror.Wait()
+	}
+
+	resp, err := client.Get(ts.URL + "/skipped")
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+	mirror.Wait()
+
+	if want := []string{"/v2/same", "/v2/broken"}; strings.Join(shadowPaths, " ") != strings.Join(want, " ") {
+		t.Errorf("mirrored paths = %v, wanted %v", shadowPaths, want)
+	}
+
+	want := regexp.MustCompile(`^\* mirrored POST request to ` + regexp.QuoteMeta(shadow.URL) + `/v2/same
+\*  original: 200 OK in \S+ \(5 B\)
+\*  mirror: 200 OK in \S+ \(5 B\)
+\*  responses match
+\* mirrored POST request to ` + regexp.QuoteMeta(shadow.URL) + `/v2/broken
+\*  original: 200 OK in \S+ \(5 B\)
+\*  mirror: 500 Internal Server Error in \S+ \(0 B\)
+\*  responses differ: status code
+$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged output = %q, wanted it to match %q", got, want)
+	}
+}
+
+func TestSingleJoiningSlash(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a, b, want string
+	}{
+		{"", "/users", "/users"},
+		{"/", "/users", "/users"},
+		{"/v2", "/users", "/v2/users"},
+		{"/v2/", "/users", "/v2/users"},
+		{"/v2", "users", "/v2/users"},
+	}
+
+	for _, tc := range testCases {
+		if got := singleJoiningSlash(tc.a, tc.b); got != tc.want {
+			t.Errorf("singleJoiningSlash(%q, %q) = %q, wanted %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+	"github.com/henvic/httpretty/internal/header"
+)
+
+// PartRedaction is a policy for redacting the parts of a multipart body.
+type PartRedaction struct {
+	// SkipParts by form name. Their headers are printed, but their content is not.
+	SkipParts []string
+
+	// RedactFilename hides the file name of uploaded files in the Content-Disposition part header.
+	RedactFilename bool
+
+	// Sanitizers for part headers, in addition to the ones used for regular headers.
+	// The key is the header name.
+	Sanitizers map[string]func(string) string
+}
+
+const redactedFilename = "████████████████████"
+
+// skipPart checks if the content of the part with the given form name should be skipped.
+func (r *PartRedaction) skipPart(name string) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, s := range r.SkipParts {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sanitizePartHeader returns a sanitized copy of the part header.
+func (p *printer) sanitizePartHeader(h textproto.MIMEHeader) textproto.MIMEHeader {
+	r := p.logger.PartRedaction
+	sanitizers := map[string]header.SanitizeHeaderFunc{}
+
+	if !p.logger.SkipSanitize {
+		for k, s := range p.logger.sanitizers() {
+			sanitizers[k] = s
+		}
+	}
+
+	if r != nil {
+		for k, s := range r.Sanitizers {
+			sanitizers[textproto.CanonicalMIMEHeaderKey(k)] = s
+		}
+
+		if r.RedactFilename {
+			sanitizers["Content-Disposition"] = chainSanitizer(sanitizers["Content-Disposition"], redactFilename)
+		}
+	}
+
+	sanitized := textproto.MIMEHeader{}
+
+	for k, values := range h {
+		s, ok := sanitizers[textproto.CanonicalMIMEHeaderKey(k)]
+
+		if !ok {
+			sanitized[k] = values
+			continue
+		}
+
+		for _, v := range values {
+			sanitized[k] = append(sanitized[k], s(v))
+		}
+	}
+
+	return sanitized
+}
+
+// printMultipart prints each part of a multipart body, such as a multipart/form-data upload:
+// a summary with its field name, file name, declared content type, and size, followed by its headers and content.
+// Text content is printed with the formatters, and binary content is summarized.
+func (p *printer) printMultipart(boundary string, body []byte) {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	for i := 1; ; i++ {
+		part, err := mr.NextPart()
+
+		if err == io.EOF {
+			return
+		}
+
+		if err != nil {
+			p.printf("* cannot read multipart body: %v\n", p.format(color.FgRed, err))
+			return
+		}
+
+		content, err := ioutil.ReadAll(part)
+
+		if err != nil {
+			p.printf("* cannot read part %d: %v\n", i, p.format(color.FgRed, err))
+			return
+		}
+
+		h := p.sanitizePartHeader(part.Header)
+		p.printf("* part %d: %s\n", i, partSummary(h, len(content)))
+		p.printPartHeader(h)
+
+		name := part.FormName()
+		mediatype, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+
+		switch {
+		case p.logger.PartRedaction.skipPart(name):
+			p.println("* part content skipped")
+		case len(content) == 0:
+		case isBinaryMediatype(mediatype) || isBinary(content):
+			p.println("* part contains binary data")
+		default:
+			p.printBody(mediatype, content)
+		}
+	}
+}
+
+// partSummary describes a part, such as `field "file", file "report.pdf", application/pdf (4.2 KiB)`.
+func partSummary(h textproto.MIMEHeader, size int) string {
+	var parts []string
+
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil {
+		if name, ok := params["name"]; ok {
+			parts = append(parts, fmt.Sprintf("field %q", name))
+		}
+
+		if filename, ok := params["filename"]; ok {
+			parts = append(parts, fmt.Sprintf("file %q", filename))
+		}
+	}
+
+	if ct := h.Get("Content-Type"); ct != "" {
+		parts = append(parts, ct)
+	}
+
+	return strings.TrimPrefix(strings.Join(parts, ", ")+" ", " ") + "(" + formatBytes(int64(size)) + ")"
+}
+
+// printPartHeader prints the headers of a part, sorted.
+func (p *printer) printPartHeader(h textproto.MIMEHeader) {
+	keys := make([]string, 0, len(h))
+
+	for k := range h {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range h[k] {
+			p.printf("*  %s%s %s\n",
+				p.format(color.FgBlue, color.Bold, k),
+				p.format(color.FgRed, ":"),
+				p.format(color.FgYellow, v),
+			)
+		}
+	}
+}
+
+func chainSanitizer(first, second header.SanitizeHeaderFunc) header.SanitizeHeaderFunc {
+	if first == nil {
+		return second
+	}
+
+	return func(v string) string {
+		return second(first(v))
+	}
+}
+
+// redactFilename from a Content-Disposition header value.
+func redactFilename(v string) string {
+	disposition, params, err := mime.ParseMediaType(v)
+
+	if err != nil {
+		return v
+	}
+
+	_, hasFilename := params["filename"]
+	_, hasFilenameExt := params["filename*"]
+
+	if !hasFilename && !hasFilenameExt {
+		return v
+	}
+
+	// mime.ParseMediaType decodes the RFC 2231 filename* parameter into filename.
+	delete(params, "filename*")
+	params["filename"] = redactedFilename
+
+	keys := make([]string, 0, len(params))
+
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(disposition)
+
+	for _, k := range keys {
+		b.WriteString("; ")
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(params[k]))
+		b.WriteString(`"`)
+	}
+
+	return b.String()
+}
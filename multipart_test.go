@@ -0,0 +1,226 @@
+package httpretty
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitizePartHeader(t *testing.T) {
+	t.Parallel()
+
+	h := textproto.MIMEHeader{
+		"Content-Disposition": []string{`form-data; name="document"; filename="secret plans.pdf"`},
+		"Content-Type":        []string{"application/pdf"},
+		"Authorization":       []string{"Bearer abc"},
+		"X-Signature":         []string{"deadbeef"},
+	}
+
+	testCases := []struct {
+		name   string
+		logger *Logger
+		want   textproto.MIMEHeader
+	}{
+		{
+			name:   "default",
+			logger: &Logger{},
+			want: textproto.MIMEHeader{
+				"Content-Disposition": []string{`form-data; name="document"; filename="secret plans.pdf"`},
+				"Content-Type":        []string{"application/pdf"},
+				"Authorization":       []string{"Bearer ████████████████████"},
+				"X-Signature":         []string{"deadbeef"},
+			},
+		},
+		{
+			name: "redacted",
+			logger: &Logger{
+				PartRedaction: &PartRedaction{
+					RedactFilename: true,
+					Sanitizers: map[string]func(string) string{
+						"x-signature": strings.ToUpper,
+					},
+				},
+			},
+			want: textproto.MIMEHeader{
+				"Content-Disposition": []string{`form-data; filename="████████████████████"; name="document"`},
+				"Content-Type":        []string{"application/pdf"},
+				"Authorization":       []string{"Bearer ████████████████████"},
+				"X-Signature":         []string{"DEADBEEF"},
+			},
+		},
+		{
+			name: "skip sanitize",
+			logger: &Logger{
+				SkipSanitize:  true,
+				PartRedaction: &PartRedaction{RedactFilename: true},
+			},
+			want: textproto.MIMEHeader{
+				"Content-Disposition": []string{`form-data; filename="████████████████████"; name="document"`},
+				"Content-Type":        []string{"application/pdf"},
+				"Authorization":       []string{"Bearer abc"},
+				"X-Signature":         []string{"deadbeef"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		p := printer{logger: tc.logger}
+
+		if got := p.sanitizePartHeader(h); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: sanitizePartHeader() = %v, wanted %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPartRedactionSkipPart(t *testing.T) {
+	t.Parallel()
+
+	var none *PartRedaction
+
+	if none.skipPart("document") {
+		t.Errorf("nil policy should not skip parts")
+	}
+
+	r := &PartRedaction{SkipParts: []string{"document"}}
+
+	if !r.skipPart("document") || r.skipPart("title") {
+		t.Errorf("policy should only skip the document part")
+	}
+}
+
+func TestRedactFilename(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		in   string
+		want string
+	}{
+		{in: `form-data; name="title"`, want: `form-data; name="title"`},
+		{in: `attachment; filename*=UTF-8''na%C3%AFve.txt`, want: `attachment; filename="████████████████████"`},
+		{in: `invalid; ;`, want: `invalid; ;`},
+	}
+
+	for _, tc := range testCases {
+		if got := redactFilename(tc.in); got != tc.want {
+			t.Errorf("redactFilename(%q) = %q, wanted %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestPrintMultipart(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	if err := w.SetBoundary("boundary"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = w.WriteField("title", "Report")
+
+	metadata, _ := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": []string{`form-data; name="metadata"`},
+		"Content-Type":        []string{"application/json"},
+	})
+
+	_, _ = metadata.Write([]byte(`{"pages":3}`))
+
+	document, _ := w.CreateFormFile("document", "plans.pdf")
+	_, _ = document.Write([]byte("%PDF-1.4\x00\x01\x02"))
+
+	token, _ := w.CreateFormField("token")
+	_, _ = token.Write([]byte("secret"))
+	_ = w.Close()
+
+	logger := &Logger{
+		Formatters: []Formatter{&JSONFormatter{}},
+		PartRedaction: &PartRedaction{
+			SkipParts:      []string{"token"},
+			RedactFilename: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	p := newPrinter(logger)
+	p.printMultipart("boundary", body.Bytes())
+
+	want := `* part 1: field "title" (6 B)
+*  Content-Disposition: form-data; name="title"
+Report
+* part 2: field "metadata", application/json (11 B)
+*  Content-Disposition: form-data; name="metadata"
+*  Content-Type: application/json
+{
+    "pages": 3
+}
+* part 3: field "document", file "████████████████████", application/octet-stream (11 B)
+*  Content-Disposition: form-data; filename="████████████████████"; name="document"
+*  Content-Type: application/octet-stream
+* part contains binary data
+* part 4: field "token" (6 B)
+*  Content-Disposition: form-data; name="token"
+* part content skipped
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("printMultipart() = %q, wanted %q", got, want)
+	}
+
+	buf.Reset()
+	p.printMultipart("boundary", []byte("--boundary\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nRep"))
+
+	if got := buf.String(); !strings.HasPrefix(got, "* cannot read part 1: ") {
+		t.Errorf("printMultipart() = %q, wanted error reading part", got)
+	}
+}
+
+func TestIncomingPartRedaction(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("token", "secret-token")
+
+	document, _ := w.CreateFormFile("document", "plans.txt")
+	_, _ = document.Write([]byte("plans"))
+	_ = w.Close()
+
+	logger := &Logger{
+		RequestBody: true,
+		PartRedaction: &PartRedaction{
+			SkipParts:      []string{"token"},
+			RedactFilename: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := buf.String()
+
+	for _, s := range []string{"secret-token", "plans.txt"} {
+		if strings.Contains(got, s) {
+			t.Errorf("logged request contains %q: %s", s, got)
+		}
+	}
+
+	for _, s := range []string{"* part content skipped\n", `filename="████████████████████"`, "plans\n"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("logged request doesn't contain %q: %s", s, got)
+		}
+	}
+}
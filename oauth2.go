@@ -0,0 +1,259 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OAuth2Formatter is a preset for OAuth 2.0 and OpenID Connect token endpoint exchanges.
+// It recognizes token requests (form bodies with a grant_type) and token responses (JSON bodies with
+// an access_token, id_token, or error), redacts the credentials and tokens in them, and prints the
+// token metadata that isn't sensitive, such as the token type, expiry, and scopes.
+// Claims of tokens that are JWTs are decoded, but their signatures aren't verified.
+//
+// Other bodies are formatted as usual: JSON bodies with the JSON formatter, and form bodies as they are.
+// Add it before other formatters matching JSON, and don't redact the tokens with Logger.RedactSecrets,
+// or there isn't anything left to decode.
+type OAuth2Formatter struct {
+	// JSON formats the JSON bodies. If value is not set, a JSONFormatter is used.
+	JSON Formatter
+}
+
+// oauth2Sensitive are the parameters of token exchanges whose values are redacted.
+var oauth2Sensitive = map[string]bool{
+	"access_token":     true,
+	"actor_token":      true,
+	"assertion":        true,
+	"client_assertion": true,
+	"client_secret":    true,
+	"code":             true,
+	"code_verifier":    true,
+	"device_code":      true,
+	"id_token":         true,
+	"password":         true,
+	"refresh_token":    true,
+	"subject_token":    true,
+}
+
+var oauth2JSONString = regexp.MustCompile(`"([a-z_]+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// Match JSON and form media types.
+func (o *OAuth2Formatter) Match(mediatype string) bool {
+	return mediatype == "application/json" || mediatype == "application/x-www-form-urlencoded"
+}
+
+// Format token exchanges, redacting their credentials and tokens.
+func (o *OAuth2Formatter) Format(w io.Writer, src []byte) error {
+	if isOAuth2Request(src) {
+		return o.formatRequest(w, src)
+	}
+
+	jf := o.JSON
+
+	if jf == nil {
+		jf = &JSONFormatter{}
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(src, &fields); err != nil || !isOAuth2Response(fields) {
+		if json.Valid(src) || !isForm(src) {
+			return jf.Format(w, src)
+		}
+
+		_, err := w.Write(src)
+		return err
+	}
+
+	redacted := oauth2JSONString.ReplaceAllFunc(src, func(m []byte) []byte {
+		name := oauth2JSONString.FindSubmatch(m)[1]
+
+		if !oauth2Sensitive[string(name)] {
+			return m
+		}
+
+		return []byte(fmt.Sprintf("%q: %q", name, redactedSecret))
+	})
+
+	if err := jf.Format(w, redacted); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, strings.Join(oauth2ResponseMetadata(fields), ""))
+	return err
+}
+
+func (o *OAuth2Formatter) formatRequest(w io.Writer, src []byte) error {
+	values, _ := url.ParseQuery(string(src))
+	pairs := strings.Split(string(src), "&")
+
+	for i, pair := range pairs {
+		name := pair
+
+		if n := strings.IndexByte(pair, '='); n >= 0 {
+			name = pair[:n]
+		}
+
+		if key, err := url.QueryUnescape(name); err == nil && oauth2Sensitive[key] {
+			pairs[i] = name + "=" + redactedSecret
+		}
+	}
+
+	io.WriteString(w, strings.Join(pairs, "&"))
+	fmt.Fprintf(w, "\n* OAuth2 token request: %s grant", values.Get("grant_type"))
+
+	if clientID := values.Get("client_id"); clientID != "" {
+		fmt.Fprintf(w, " for client %s", clientID)
+	}
+
+	if scope := values.Get("scope"); scope != "" {
+		fmt.Fprintf(w, ", scope: %s", scope)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func isForm(src []byte) bool {
+	_, err := url.ParseQuery(string(src))
+	return err == nil && !bytes.ContainsAny(src, " \n{")
+}
+
+func isOAuth2Request(src []byte) bool {
+	if !isForm(src) {
+		return false
+	}
+
+	values, _ := url.ParseQuery(string(src))
+	return values.Get("grant_type") != ""
+}
+
+func isOAuth2Response(fields map[string]interface{}) bool {
+	for _, name := range []string{"access_token", "id_token"} {
+		if _, ok := fields[name].(string); ok {
+			return true
+		}
+	}
+
+	_, ok := fields["error"].(string)
+	return ok && len(fields) <= 3
+}
+
+// oauth2ResponseMetadata returns lines describing a token response.
+func oauth2ResponseMetadata(fields map[string]interface{}) []string {
+	if e, ok := fields["error"].(string); ok {
+		line := "\n* OAuth2 token error: " + e
+
+		if desc, ok := fields["error_description"].(string); ok {
+			line += " (" + desc + ")"
+		}
+
+		return []string{line + "\n"}
+	}
+
+	line := "\n* OAuth2 token response"
+
+	if tokenType, ok := fields["token_type"].(string); ok {
+		line += ": " + tokenType + " token"
+	}
+
+	if expiresIn, ok := fields["expires_in"].(float64); ok {
+		line += fmt.Sprintf(", expires in %v", time.Duration(expiresIn)*time.Second)
+	}
+
+	if scope, ok := fields["scope"].(string); ok {
+		line += ", scope: " + scope
+	}
+
+	if _, ok := fields["refresh_token"]; ok {
+		line += ", with refresh token"
+	}
+
+	lines := []string{line + "\n"}
+
+	for _, name := range []string{"access_token", "id_token"} {
+		token, ok := fields[name].(string)
+
+		if !ok {
+			continue
+		}
+
+		if claims := jwtClaims(token); claims != "" {
+			lines = append(lines, fmt.Sprintf("* %s claims: %s\n", name, claims))
+		}
+	}
+
+	return lines
+}
+
+// jwtClaims decodes the claims of a JWT that aren't sensitive, or returns an empty string if the token isn't a JWT.
+func jwtClaims(token string) string {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]interface{}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	var out []string
+
+	if iss, ok := claims["iss"].(string); ok {
+		out = append(out, "iss="+iss)
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		out = append(out, "aud="+aud)
+	case []interface{}:
+		var audiences []string
+
+		for _, a := range aud {
+			audiences = append(audiences, fmt.Sprint(a))
+		}
+
+		out = append(out, "aud="+strings.Join(audiences, ","))
+	}
+
+	for _, name := range []string{"iat", "nbf", "exp"} {
+		if v, ok := claims[name].(float64); ok {
+			out = append(out, name+"="+time.Unix(int64(v), 0).UTC().Format(time.RFC3339))
+		}
+	}
+
+	for _, name := range []string{"scope", "scp"} {
+		switch scope := claims[name].(type) {
+		case string:
+			out = append(out, "scope="+scope)
+		case []interface{}:
+			var scopes []string
+
+			for _, s := range scope {
+				scopes = append(scopes, fmt.Sprint(s))
+			}
+
+			sort.Strings(scopes)
+			out = append(out, "scope="+strings.Join(scopes, " "))
+		}
+	}
+
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,266 @@
+package httpretty
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// OCSP requests and responses (application/ocsp-request and application/ocsp-response).
+// See https://tools.ietf.org/html/rfc6960#section-4
+
+var (
+	oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+)
+
+var ocspHashes = map[string]string{
+	"1.3.14.3.2.26":          "SHA-1",
+	"2.16.840.1.101.3.4.2.1": "SHA-256",
+	"2.16.840.1.101.3.4.2.2": "SHA-384",
+	"2.16.840.1.101.3.4.2.3": "SHA-512",
+}
+
+var ocspStatuses = map[asn1.Enumerated]string{
+	0: "successful",
+	1: "malformedRequest",
+	2: "internalError",
+	3: "tryLater",
+	5: "sigRequired",
+	6: "unauthorized",
+}
+
+var ocspRevocationReasons = map[asn1.Enumerated]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+func (id ocspCertID) String() string {
+	hash, ok := ocspHashes[id.HashAlgorithm.Algorithm.String()]
+
+	if !ok {
+		hash = id.HashAlgorithm.Algorithm.String()
+	}
+
+	return fmt.Sprintf("serial %s (issuer name hash %x, issuer key hash %x, %s)",
+		id.SerialNumber.Text(16), id.NameHash, id.IssuerKeyHash, hash)
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+		RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+		RequestList   []struct {
+			Cert       ocspCertID
+			Extensions []pkix.Extension `asn1:"explicit,tag:0,optional"`
+		}
+		Extensions []pkix.Extension `asn1:"explicit,tag:2,optional"`
+	}
+	Signature asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData struct {
+		Version     int `asn1:"explicit,tag:0,default:0,optional"`
+		ResponderID asn1.RawValue
+		ProducedAt  time.Time `asn1:"generalized"`
+		Responses   []ocspSingleResponse
+		Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+	}
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID  ocspCertID
+	Good    asn1.Flag `asn1:"tag:0,optional"`
+	Revoked struct {
+		RevocationTime time.Time       `asn1:"generalized"`
+		Reason         asn1.Enumerated `asn1:"explicit,tag:0,default:0,optional"`
+	} `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+func unmarshalDER(b []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(b, v)
+
+	if err == nil && len(rest) != 0 {
+		err = errors.New("trailing data")
+	}
+
+	return err
+}
+
+func ocspNonce(extensions []pkix.Extension) []byte {
+	for _, ext := range extensions {
+		if ext.Id.Equal(oidOCSPNonce) {
+			return ext.Value
+		}
+	}
+
+	return nil
+}
+
+func decodeOCSPRequest(body []byte) (string, error) {
+	var req ocspRequest
+
+	if err := unmarshalDER(body, &req); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "OCSP request for %d certificate(s)", len(req.TBSRequest.RequestList))
+
+	for _, r := range req.TBSRequest.RequestList {
+		fmt.Fprintf(&b, "\n  certificate: %v", r.Cert)
+	}
+
+	if nonce := ocspNonce(req.TBSRequest.Extensions); nonce != nil {
+		fmt.Fprintf(&b, "\n  nonce: %x", nonce)
+	}
+
+	if len(req.Signature.FullBytes) != 0 {
+		b.WriteString("\n  signed: yes")
+	}
+
+	return b.String(), nil
+}
+
+func decodeOCSPResponse(body []byte) (string, error) {
+	var resp ocspResponse
+
+	if err := unmarshalDER(body, &resp); err != nil {
+		return "", err
+	}
+
+	status, ok := ocspStatuses[resp.Status]
+
+	if !ok {
+		status = fmt.Sprintf("status %d", resp.Status)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "OCSP response: %s", status)
+
+	if resp.Response.ResponseType == nil {
+		return b.String(), nil
+	}
+
+	if !resp.Response.ResponseType.Equal(oidOCSPBasic) {
+		fmt.Fprintf(&b, "\n  response type: %v", resp.Response.ResponseType)
+		return b.String(), nil
+	}
+
+	var basic ocspBasicResponse
+
+	if err := unmarshalDER(resp.Response.Response, &basic); err != nil {
+		return "", fmt.Errorf("cannot decode basic response: %v", err)
+	}
+
+	data := basic.TBSResponseData
+	responder, err := ocspResponderID(data.ResponderID)
+
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(&b, "\n  responder: %s", responder)
+	fmt.Fprintf(&b, "\n  produced at: %s", data.ProducedAt.UTC().Format(time.RFC3339))
+
+	for _, r := range data.Responses {
+		fmt.Fprintf(&b, "\n  certificate: %v", r.CertID)
+		fmt.Fprintf(&b, "\n    status: %s", r.status())
+		fmt.Fprintf(&b, "\n    this update: %s", r.ThisUpdate.UTC().Format(time.RFC3339))
+
+		if !r.NextUpdate.IsZero() {
+			fmt.Fprintf(&b, "\n    next update: %s", r.NextUpdate.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if nonce := ocspNonce(data.Extensions); nonce != nil {
+		fmt.Fprintf(&b, "\n  nonce: %x", nonce)
+	}
+
+	if len(basic.Certificates) != 0 {
+		fmt.Fprintf(&b, "\n  certificates: %d", len(basic.Certificates))
+	}
+
+	return b.String(), nil
+}
+
+func (r ocspSingleResponse) status() string {
+	switch {
+	case bool(r.Good):
+		return "good"
+	case bool(r.Unknown):
+		return "unknown"
+	case !r.Revoked.RevocationTime.IsZero():
+		reason, ok := ocspRevocationReasons[r.Revoked.Reason]
+
+		if !ok {
+			reason = fmt.Sprintf("reason %d", r.Revoked.Reason)
+		}
+
+		return fmt.Sprintf("revoked at %s (%s)", r.Revoked.RevocationTime.UTC().Format(time.RFC3339), reason)
+	}
+
+	return "missing"
+}
+
+// ocspResponderID is either the name of the responder or the hash of its public key.
+func ocspResponderID(id asn1.RawValue) (string, error) {
+	switch id.Tag {
+	case 1:
+		var rdn pkix.RDNSequence
+
+		if err := unmarshalDER(id.Bytes, &rdn); err != nil {
+			return "", fmt.Errorf("cannot decode responder name: %v", err)
+		}
+
+		var name pkix.Name
+		name.FillFromRDNSequence(&rdn)
+		return name.String(), nil
+	case 2:
+		var key []byte
+
+		if err := unmarshalDER(id.Bytes, &key); err != nil {
+			return "", fmt.Errorf("cannot decode responder key hash: %v", err)
+		}
+
+		return "key hash " + hex.EncodeToString(key), nil
+	}
+
+	return "", fmt.Errorf("unknown responder ID (tag %d)", id.Tag)
+}
@@ -0,0 +1,137 @@
+package httpretty
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testOCSPCertID() ocspCertID {
+	return ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26},
+			Parameters: asn1.NullRawValue,
+		},
+		NameHash:      []byte{0x01, 0x02},
+		IssuerKeyHash: []byte{0x03, 0x04},
+		SerialNumber:  big.NewInt(0xcafe),
+	}
+}
+
+func TestDecodeOCSPRequest(t *testing.T) {
+	t.Parallel()
+
+	var req ocspRequest
+	req.TBSRequest.RequestList = append(req.TBSRequest.RequestList, struct {
+		Cert       ocspCertID
+		Extensions []pkix.Extension `asn1:"explicit,tag:0,optional"`
+	}{Cert: testOCSPCertID()})
+	req.TBSRequest.Extensions = []pkix.Extension{{Id: oidOCSPNonce, Value: []byte{0x04, 0x02, 0xAB, 0xCD}}}
+
+	der, err := asn1.Marshal(req)
+
+	if err != nil {
+		t.Fatalf("cannot marshal request: %v", err)
+	}
+
+	got, err := decodeOCSPRequest(der)
+
+	if err != nil {
+		t.Fatalf("cannot decode request: %v", err)
+	}
+
+	want := `OCSP request for 1 certificate(s)
+  certificate: serial cafe (issuer name hash 0102, issuer key hash 0304, SHA-1)
+  nonce: 0402abcd`
+
+	if got != want {
+		t.Errorf("decoded request = %q, wanted %q", got, want)
+	}
+}
+
+func TestDecodeOCSPResponse(t *testing.T) {
+	t.Parallel()
+
+	keyHash, err := asn1.Marshal([]byte{0xAA, 0xBB})
+
+	if err != nil {
+		t.Fatalf("cannot marshal key hash: %v", err)
+	}
+
+	var basic ocspBasicResponse
+	basic.TBSResponseData.ResponderID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: keyHash}
+	basic.TBSResponseData.ProducedAt = time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	good := ocspSingleResponse{
+		CertID:     testOCSPCertID(),
+		Good:       true,
+		ThisUpdate: time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC),
+		NextUpdate: time.Date(2020, time.May, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	revoked := ocspSingleResponse{
+		CertID:     testOCSPCertID(),
+		ThisUpdate: time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+	revoked.CertID.SerialNumber = big.NewInt(0xbeef)
+	revoked.Revoked.RevocationTime = time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC)
+	revoked.Revoked.Reason = 1
+
+	basic.TBSResponseData.Responses = []ocspSingleResponse{good, revoked}
+	basic.SignatureAlgorithm.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	basic.Signature = asn1.BitString{Bytes: []byte{0x00}, BitLength: 8}
+
+	b, err := asn1.Marshal(basic)
+
+	if err != nil {
+		t.Fatalf("cannot marshal basic response: %v", err)
+	}
+
+	var resp ocspResponse
+	resp.Response.ResponseType = oidOCSPBasic
+	resp.Response.Response = b
+
+	der, err := asn1.Marshal(resp)
+
+	if err != nil {
+		t.Fatalf("cannot marshal response: %v", err)
+	}
+
+	got, err := decodeOCSPResponse(der)
+
+	if err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+
+	want := `OCSP response: successful
+  responder: key hash aabb
+  produced at: 2020-05-01T12:00:00Z
+  certificate: serial cafe (issuer name hash 0102, issuer key hash 0304, SHA-1)
+    status: good
+    this update: 2020-05-01T00:00:00Z
+    next update: 2020-05-08T00:00:00Z
+  certificate: serial beef (issuer name hash 0102, issuer key hash 0304, SHA-1)
+    status: revoked at 2020-04-01T00:00:00Z (keyCompromise)
+    this update: 2020-05-01T00:00:00Z`
+
+	if got != want {
+		t.Errorf("decoded response = %q, wanted %q", got, want)
+	}
+}
+
+func TestDecodeOCSPResponseUnsuccessful(t *testing.T) {
+	t.Parallel()
+
+	// SEQUENCE { ENUMERATED 3 }
+	got, err := decodeOCSPResponse([]byte{0x30, 0x03, 0x0A, 0x01, 0x03})
+
+	if err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+
+	if want := "OCSP response: tryLater"; got != want {
+		t.Errorf("decoded response = %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package httpretty
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// paginationRels are the link relations used for pagination, in the order they are printed.
+var paginationRels = []string{"first", "prev", "next", "last"}
+
+// paginationLinks returns the pagination links of the Link headers by relation (first, prev, next, and last),
+// resolved against the URL of the request. See https://www.rfc-editor.org/rfc/rfc8288
+func paginationLinks(base *url.URL, h http.Header) map[string]*url.URL {
+	var links map[string]*url.URL
+
+	for _, v := range h["Link"] {
+		for _, link := range splitLinks(v) {
+			target, rels, ok := parseLink(link)
+
+			if !ok {
+				continue
+			}
+
+			for _, rel := range rels {
+				if rel == "previous" {
+					rel = "prev"
+				}
+
+				if rel != "first" && rel != "prev" && rel != "next" && rel != "last" {
+					continue
+				}
+
+				u, err := url.Parse(target)
+
+				if err != nil {
+					continue
+				}
+
+				if base != nil {
+					u = base.ResolveReference(u)
+				}
+
+				if links == nil {
+					links = map[string]*url.URL{}
+				}
+
+				if _, ok := links[rel]; !ok {
+					links[rel] = u
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// splitLinks splits the value of a Link header into its links, ignoring the commas inside URLs and quoted strings.
+func splitLinks(v string) []string {
+	var (
+		links  []string
+		start  int
+		inURL  bool
+		quoted bool
+	)
+
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case quoted && c == '\\':
+			i++
+		case quoted:
+			quoted = c != '"'
+		case c == '<':
+			inURL = true
+		case c == '>':
+			inURL = false
+		case inURL:
+		case c == '"':
+			quoted = true
+		case c == ',':
+			links = append(links, v[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(links, v[start:])
+}
+
+// parseLink parses a link such as `<https://example.com/?page=2>; rel="next"`, returning its target and relations.
+func parseLink(link string) (target string, rels []string, ok bool) {
+	link = strings.TrimSpace(link)
+
+	if !strings.HasPrefix(link, "<") {
+		return "", nil, false
+	}
+
+	end := strings.IndexByte(link, '>')
+
+	if end == -1 {
+		return "", nil, false
+	}
+
+	target = link[1:end]
+
+	for _, param := range strings.Split(link[end+1:], ";") {
+		name, value := param, ""
+
+		if i := strings.IndexByte(param, '='); i != -1 {
+			name, value = param[:i], param[i+1:]
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), "rel") {
+			rels = append(rels, strings.Fields(strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`)))...)
+		}
+	}
+
+	return target, rels, true
+}
+
+// printPagination prints a summary of the pagination links of a response,
+// such as "* pagination: prev ?page=1, next ?page=3, last ?page=10".
+// Links to the same endpoint as the request are shortened to their query.
+func (p *printer) printPagination(base *url.URL, h http.Header) {
+	links := paginationLinks(base, h)
+
+	if len(links) == 0 {
+		return
+	}
+
+	var parts []string
+
+	for _, rel := range paginationRels {
+		u, ok := links[rel]
+
+		if !ok {
+			continue
+		}
+
+		if base != nil && u.Scheme == base.Scheme && u.Host == base.Host && u.Path == base.Path && u.RawQuery != "" {
+			parts = append(parts, rel+" "+p.redactURL("?"+u.RawQuery))
+			continue
+		}
+
+		parts = append(parts, rel+" "+p.linkURL(u))
+	}
+
+	summary := strings.Join(parts, ", ")
+
+	if _, ok := links["next"]; !ok {
+		summary += " (no next page)"
+	}
+
+	p.printf("* pagination: %s\n", summary)
+}
+
+// pagination returns the pagination links of a response by relation, for an Event.
+func (p *printer) pagination(base *url.URL, h http.Header) map[string]string {
+	links := paginationLinks(base, h)
+
+	if len(links) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(links))
+
+	for rel, u := range links {
+		m[rel] = p.linkURL(u)
+	}
+
+	return m
+}
+
+// linkURL returns a link sanitized and redacted as the URLs printed.
+func (p *printer) linkURL(u *url.URL) string {
+	s := u.String()
+
+	if !p.logger.SkipSanitize {
+		s = sanitizeUserinfo(u, p.logger.redact)
+	}
+
+	return p.redactURL(s)
+}
+
+// requestURL returns the absolute URL of a request received by a server.
+func requestURL(req *http.Request) *url.URL {
+	u := *req.URL
+
+	if u.Host == "" {
+		u.Scheme, u.Host = "http", req.Host
+
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+
+	return &u
+}
@@ -0,0 +1,133 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrintPagination(t *testing.T) {
+	t.Parallel()
+
+	base, _ := url.Parse("https://api.example.com/repos?page=2&per_page=50")
+
+	testCases := []struct {
+		name string
+		h    http.Header
+		want string
+	}{
+		{
+			name: "github style",
+			h: http.Header{"Link": {`<https://api.example.com/repos?page=3&per_page=50>; rel="next", ` +
+				`<https://api.example.com/repos?page=10&per_page=50>; rel="last", ` +
+				`<https://api.example.com/repos?page=1&per_page=50>; rel="first", ` +
+				`<https://api.example.com/repos?page=1&per_page=50>; rel="prev"`}},
+			want: "* pagination: first ?page=1&per_page=50, prev ?page=1&per_page=50, next ?page=3&per_page=50, last ?page=10&per_page=50\n",
+		},
+		{
+			name: "relative links on multiple headers",
+			h: http.Header{"Link": {
+				`</repos?page=1&per_page=50>; rel="previous first"`,
+				`</repos?cursor=a,b>; rel=next; title="a, b"`,
+			}},
+			want: "* pagination: first ?page=1&per_page=50, prev ?page=1&per_page=50, next ?cursor=a,b\n",
+		},
+		{
+			name: "other endpoint and last page",
+			h:    http.Header{"Link": {`<https://cdn.example.com/archive?page=1&access_token=secret>; rel="prev"`}},
+			want: "* pagination: prev https://cdn.example.com/archive?page=1&access_token=" + redactedValue + " (no next page)\n",
+		},
+		{
+			name: "other relations",
+			h:    http.Header{"Link": {`<https://example.com/style.css>; rel=preload; as=style`}},
+		},
+		{
+			name: "malformed",
+			h:    http.Header{"Link": {`https://example.com/?page=2; rel="next"`}},
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printPagination(base, tc.h)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("printPagination(%s) = %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOutgoingPagination(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Link", `</items?page=3>; rel="next"`)
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Get(ts.URL + "/items?page=2")
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if want := "* pagination: next ?page=3\n< HTTP/1.1 200 OK\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("logged output doesn't contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestIncomingPaginationEvent(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		ResponseHeader: true,
+	}
+
+	logger.SetEncoder(&JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Link", `</items?page=1>; rel="prev"`)
+		w.Header().Add("Link", `</items?page=3>; rel="next"`)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/items?page=2", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := decodeEvent(t, buf.Bytes())
+
+	want := map[string]interface{}{
+		"prev": "http://example.com/items?page=1",
+		"next": "http://example.com/items?page=3",
+	}
+
+	if got := event["pagination"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("event pagination = %v, wanted %v", got, want)
+	}
+}
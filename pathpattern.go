@@ -0,0 +1,63 @@
+package httpretty
+
+import "strings"
+
+// pathPattern is a path with placeholder segments, such as /users/{id}.
+type pathPattern struct {
+	pattern  string
+	segments []string
+}
+
+func newPathPattern(pattern string) pathPattern {
+	return pathPattern{
+		pattern:  pattern,
+		segments: strings.Split(pattern, "/"),
+	}
+}
+
+// match checks if the path matches the pattern. Placeholders match any non-empty segment.
+func (pp pathPattern) match(path string) bool {
+	segments := strings.Split(path, "/")
+
+	if len(segments) != len(pp.segments) {
+		return false
+	}
+
+	for i, s := range pp.segments {
+		if isPlaceholder(s) {
+			if segments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+
+		if s != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPlaceholder(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// maskPath replaces the path of a request URI (with or without a query) with the first pattern it matches.
+// See Logger.PathPatterns.
+func (p *printer) maskPath(uri string) string {
+	path, query := uri, ""
+
+	if i := strings.IndexByte(uri, '?'); i != -1 {
+		path, query = uri[:i], uri[i:]
+	}
+
+	for _, pp := range p.config.paths {
+		if pp.match(path) {
+			return pp.pattern + query
+		}
+	}
+
+	return uri
+}
@@ -0,0 +1,88 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPathPatternMatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/users/{id}", path: "/users/123", want: true},
+		{pattern: "/users/{id}", path: "/users/", want: false},
+		{pattern: "/users/{id}", path: "/users/123/posts", want: false},
+		{pattern: "/users/{id}/posts/{post}", path: "/users/123/posts/abc", want: true},
+		{pattern: "/users/{id}/posts/{post}", path: "/users/123/comments/abc", want: false},
+		{pattern: "/users/{}", path: "/users/{}", want: true},
+		{pattern: "/users/{}", path: "/users/123", want: false},
+	}
+
+	for _, tc := range testCases {
+		if got := newPathPattern(tc.pattern).match(tc.path); got != tc.want {
+			t.Errorf("pattern %q match(%q) = %v, wanted %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestIncomingPathPatterns(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader: true,
+	}
+
+	logger.PathPatterns("/users/{id}", "/users/{id}/posts/{post}")
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/users/123/posts/456?draft=1", nil)
+	req.RemoteAddr = ""
+	logger.Middleware(helloHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `* Request to http://example.com/users/{id}/posts/{post}?draft=1
+> GET /users/{id}/posts/{post}?draft=1 HTTP/1.1
+> Host: example.com
+
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingPathPatterns(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		Logfmt: true,
+	}
+
+	logger.PathPatterns("/users/{id}")
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	if _, err := client.Get(ts.URL + "/users/123"); err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	if got, want := buf.String(), "path=/users/{id} "; !strings.Contains(got, want) {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package httpretty
+
+import (
+	"net/http"
+	"time"
+)
+
+// ExchangeSummary describes a completed exchange for a PostFilter.
+type ExchangeSummary struct {
+	// Request of the exchange. Its body might have been read already.
+	Request *http.Request
+
+	// StatusCode of the response, or zero if the exchange failed without one.
+	StatusCode int
+
+	// Duration of the exchange, until its output is ready to be printed.
+	Duration time.Duration
+
+	// Err of an exchange that failed, such as a connection error.
+	Err error
+}
+
+// PostFilter decides whether an exchange is printed once it completes.
+// Return false to discard its output, such as for requests faster than 300ms that succeeded.
+// It is called without holding the logger, so it can call its methods, such as SetPostFilter.
+type PostFilter func(s ExchangeSummary) (keep bool)
+
+// SetPostFilter allows you to set a function to discard exchanges once they complete.
+// It only applies to the OnEnd flusher, as it relies on the exchange being buffered until the end.
+// Pass nil to remove the post filter. This method is concurrency safe.
+func (l *Logger) SetPostFilter(f PostFilter) {
+	l.updateConfig(func(c *config) {
+		c.postFilter = f
+	})
+}
+
+// startSummary starts summarizing the exchange, if a post filter is set.
+func (p *printer) startSummary(req *http.Request) {
+	if p.config.postFilter == nil {
+		return
+	}
+
+	p.summary = &ExchangeSummary{
+		Request: req,
+	}
+
+	p.started = time.Now()
+}
+
+// setErr records the error of a failed exchange.
+func (p *printer) setErr(err error) {
+	p.failed = true
+
+	if p.summary != nil {
+		p.summary.Err = err
+	}
+}
+
+// keepExchange checks if the output of the exchange should be kept.
+// The post filter is called without the logger mutex held, so it can call the logger,
+// and a slow filter doesn't hold back the output of other exchanges.
+func (p *printer) keepExchange() (keep bool) {
+	s, f := p.postFilterSummary()
+
+	if f == nil {
+		return true
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			p.printf("* cannot post-filter exchange: panic: %v\n", e)
+			keep = true
+		}
+	}()
+
+	return f(s)
+}
+
+// postFilterSummary returns the summary of the exchange and the post filter to call, if any.
+func (p *printer) postFilterSummary() (ExchangeSummary, PostFilter) {
+	p.logger.mu.Lock()
+	defer p.logger.mu.Unlock()
+
+	f := p.config.postFilter
+
+	if p.summary == nil || f == nil || p.flusher != OnEnd {
+		return ExchangeSummary{}, nil
+	}
+
+	s := *p.summary
+	s.StatusCode = p.statusCode
+	s.Duration = time.Since(p.started)
+	return s, f
+}
@@ -0,0 +1,173 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutgoingPostFilter(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+
+	var summaries []ExchangeSummary
+
+	logger.SetPostFilter(func(s ExchangeSummary) bool {
+		summaries = append(summaries, s)
+		return s.StatusCode >= 500 || s.Err != nil || s.Duration > 300*time.Millisecond
+	})
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for _, path := range []string{"/ok", "/fail"} {
+		resp, err := client.Get(ts.URL + path)
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		resp.Body.Close()
+	}
+
+	if _, err := client.Get("http://localhost:0/unreachable"); err == nil {
+		t.Errorf("expected request to unreachable server to fail")
+	}
+
+	got := buf.String()
+
+	if strings.Contains(got, "/ok") {
+		t.Errorf("exchange discarded by the post filter was printed: %s", got)
+	}
+
+	if !strings.Contains(got, "> GET /fail HTTP/1.1") || !strings.Contains(got, "< HTTP/1.1 500 Internal Server Error") {
+		t.Errorf("exchange kept by the post filter wasn't printed: %s", got)
+	}
+
+	if !strings.Contains(got, "> GET /unreachable HTTP/1.1") {
+		t.Errorf("failed exchange wasn't printed: %s", got)
+	}
+
+	if len(summaries) != 3 {
+		t.Fatalf("expected 3 summaries, got %d", len(summaries))
+	}
+
+	if s := summaries[0]; s.StatusCode != http.StatusOK || s.Request.URL.Path != "/ok" || s.Err != nil || s.Duration <= 0 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+
+	if s := summaries[2]; s.StatusCode != 0 || s.Err == nil {
+		t.Errorf("unexpected summary of failed exchange: %+v", s)
+	}
+}
+
+func TestIncomingPostFilter(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+	logger.SetPostFilter(func(s ExchangeSummary) bool {
+		return s.StatusCode != http.StatusOK
+	})
+
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	got := buf.String()
+
+	if strings.Contains(got, "/ok") {
+		t.Errorf("exchange discarded by the post filter was printed: %s", got)
+	}
+
+	if !strings.Contains(got, "< HTTP/1.1 404 Not Found") {
+		t.Errorf("exchange kept by the post filter wasn't printed: %s", got)
+	}
+}
+
+func TestPostFilterCallingLogger(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+	logger.SetPostFilter(func(s ExchangeSummary) bool {
+		// the logger mutex isn't held while filtering, so the logger can be used.
+		logger.SetOutput(&buf)
+		return true
+	})
+
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	done := make(chan struct{})
+
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exchange didn't end: the post filter is called with the logger mutex held")
+	}
+
+	if got := buf.String(); !strings.Contains(got, "> GET / HTTP/1.1") {
+		t.Errorf("exchange kept by the post filter wasn't printed: %s", got)
+	}
+}
+
+func TestPostFilterPanic(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFlusher(OnEnd)
+	logger.SetPostFilter(func(s ExchangeSummary) bool {
+		panic("evil")
+	})
+
+	h := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := buf.String(), "* cannot post-filter exchange: panic: evil\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("logged output = %q, wanted suffix %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package httpretty
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -12,8 +13,12 @@ import (
 	"net"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/henvic/httpretty/internal/color"
 	"github.com/henvic/httpretty/internal/header"
@@ -24,89 +29,363 @@ func newPrinter(l *Logger) printer {
 	defer l.mu.Unlock()
 
 	return printer{
-		logger:  l,
-		flusher: l.flusher,
+		logger:        l,
+		config:        l.getConfig(),
+		formatters:    l.Formatters,
+		flusher:       l.flusher,
+		streamFlusher: l.stream,
 	}
 }
 
 type printer struct {
 	flusher Flusher
 
+	// streamFlusher replaces flusher on streaming exchanges, if set.
+	streamFlusher *Flusher
+
 	logger *Logger
 	buf    bytes.Buffer
+
+	// config and formatters of the logger when the exchange started.
+	config     *config
+	formatters []Formatter
+
+	written   int64
+	truncated bool
+
+	// degraded is set when the output writer blocks for longer than Logger.WriteTimeout.
+	degraded bool
+	dropped  int
+
+	// route and fields are set by the options passed to Logger.Handler.
+	route  string
+	fields map[string]string
+
+	skipBodies bool
+
+	annotations *annotations
+
+	// autoHeaders the HTTP transport is going to add to an outgoing request.
+	autoHeaders http.Header
+
+	// bodyKey identifies the response being printed for Logger.SkipUnchangedResponseBody.
+	bodyKey string
+
+	// held request body, waiting for the response to check Logger.CaptureHeader and Logger.ErrorBodiesOnly.
+	held *printer
+
+	// multiStatus is set when printing the body of a 207 Multi-Status response.
+	multiStatus bool
+
+	// streamed request body, printed once the response is received.
+	streamed *streamedBody
+
+	// statusCode of the response, or failed if the exchange failed without one, for outputs such as SyslogSink.
+	statusCode int
+	failed     bool
+
+	// pending output held back while another exchange holds the output, when Logger.OrderedOutput is set.
+	pending bytes.Buffer
+
+	// ended is set once the output is flushed at the end of the exchange.
+	ended bool
+
+	// counted request body, when Logger.CountBodyBytes is set.
+	counted *countingBody
+
+	// summary of the exchange for the post filter, and when it started.
+	summary *ExchangeSummary
+	started time.Time
+
+	// redaction rules set on the request context with WithRedaction.
+	redaction *Redaction
+
+	// logged output of the exchange, when Logger.ContextOutput is set.
+	logged *loggedOutput
+
+	// sequence number of the exchange, when Logger.SequenceNumbers is set.
+	sequence uint64
+
+	// bodies buffered, which can be summarized to stay within Logger.MaxBufferedBytes,
+	// and how much of the memory in use is accounted for this exchange.
+	bodies    []bufferedBody
+	bodyStart int
+	inBody    bool
+	accounted int64
 }
 
 func (p *printer) maybeOnReady() {
 	if p.flusher == OnReady {
-		p.flush()
+		p.flushStep()
 	}
 }
 
+// flush the output at the end of the exchange, unless the post filter discards it.
 func (p *printer) flush() {
-	if p.flusher == NoBuffer {
-		return
-	}
+	keep := p.keepExchange()
 
 	p.logger.mu.Lock()
 	defer p.logger.mu.Unlock()
-	defer p.buf.Reset()
-	w := p.logger.getWriter()
-	fmt.Fprint(w, p.buf.String())
+
+	if !keep {
+		p.buf.Reset()
+	}
+
+	p.flushBuffer(true)
 }
 
-func (p *printer) print(a ...interface{}) {
+// flushStep flushes the output of a step of the exchange.
+func (p *printer) flushStep() {
 	p.logger.mu.Lock()
 	defer p.logger.mu.Unlock()
-	w := p.logger.getWriter()
+	p.flushBuffer(false)
+}
 
-	if p.flusher == NoBuffer {
-		fmt.Fprint(w, a...)
-		return
+// flushBuffer writes what is buffered. The logger mutex must be held.
+func (p *printer) flushBuffer(end bool) {
+	var s string
+
+	if p.flusher != NoBuffer {
+		s = p.buf.String()
+		p.buf.Reset()
+	}
+
+	if end && p.held != nil {
+		p.held.buf.Reset()
+		p.held.updateBuffered()
 	}
 
-	fmt.Fprint(&p.buf, a...)
+	p.updateBuffered()
+	p.emit(s, end)
+	p.warnDropped()
+
+	if end {
+		p.ended = true
+	}
+}
+
+func (p *printer) print(a ...interface{}) {
+	p.write(fmt.Sprint(a...))
 }
 
 func (p *printer) println(a ...interface{}) {
+	p.write(fmt.Sprintln(a...))
+}
+
+func (p *printer) printf(format string, a ...interface{}) {
+	p.write(fmt.Sprintf(format, a...))
+}
+
+func (p *printer) write(s string) {
 	p.logger.mu.Lock()
 	defer p.logger.mu.Unlock()
-	w := p.logger.getWriter()
+	s = p.limit(s)
+
+	if p.logged != nil {
+		p.logged.write(s)
+	}
 
 	if p.flusher == NoBuffer {
-		fmt.Fprintln(w, a...)
+		p.emit(s, p.ended)
 		return
 	}
 
-	fmt.Fprintln(&p.buf, a...)
+	p.buf.WriteString(s)
+
+	if p.flusher == OnEnd {
+		p.account()
+	}
+}
+
+// queuedOutput of an exchange that ended while another held the output.
+type queuedOutput struct {
+	p *printer
+	s string
 }
 
-func (p *printer) printf(format string, a ...interface{}) {
-	p.logger.mu.Lock()
-	defer p.logger.mu.Unlock()
+// emit writes s to the output, unless Logger.OrderedOutput is set and another exchange holds the output.
+// In that case, it is held back until the exchange holding the output ends. The logger mutex must be held.
+func (p *printer) emit(s string, end bool) {
+	l := p.logger
+
+	if !l.OrderedOutput {
+		if s != "" {
+			p.output(s)
+		}
+
+		return
+	}
+
+	if l.owner != nil && l.owner != p {
+		p.pending.WriteString(s)
+
+		if end && p.pending.Len() != 0 {
+			l.queued = append(l.queued, queuedOutput{p: p, s: p.pending.String()})
+			p.pending.Reset()
+		}
+
+		return
+	}
+
+	if p.pending.Len() != 0 {
+		s = p.pending.String() + s
+		p.pending.Reset()
+	}
+
+	if s != "" {
+		p.output(s)
+	}
+
+	switch {
+	case !end && s != "":
+		l.owner = p
+	case end && l.owner == p:
+		l.owner = nil
+		queued := l.queued
+		l.queued = nil
+
+		for _, q := range queued {
+			q.p.output(q.s)
+		}
+	}
+}
+
+// output writes to the logger output. The logger mutex must be held.
+//
+// If Logger.WriteTimeout is set, the write happens on a separate goroutine,
+// and the rest of the exchange is dropped if it doesn't finish in time.
+func (p *printer) output(s string) {
 	w := p.logger.getWriter()
+	timeout := p.logger.WriteTimeout
 
-	if p.flusher == NoBuffer {
-		fmt.Fprintf(w, format, a...)
+	if timeout <= 0 {
+		p.writeTo(w, s)
+		return
+	}
+
+	// drop the output if the writer is still blocked by a previous write to avoid stalling.
+	if p.degraded || !atomic.CompareAndSwapInt32(&p.logger.pendingWrite, 0, 1) {
+		p.degraded = true
+		p.dropped += len(s)
 		return
 	}
 
-	fmt.Fprintf(&p.buf, format, a...)
+	done := make(chan struct{})
+	statusCode, failed := p.statusCode, p.failed
+
+	go func() {
+		writeExchange(w, s, statusCode, failed)
+		atomic.StoreInt32(&p.logger.pendingWrite, 0)
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		p.degraded = true
+	}
+}
+
+// writeTo writes to w, passing along the outcome of the exchange if w is an exchangeWriter.
+func (p *printer) writeTo(w io.Writer, s string) {
+	writeExchange(w, s, p.statusCode, p.failed)
+}
+
+// warnDropped prints a warning about dropped output if the writer is available again.
+// The logger mutex must be held.
+func (p *printer) warnDropped() {
+	if !p.degraded || atomic.LoadInt32(&p.logger.pendingWrite) != 0 {
+		return
+	}
+
+	dropped := p.dropped
+	p.degraded = false
+	p.dropped = 0
+	p.output(fmt.Sprintf("* output writer blocked for more than %v: %d bytes of this exchange were dropped\n",
+		p.logger.WriteTimeout, dropped))
+}
+
+// limit output to the Logger.MaxExchangeBytes budget, adding a marker once it is exceeded.
+func (p *printer) limit(s string) string {
+	max := p.logger.MaxExchangeBytes
+
+	if max <= 0 {
+		return s
+	}
+
+	if p.truncated {
+		return ""
+	}
+
+	if p.written+int64(len(s)) <= max {
+		p.written += int64(len(s))
+		return s
+	}
+
+	p.truncated = true
+	n := max - p.written
+
+	// avoid breaking a multi-byte character in half
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	s = s[:n]
+	p.written = max
+
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	return s + fmt.Sprintf("* exchange output truncated (longer than %d bytes)\n", max)
 }
 
 func (p *printer) printRequest(req *http.Request) {
+	if p.logger.Fingerprint {
+		p.printFingerprint(req)
+	}
+
 	if p.logger.RequestHeader {
 		p.printRequestHeader(req)
 		p.maybeOnReady()
 	}
 
-	if p.logger.RequestBody && req.Body != nil {
+	if p.logger.RequestBody && !p.skipBodies && req.Body != nil {
+		if p.logger.CaptureHeader != "" || p.logger.ErrorBodiesOnly {
+			p.holdRequestBody(req)
+			return
+		}
+
 		p.printRequestBody(req)
 		p.maybeOnReady()
+		return
 	}
+
+	p.countRequestBody(req)
 }
 
 func (p *printer) printRequestInfo(req *http.Request) {
 	to := req.URL.String()
+	mounted := p.logger.MountedPaths && isRewritten(req)
+
+	if !p.logger.SkipSanitize {
+		to = sanitizeUserinfo(req.URL, p.logger.redact)
+	}
+
+	if masked := p.maskPath(req.URL.RequestURI()); masked != req.URL.RequestURI() {
+		to = masked
+
+		if req.URL.Host != "" {
+			to = req.URL.Scheme + "://" + req.URL.Host + to
+		}
+	}
+
+	if mounted {
+		to = p.maskPath(req.RequestURI)
+	}
 
 	// req.URL.Host is empty on the request received by a server
 	if req.URL.Host == "" {
@@ -120,16 +399,28 @@ func (p *printer) printRequestInfo(req *http.Request) {
 		to = schema + to
 	}
 
-	p.printf("* Request to %s\n", p.format(color.FgBlue, to))
+	p.printf("* Request to %s\n", p.format(color.FgBlue, "%s", p.redactURL(to)))
+
+	if mounted {
+		p.printf("* Handler path: %s\n", p.format(color.FgBlue, "%s", p.maskURI(req.URL.RequestURI())))
+	}
 
 	if req.RemoteAddr != "" {
 		p.printf("* Request from %s\n", p.format(color.FgBlue, req.RemoteAddr))
 	}
+
+	if p.route != "" {
+		p.printf("* Route: %s\n", p.format(color.FgBlue, "%s", p.route))
+	}
+
+	for _, key := range sortedKeys(p.fields) {
+		p.printf("* %s: %s\n", key, p.format(color.FgBlue, "%s", p.fields[key]))
+	}
 }
 
 // checkFilter checkes if the request is filtered and if the Request value is nil.
 func (p *printer) checkFilter(req *http.Request) (skip bool) {
-	filter := p.logger.getFilter()
+	filter := p.config.filter
 
 	if req == nil {
 		p.printf("> %s\n", p.format(color.FgRed, "error: null request"))
@@ -166,20 +457,61 @@ func (p *printer) printResponse(resp *http.Response) {
 		return
 	}
 
+	p.statusCode = resp.StatusCode
+
+	if isStreamingResponse(resp.StatusCode, resp.Header) {
+		p.streaming()
+	}
+
+	if p.logger.AuditSecurityHeaders {
+		p.printSecurityHeaders(resp.TLS, resp.Header)
+	}
+
 	if p.logger.ResponseHeader {
+		if resp.Request != nil {
+			p.printConditional(resp.Request, resp.StatusCode)
+			p.printRange(resp.Request, resp.StatusCode, resp.Header)
+			p.printCORSPreflight(resp.Request, resp.Header)
+			p.printWebSocketNegotiation(resp.Request, resp.StatusCode, resp.Header)
+		}
+
+		p.printCacheStatus(resp.Header)
+
+		if resp.Request != nil {
+			p.printPagination(resp.Request.URL, resp.Header)
+		}
+
 		p.printResponseHeader(resp.Proto, resp.Status, resp.Header)
+		p.printReasonPhrase(resp.StatusCode, resp.Status)
+
+		if resp.Uncompressed && !p.logger.ResponseBody {
+			p.println("* body was transparently gunzipped by the transport")
+		}
+
 		p.maybeOnReady()
 	}
 
-	if p.logger.ResponseBody && resp.Body != nil && (resp.Request == nil || resp.Request.Method != http.MethodHead) {
+	p.printResponseAnnotations(resp)
+
+	captured := p.isCaptured(resp.StatusCode, resp.Header)
+	p.releaseRequestBody(captured)
+
+	if p.logger.ResponseBody && captured && resp.Body != nil && (resp.Request == nil || resp.Request.Method != http.MethodHead) {
+		if resp.Request != nil {
+			p.bodyKey = responseBodyKey(resp.Request)
+		}
+
+		p.multiStatus = resp.StatusCode == http.StatusMultiStatus
 		p.printResponseBodyOut(resp)
 		p.maybeOnReady()
+		return
 	}
 
+	p.countResponseBody(resp)
 }
 
 func (p *printer) checkBodyFiltered(h http.Header) (skip bool, err error) {
-	if f := p.logger.getBodyFilter(); f != nil {
+	if f := p.config.bodyFilter; f != nil {
 		defer func() {
 			if e := recover(); e != nil {
 				p.printf("* panic while filtering body: %v\n", e)
@@ -211,14 +543,45 @@ func (p *printer) printResponseBodyOut(resp *http.Response) {
 	}
 
 	if p.logger.MaxResponseBody > 0 && resp.ContentLength > p.logger.MaxResponseBody {
-		p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n", resp.ContentLength, p.logger.MaxResponseBody)
+		if p.logger.OffloadBodies != nil {
+			resp.Body = p.offloadBody(resp.Body, resp.Header.Get("Content-Type"))
+			return
+		}
+
+		if p.logger.SpillDir != "" {
+			resp.Body = p.spillBody(resp.Body)
+			return
+		}
+
+		if p.logger.TruncateLongJSON && isJSONMediatype(resp.Header.Get("Content-Type")) {
+			resp.Body = p.truncateLongJSON(resp.Body, resp.ContentLength, p.logger.MaxResponseBody)
+		} else {
+			p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n", resp.ContentLength, p.logger.MaxResponseBody)
+		}
+
+		if p.logger.ChecksumLongBodies {
+			resp.Body = p.checksumBody(resp.Body)
+		}
 		return
 	}
 
-	contentType := resp.Header.Get("Content-Type")
+	if resp.Uncompressed {
+		p.printTransparentDecompression(resp)
+	}
+
+	if d := p.delimitedStream(resp.Header); d != nil {
+		resp.Body = p.frameBody(d, resp.Body)
+		return
+	}
 
 	if resp.ContentLength == -1 {
-		if newBody := p.printBodyUnknownLength(contentType, p.logger.MaxResponseBody, resp.Body); newBody != nil {
+		newBody, captured := p.printBodyUnknownLength(resp.Header, p.logger.MaxResponseBody, resp.Body)
+
+		switch {
+		case captured != nil && p.logger.CapturedResponseBody:
+			resp.Body.Close()
+			resp.Body = newCapturedBody(captured)
+		case newBody != nil:
 			resp.Body = newBody
 		}
 
@@ -230,10 +593,15 @@ func (p *printer) printResponseBodyOut(resp *http.Response) {
 	defer resp.Body.Close()
 
 	defer func() {
+		if p.logger.CapturedResponseBody && int64(buf.Len()) == resp.ContentLength {
+			resp.Body = newCapturedBody(buf.Bytes())
+			return
+		}
+
 		resp.Body = ioutil.NopCloser(&buf)
 	}()
 
-	p.printBodyReader(contentType, tee)
+	p.printBodyReader(resp.Header, tee)
 }
 
 // isBinary uses heuristics to guess if file is binary (actually, "printable" in the terminal).
@@ -304,7 +672,9 @@ func isBinaryMediatype(mediatype string) bool {
 
 const maxDefaultUnknownReadable = 4096 // bytes
 
-func (p *printer) printBodyUnknownLength(contentType string, maxLength int64, r io.ReadCloser) (newBody io.ReadCloser) {
+// printBodyUnknownLength prints a body of unknown length, if it isn't too long.
+// The captured value is set when the whole body was read.
+func (p *printer) printBodyUnknownLength(h http.Header, maxLength int64, r io.ReadCloser) (newBody io.ReadCloser, captured []byte) {
 	shortReader := bufio.NewReader(r)
 
 	if maxLength == 0 {
@@ -322,11 +692,21 @@ func (p *printer) printBodyUnknownLength(contentType string, maxLength int64, r
 	// Avoiding returning early to mitigate any risk of bad reader implementations that might
 	// send something even after returning io.EOF if read again.
 	case err == io.EOF && n == 0:
+		captured = pb
+	case err == nil && int64(n) > maxLength && p.logger.OffloadBodies != nil:
+		newBody = p.offloadBody(newBody, h.Get("Content-Type"))
+	case err == nil && int64(n) > maxLength && p.logger.SpillDir != "":
+		newBody = p.spillBody(newBody)
 	case err == nil && int64(n) > maxLength:
 		p.printf("* body is too long, skipping (contains more than %d bytes)\n", n-1)
+
+		if p.logger.ChecksumLongBodies {
+			newBody = p.checksumBody(newBody)
+		}
 	case err == io.ErrUnexpectedEOF || err == nil:
+		captured = pb
 		// cannot pass same bytes reader below because we only read it once.
-		p.printBodyReader(contentType, bytes.NewReader(pb))
+		p.printBodyReader(h, bytes.NewReader(pb))
 	default:
 		p.printf("* cannot read body: %v (%d bytes read)\n", err, n)
 	}
@@ -467,14 +847,28 @@ func (p *printer) printCertificate(hostname string, cert *x509.Certificate) {
 	p.println("*  TLS certificate verify ok.")
 }
 
-func (p *printer) printServerResponse(req *http.Request, rec *responseRecorder) {
-	if p.logger.ResponseHeader {
-		// TODO(henvic): see how httptest.ResponseRecorder adds extra headers due to Content-Type detection
-		// and other stuff (Date). It would be interesting to show them here too (either as default or opt-in).
-		p.printResponseHeader(req.Proto, fmt.Sprintf("%d %s", rec.statusCode, http.StatusText(rec.statusCode)), rec.Header())
+func (p *printer) printServerResponse(req *http.Request, rec *responseRecorder, resolveFile func(req *http.Request) string) {
+	if rec.eventStream {
+		p.printEventStreamEnd(rec)
+		return
+	}
+
+	if rec.hijacked {
+		p.statusCode = rec.statusCode
+		p.printRequestBodySize()
+		p.printPrincipal()
+		p.releaseRequestBody(false)
+		p.printHijacked(req)
+		return
 	}
 
-	if !p.logger.ResponseBody || rec.size == 0 {
+	p.printServerResponseHeader(req, rec, resolveFile)
+
+	captured := p.isCaptured(rec.statusCode, rec.Header())
+	p.releaseRequestBody(captured)
+
+	if !p.logger.ResponseBody || !captured || p.skipBodies || rec.size == 0 {
+		p.printResponseBodySize(rec.size)
 		return
 	}
 
@@ -493,12 +887,70 @@ func (p *printer) printServerResponse(req *http.Request, rec *responseRecorder)
 		return
 	}
 
-	if p.logger.MaxResponseBody > 0 && rec.size > p.logger.MaxResponseBody {
-		p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n", rec.size, p.logger.MaxResponseBody)
+	if rec.spill != nil {
+		p.printSpilledBody(rec.spill.Name(), rec.preview)
+		return
+	}
+
+	// the body isn't buffered when it is declared too long by its Content-Length.
+	if p.logger.MaxResponseBody > 0 && (rec.size > p.logger.MaxResponseBody || rec.buf == nil) {
+		if rec.head != nil && isJSONMediatype(rec.Header().Get("Content-Type")) {
+			p.printTruncatedJSON(rec.head, rec.size, p.logger.MaxResponseBody)
+		} else {
+			p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n", rec.size, p.logger.MaxResponseBody)
+		}
+
+		if rec.checksum != nil {
+			p.printChecksum(rec.checksum.Sum(nil), rec.size)
+		}
 		return
 	}
 
-	p.printBodyReader(rec.Header().Get("Content-Type"), rec.buf)
+	p.bodyKey = responseBodyKey(req)
+	p.multiStatus = rec.statusCode == http.StatusMultiStatus
+	p.printBodyReader(rec.Header(), rec.buf)
+}
+
+// printServerResponseHeader prints the response of a handler up to its body.
+func (p *printer) printServerResponseHeader(req *http.Request, rec *responseRecorder, resolveFile func(req *http.Request) string) {
+	p.statusCode = rec.statusCode
+	p.printRequestBodySize()
+	p.printPrincipal()
+
+	if isStreamingResponse(rec.statusCode, rec.Header()) {
+		p.streaming()
+	}
+
+	if p.logger.AuditSecurityHeaders {
+		p.printSecurityHeaders(req.TLS, rec.Header())
+	}
+
+	if p.logger.ResponseHeader {
+		for _, code := range rec.informational {
+			p.printInformational(req.Proto, code, nil)
+		}
+
+		// TODO(henvic): see how httptest.ResponseRecorder adds extra headers due to Content-Type detection
+		// and other stuff (Date). It would be interesting to show them here too (either as default or opt-in).
+		p.printConditional(req, rec.statusCode)
+		p.printRange(req, rec.statusCode, rec.Header())
+		p.printStaticFile(req, resolveFile, rec)
+		p.printCORSPreflight(req, rec.Header())
+		p.printWebSocketNegotiation(req, rec.statusCode, rec.Header())
+		p.printCacheStatus(rec.Header())
+		p.printPagination(requestURL(req), rec.Header())
+		p.printResponseHeader(req.Proto, fmt.Sprintf("%d %s", rec.statusCode, http.StatusText(rec.statusCode)), rec.Header())
+	}
+
+	p.printResponseAnnotations(&http.Response{
+		Status:     fmt.Sprintf("%d %s", rec.statusCode, http.StatusText(rec.statusCode)),
+		StatusCode: rec.statusCode,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     rec.Header(),
+		Request:    req,
+	})
 }
 
 func (p *printer) printResponseHeader(proto, status string, h http.Header) {
@@ -510,8 +962,8 @@ func (p *printer) printResponseHeader(proto, status string, h http.Header) {
 	p.println()
 }
 
-func (p *printer) printBodyReader(contentType string, r io.Reader) {
-	mediatype, _, _ := mime.ParseMediaType(contentType)
+func (p *printer) printBodyReader(h http.Header, r io.Reader) {
+	mediatype, params, _ := mime.ParseMediaType(h.Get("Content-Type"))
 	body, err := ioutil.ReadAll(r)
 
 	if err != nil {
@@ -519,12 +971,66 @@ func (p *printer) printBodyReader(contentType string, r io.Reader) {
 		return
 	}
 
+	if encoding := h.Get("Content-Encoding"); encoding != "" {
+		if body, err = decodeContent(encoding, body); err != nil {
+			p.printf("* cannot decode %s body: %v\n", encoding, p.format(color.FgRed, err))
+			return
+		}
+	}
+
+	if p.bodyKey != "" && p.logger.SkipUnchangedResponseBody && p.checkUnchangedBody(body) {
+		return
+	}
+
+	if p.multiStatus {
+		p.printMultiStatus(body)
+	}
+
+	if mediatype == "" && len(body) != 0 {
+		mediatype = sniffMediatype(body)
+
+		if mediatype != "application/octet-stream" && (isBinaryMediatype(mediatype) || isBinary(body)) {
+			p.printf("* body appears to be %s (%s)\n", mediatype, formatBytes(int64(len(body))))
+			return
+		}
+	}
+
+	if framing, ok := matchFraming(mediatype); ok {
+		p.printFramedBody(framing, body)
+		return
+	}
+
+	if decode, ok := binaryDecoders[mediatype]; ok {
+		p.printDecodedBody(mediatype, decode, body)
+		return
+	}
+
+	if strings.HasPrefix(mediatype, "multipart/") && params["boundary"] != "" {
+		p.printMultipart(params["boundary"], body)
+		return
+	}
+
+	p.printBody(mediatype, body)
+}
+
+func (p *printer) printBody(mediatype string, body []byte) {
+	p.startBody()
+	defer p.endBody()
+
 	if isBinary(body) {
 		p.println("* body contains binary data")
 		return
 	}
 
-	for _, f := range p.logger.Formatters {
+	if patterns := p.secretPatterns(); len(patterns) != 0 {
+		var counts []secretCount
+
+		if body, counts = redactSecrets(patterns, body, p.logger.redact); len(counts) != 0 {
+			p.printRedactedSecrets(counts)
+		}
+	}
+
+	for _, f := range p.formatters {
 		if ok := p.safeBodyMatch(f, mediatype); !ok {
 			continue
 		}
@@ -552,7 +1058,39 @@ func (p *printer) safeBodyMatch(f Formatter, mediatype string) bool {
 	return f.Match(mediatype)
 }
 
-func (p *printer) safeBodyFormat(f Formatter, w io.Writer, src []byte) (err error) {
+func (p *printer) safeBodyFormat(f Formatter, w io.Writer, src []byte) error {
+	timeout := p.logger.FormatterTimeout
+
+	if timeout <= 0 {
+		return recoverBodyFormat(f, w, src)
+	}
+
+	// format on a separate goroutine using copies, so it can be abandoned if it hangs.
+	var buf bytes.Buffer
+	src = append([]byte(nil), src...)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- recoverBodyFormat(f, &buf, src)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+
+		_, err = buf.WriteTo(w)
+		return err
+	case <-timer.C:
+		return fmt.Errorf("formatter timed out after %v", timeout)
+	}
+}
+
+func recoverBodyFormat(f Formatter, w io.Writer, src []byte) (err error) {
 	defer func() {
 		// should not return panic as error because we want to try the next formatter
 		if e := recover(); e != nil {
@@ -572,43 +1110,174 @@ func (p *printer) format(s ...interface{}) string {
 }
 
 func (p *printer) printHeaders(prefix rune, h http.Header) {
-	if !p.logger.SkipSanitize {
-		h = header.Sanitize(header.DefaultSanitizers, h)
+	p.printHeadersMarked(prefix, h, nil)
+}
+
+// printHeadersMarked prints headers together with the auto headers, which are marked as added automatically.
+// Headers are printed on every exchange, so the lines are written to a pooled buffer, and printed at once.
+func (p *printer) printHeadersMarked(prefix rune, h, auto http.Header) {
+	if len(auto) != 0 {
+		merged := http.Header{}
+
+		for key, values := range auto {
+			merged[key] = values
+		}
+
+		for key, values := range h {
+			merged[key] = values
+		}
+
+		h = merged
+	}
+
+	skipped := p.config.skipHeader
+	defer p.printRepeatedHeaders(h, skipped)
+
+	hb := headerBufferPool.Get().(*headerBuffer)
+	defer hb.release()
+
+	for key := range h {
+		if _, skip := skipped[key]; !skip {
+			hb.keys = append(hb.keys, key)
+		}
 	}
 
-	skipped := p.logger.cloneSkipHeader()
+	sort.Strings(hb.keys)
+
+	for _, key := range hb.keys {
+		var sanitize header.SanitizeHeaderFunc
 
-	for _, key := range sortHeaderKeys(h) {
-		for _, v := range h[key] {
-			if _, skip := skipped[key]; skip {
-				continue
+		if !p.logger.SkipSanitize {
+			sanitize = p.logger.sanitizer(key)
+		}
+
+		if p.redaction.redactsHeader(key) {
+			sanitize = p.logger.redact
+		}
+
+		_, marked := auto[key]
+		values := h[key]
+
+		// see https://tools.ietf.org/html/rfc7230#section-3.2.2
+		if p.logger.JoinHeaderValues && len(values) > 1 && key != "Set-Cookie" {
+			p.writeHeaderLine(&hb.buf, prefix, key, joinHeaderValues(key, values, sanitize), marked)
+			continue
+		}
+
+		for _, v := range values {
+			if sanitize != nil {
+				v = sanitize(v)
 			}
-			p.printf("%c %s%s %s\n", prefix,
-				p.format(color.FgBlue, color.Bold, key),
-				p.format(color.FgRed, ":"),
-				p.format(color.FgYellow, v))
+
+			p.writeHeaderLine(&hb.buf, prefix, key, v, marked)
+		}
+	}
+
+	if hb.buf.Len() != 0 {
+		p.write(hb.buf.String())
+	}
+}
+
+// joinHeaderValues in a single comma-separated value. See Logger.JoinHeaderValues.
+func joinHeaderValues(key string, values []string, sanitize header.SanitizeHeaderFunc) string {
+	sep := ", "
+
+	// see https://tools.ietf.org/html/rfc6265#section-5.4
+	if key == "Cookie" {
+		sep = "; "
+	}
+
+	var b strings.Builder
+
+	for i, v := range values {
+		if i != 0 {
+			b.WriteString(sep)
+		}
+
+		if sanitize != nil {
+			v = sanitize(v)
 		}
+
+		b.WriteString(v)
 	}
+
+	return b.String()
 }
 
-func sortHeaderKeys(h http.Header) []string {
-	keys := make([]string, 0, len(h))
+// Escape sequences used for printing headers with colors.
+var (
+	headerKeyColor    = color.Start(color.FgBlue, color.Bold)
+	headerColonColor  = color.Start(color.FgRed)
+	headerValueColor  = color.Start(color.FgYellow)
+	headerMarkerColor = color.Start(color.Faint)
+)
 
-	for key := range h {
-		keys = append(keys, key)
+// writeHeaderLine writes a header line such as "> Content-Type: application/json".
+func (p *printer) writeHeaderLine(buf *bytes.Buffer, prefix rune, key, value string, marked bool) {
+	buf.WriteRune(prefix)
+	buf.WriteByte(' ')
+
+	if !p.logger.Colors {
+		buf.WriteString(key)
+		buf.WriteString(": ")
+		buf.WriteString(value)
+
+		if marked {
+			buf.WriteString(" (auto)")
+		}
+
+		buf.WriteByte('\n')
+		return
 	}
 
-	sort.Strings(keys)
+	for _, s := range []string{
+		headerKeyColor, key, color.End,
+		headerColonColor, ":", color.End, " ",
+		headerValueColor, value, color.End,
+	} {
+		buf.WriteString(s)
+	}
+
+	if marked {
+		buf.WriteString(" " + headerMarkerColor + "(auto)" + color.End)
+	}
+
+	buf.WriteByte('\n')
+}
+
+// headerBuffer is used for printing headers.
+type headerBuffer struct {
+	keys []string
+	buf  bytes.Buffer
+}
+
+var headerBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &headerBuffer{}
+	},
+}
+
+// maxPooledHeaderBuffer avoids keeping buffers that grew too much in the pool.
+const maxPooledHeaderBuffer = 64 << 10
 
-	return keys
+func (hb *headerBuffer) release() {
+	if hb.buf.Cap() > maxPooledHeaderBuffer {
+		return
+	}
+
+	hb.keys = hb.keys[:0]
+	hb.buf.Reset()
+	headerBufferPool.Put(hb)
 }
 
 func (p *printer) printRequestHeader(req *http.Request) {
 	p.printf("> %s %s %s\n",
 		p.format(color.FgBlue, color.Bold, req.Method),
-		p.format(color.FgYellow, req.URL.RequestURI()),
+		p.format(color.FgYellow, "%s", p.maskURI(req.URL.RequestURI())),
 		p.format(color.FgBlue, req.Proto))
 
+	p.printNormalizedRequestURI(req)
+
 	host := req.Host
 
 	if host == "" {
@@ -616,14 +1285,22 @@ func (p *printer) printRequestHeader(req *http.Request) {
 	}
 
 	if host != "" {
-		p.printf("> %s%s %s\n",
+		var marker string
+
+		// http.NewRequest copies the Host from the URL, unless it is overridden afterwards.
+		if p.autoHeaders != nil && (req.Host == "" || req.Host == req.URL.Host) {
+			marker = " " + p.format(color.Faint, "(auto)")
+		}
+
+		p.printf("> %s%s %s%s\n",
 			p.format(color.FgBlue, color.Bold, "Host"),
 			p.format(color.FgRed, ":"),
 			p.format(color.FgYellow, host),
+			marker,
 		)
 	}
 
-	p.printHeaders('>', req.Header)
+	p.printHeadersMarked('>', req.Header, p.autoHeaders)
 	p.println()
 }
 
@@ -648,14 +1325,34 @@ func (p *printer) printRequestBody(req *http.Request) {
 		return
 	}
 
-	// TODO(henvic): add support for printing multipart/formdata information as body (to responses too).
-	if p.logger.MaxRequestBody > 0 && req.ContentLength > p.logger.MaxRequestBody {
-		p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n",
-			req.ContentLength, p.logger.MaxRequestBody)
+	if req.ContentLength <= 0 && isStreamedBody(req.Body) {
+		p.streamRequestBody(req)
 		return
 	}
 
-	contentType := req.Header.Get("Content-Type")
+	if p.logger.MaxRequestBody > 0 && req.ContentLength > p.logger.MaxRequestBody {
+		if p.logger.OffloadBodies != nil {
+			req.Body = p.offloadBody(req.Body, req.Header.Get("Content-Type"))
+			return
+		}
+
+		if p.logger.SpillDir != "" {
+			req.Body = p.spillBody(req.Body)
+			return
+		}
+
+		if p.logger.TruncateLongJSON && isJSONMediatype(req.Header.Get("Content-Type")) {
+			req.Body = p.truncateLongJSON(req.Body, req.ContentLength, p.logger.MaxRequestBody)
+		} else {
+			p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n",
+				req.ContentLength, p.logger.MaxRequestBody)
+		}
+
+		if p.logger.ChecksumLongBodies {
+			req.Body = p.checksumBody(req.Body)
+		}
+		return
+	}
 
 	if req.ContentLength > 0 {
 		var buf bytes.Buffer
@@ -666,21 +1363,51 @@ func (p *printer) printRequestBody(req *http.Request) {
 			req.Body = ioutil.NopCloser(&buf)
 		}()
 
-		p.printBodyReader(contentType, tee)
+		p.printBodyReader(req.Header, tee)
 		return
 	}
 
-	if newBody := p.printBodyUnknownLength(contentType, p.logger.MaxRequestBody, req.Body); newBody != nil {
+	if newBody, _ := p.printBodyUnknownLength(req.Header, p.logger.MaxRequestBody, req.Body); newBody != nil {
 		req.Body = newBody
 	}
 }
 
-func (p *printer) printTimeRequest() (end func()) {
+func (p *printer) printTimeRequest(ctx context.Context) (end func()) {
 	startRequest := time.Now()
 
-	p.printf("* Request at %v\n", startRequest)
+	p.printf("* Request at %v\n", p.formatTime(startRequest))
 
 	return func() {
-		p.printf("* Request took %v\n", time.Since(startRequest))
+		took := time.Since(startRequest)
+		p.printf("* Request took %v\n", took)
+
+		if deadline, ok := ctx.Deadline(); ok {
+			p.printDeadlineBudget(took, deadline.Sub(startRequest))
+		}
+	}
+}
+
+// printDeadlineBudget prints how much of the time left until the context deadline was used,
+// warning when the exchange completes close to it, an early signal for looming timeouts.
+func (p *printer) printDeadlineBudget(took, budget time.Duration) {
+	p.printf("* used %v of %v deadline\n", took.Round(time.Millisecond), budget.Round(time.Millisecond))
+
+	if budget > 0 && budget-took < budget/10 {
+		p.printf("* %s\n", p.format(color.FgRed, "completed within 10%% of its deadline"))
+	}
+}
+
+func (p *printer) formatTime(t time.Time) string {
+	if loc := p.logger.Location; loc != nil {
+		t = t.In(loc)
+	}
+
+	switch layout := p.logger.TimeFormat; layout {
+	case "":
+		return t.String()
+	case TimeFormatUnixMilli:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	default:
+		return t.Format(layout)
 	}
 }
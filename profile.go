@@ -0,0 +1,85 @@
+package httpretty
+
+import "time"
+
+// ProfileDev returns a logger for local development, printing headers and bodies of all
+// exchanges in colors, with JSON and XML bodies formatted, and rate limits summarized.
+func ProfileDev() *Logger {
+	logger := &Logger{
+		Time:           true,
+		TLS:            true,
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+		Colors:         true,
+		Formatters:     []Formatter{&JSONFormatter{}, &XMLFormatter{}},
+	}
+
+	logger.SetResponseAnnotator(RateLimitAnnotator)
+	return logger
+}
+
+// ProfileProdSafe returns a logger for production traffic, printing only headers
+// unless the exchange fails, with credentials and secrets in bodies redacted, and limits on how much is printed.
+// Long JSON bodies are sampled, and the output is flushed once per exchange,
+// so a slow output cannot stall the requests for long. Health checks are skipped (see SkipHealthChecks).
+func ProfileProdSafe() *Logger {
+	logger := &Logger{
+		Time:             true,
+		RequestHeader:    true,
+		RequestBody:      true,
+		ResponseHeader:   true,
+		ResponseBody:     true,
+		ErrorBodiesOnly:  true,
+		RedactSecrets:    builtinSecretPatterns(),
+		MaxRequestBody:   4096,
+		MaxResponseBody:  4096,
+		MaxExchangeBytes: 16384,
+		WriteTimeout:     time.Second,
+		TimeFormat:       time.RFC3339Nano,
+		Location:         time.UTC,
+		Formatters: []Formatter{&JSONFormatter{
+			ArraySample: 5,
+			MaxDepth:    8,
+		}},
+	}
+
+	logger.SetFlusher(OnEnd)
+	logger.SetFilter(SkipHealthChecks)
+	return logger
+}
+
+// ProfileAudit returns a logger keeping a complete record of the exchanges, with credentials and secrets in bodies redacted.
+// Bodies too long to print are identified by their SHA-256 checksum, and the output is
+// flushed once per exchange so concurrent exchanges aren't mingled.
+func ProfileAudit() *Logger {
+	logger := &Logger{
+		Time:               true,
+		TLS:                true,
+		RequestHeader:      true,
+		RequestBody:        true,
+		ResponseHeader:     true,
+		ResponseBody:       true,
+		ChecksumLongBodies: true,
+		RedactSecrets:      builtinSecretPatterns(),
+		MaxRequestBody:     65536,
+		MaxResponseBody:    65536,
+		TimeFormat:         time.RFC3339Nano,
+		Location:           time.UTC,
+	}
+
+	logger.SetFlusher(OnEnd)
+	return logger
+}
+
+// builtinSecretPatterns returns all the built-in secret patterns.
+func builtinSecretPatterns() []SecretPattern {
+	var patterns []SecretPattern
+
+	for _, pack := range [][]SecretPattern{AWSKeys, GitHubTokens, SlackWebhooks, PrivateKeys} {
+		patterns = append(patterns, pack...)
+	}
+
+	return patterns
+}
@@ -0,0 +1,98 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Parallel()
+
+	for name, profile := range map[string]func() *Logger{
+		"dev":       ProfileDev,
+		"prod-safe": ProfileProdSafe,
+		"audit":     ProfileAudit,
+	} {
+		logger := profile()
+
+		if !logger.RequestHeader || !logger.ResponseHeader {
+			t.Errorf("%s profile should print headers", name)
+		}
+
+		if logger.SkipSanitize {
+			t.Errorf("%s profile should sanitize headers", name)
+		}
+
+		if profile() == logger {
+			t.Errorf("%s profile should return a new logger each time", name)
+		}
+	}
+}
+
+func TestIncomingProfileProdSafe(t *testing.T) {
+	t.Parallel()
+
+	logger := ProfileProdSafe()
+	logger.SkipRequestInfo = true
+	logger.Time = false
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, "Hello, world!")
+	}))
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "/",
+			want: `> POST / HTTP/1.1
+> Host: example.com
+> Authorization: Bearer ████████████████████
+
+< HTTP/1.1 200 OK
+< Content-Type: text/plain; charset=utf-8
+
+`,
+		},
+		{
+			path: "/missing",
+			want: `> POST /missing HTTP/1.1
+> Host: example.com
+> Authorization: Bearer ████████████████████
+
+< HTTP/1.1 404 Not Found
+< Content-Type: text/plain; charset=utf-8
+< X-Content-Type-Options: nosniff
+
+* request body:
+Hi
+
+not found
+
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+tc.path, strings.NewReader("Hi"))
+		req.Header.Set("Authorization", "Bearer secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("logged HTTP request for %s %s; want %s", tc.path, got, tc.want)
+		}
+	}
+}
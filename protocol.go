@@ -0,0 +1,65 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// alpnProtocols maps the ALPN protocol IDs of HTTP to their major version.
+var alpnProtocols = map[string]int{
+	"h2":       2,
+	"http/1.1": 1,
+}
+
+// offeredProtocols the transport offers with ALPN, if known.
+// It must be called after the transport is used, as it only configures HTTP/2 on its first request.
+func offeredProtocols(transport http.RoundTripper) []string {
+	t, ok := transport.(*http.Transport)
+
+	if !ok || t.TLSClientConfig == nil {
+		return nil
+	}
+
+	return t.TLSClientConfig.NextProtos
+}
+
+// printProtocolNegotiation prints why the protocol used differs from the one negotiated or preferred by the client,
+// such as when HTTP/2 was offered, but the server only supports HTTP/1.1.
+func (p *printer) printProtocolNegotiation(offered []string, resp *http.Response) {
+	if resp == nil || resp.TLS == nil {
+		return
+	}
+
+	negotiated := resp.TLS.NegotiatedProtocol
+
+	if major, ok := alpnProtocols[negotiated]; ok && major != resp.ProtoMajor {
+		p.printf("* %s: ALPN negotiated %s, but %s was used\n",
+			p.format(color.FgYellow, "unexpected protocol"), negotiated, resp.Proto)
+		return
+	}
+
+	if resp.ProtoMajor >= 2 || !containsString(offered, "h2") {
+		return
+	}
+
+	reason := "the server didn't select a protocol"
+
+	if negotiated != "" {
+		reason = "the server selected " + negotiated
+	}
+
+	p.printf("* %s: HTTP/2 was offered (ALPN: %s), but %s was used: %s\n",
+		p.format(color.FgYellow, "protocol fallback"), strings.Join(offered, ", "), resp.Proto, reason)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOutgoingProtocolFallback(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewTLSServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	transport := newTransport()
+	transport.TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(transport),
+	}
+
+	resp, err := client.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("Hello, world!"))
+
+	want := "* protocol fallback: HTTP/2 was offered (ALPN: h2, http/1.1), but HTTP/1.1 was used: the server selected http/1.1\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestPrintProtocolNegotiation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		offered    []string
+		proto      string
+		major      int
+		negotiated string
+		noTLS      bool
+		want       string
+	}{
+		{
+			name:       "h2",
+			offered:    []string{"h2", "http/1.1"},
+			proto:      "HTTP/2.0",
+			major:      2,
+			negotiated: "h2",
+		},
+		{
+			name:    "http/1.1 only",
+			offered: []string{"http/1.1"},
+			proto:   "HTTP/1.1",
+			major:   1,
+		},
+		{
+			name:    "plain text",
+			offered: []string{"h2", "http/1.1"},
+			proto:   "HTTP/1.1",
+			major:   1,
+			noTLS:   true,
+		},
+		{
+			name:    "no ALPN",
+			offered: []string{"h2", "http/1.1"},
+			proto:   "HTTP/1.1",
+			major:   1,
+			want:    "* protocol fallback: HTTP/2 was offered (ALPN: h2, http/1.1), but HTTP/1.1 was used: the server didn't select a protocol\n",
+		},
+		{
+			name:       "unexpected",
+			proto:      "HTTP/1.1",
+			major:      1,
+			negotiated: "h2",
+			want:       "* unexpected protocol: ALPN negotiated h2, but HTTP/1.1 was used\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		resp := &http.Response{
+			Proto:      tc.proto,
+			ProtoMajor: tc.major,
+		}
+
+		if !tc.noTLS {
+			resp.TLS = &tls.ConnectionState{
+				NegotiatedProtocol: tc.negotiated,
+			}
+		}
+
+		p := newPrinter(logger)
+		p.printProtocolNegotiation(tc.offered, resp)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: logged %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package httpretty
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// proxyAuthFlow of requests challenged by a proxy with 407 Proxy Authentication Required,
+// linking the challenge to the requests retried with credentials.
+type proxyAuthFlow struct {
+	proxy    string
+	attempts int
+	at       time.Time
+}
+
+// proxyAuthFlowTTL is how long a challenge is linked to requests retried after it.
+const proxyAuthFlowTTL = time.Minute
+
+var realmRegexp = regexp.MustCompile(`(?i)\brealm="([^"]*)"`)
+
+// proxyAuthKey identifies a request to link a challenge to its retries.
+func proxyAuthKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// proxyName of the proxy a request is sent through, if known.
+func proxyName(req *http.Request, transport http.RoundTripper) string {
+	if t, ok := transport.(*http.Transport); ok && t.Proxy != nil {
+		if u, err := t.Proxy(req); err == nil && u != nil {
+			return u.Host
+		}
+	}
+
+	return "the proxy"
+}
+
+// printProxyAuthRetry prints if a request is retried after a proxy authentication challenge.
+func (p *printer) printProxyAuthRetry(req *http.Request) {
+	l := p.logger
+	l.mu.Lock()
+	var flow proxyAuthFlow
+	f, ok := l.proxyAuth[proxyAuthKey(req)]
+
+	if ok {
+		flow = *f
+	}
+
+	l.mu.Unlock()
+
+	if !ok || time.Since(flow.at) > proxyAuthFlowTTL {
+		return
+	}
+
+	credentials := "without a Proxy-Authorization header"
+
+	if v := req.Header.Get("Proxy-Authorization"); v != "" {
+		credentials = "with " + strings.Fields(v)[0] + " credentials"
+	}
+
+	p.printf("* retrying after proxy authentication challenge from %s (attempt %d) %s\n",
+		flow.proxy, flow.attempts+1, credentials)
+}
+
+// printProxyAuth prints the proxy authentication challenge of a 407 response, and the outcome of retried requests.
+func (p *printer) printProxyAuth(req *http.Request, resp *http.Response, err error, transport http.RoundTripper) {
+	l := p.logger
+	key := proxyAuthKey(req)
+
+	l.mu.Lock()
+	flow, retried := l.proxyAuth[key]
+
+	if retried && time.Since(flow.at) > proxyAuthFlowTTL {
+		retried = false
+	}
+
+	challenged := resp != nil && resp.StatusCode == http.StatusProxyAuthRequired
+
+	switch {
+	case challenged && !retried:
+		flow = &proxyAuthFlow{proxy: proxyName(req, transport)}
+		l.setProxyAuthFlow(key, flow)
+		fallthrough
+	case challenged:
+		flow.attempts++
+		flow.at = time.Now()
+	case retried:
+		delete(l.proxyAuth, key)
+	}
+
+	var f proxyAuthFlow
+
+	if flow != nil {
+		f = *flow
+	}
+
+	l.mu.Unlock()
+
+	switch {
+	case err != nil && strings.HasSuffix(err.Error(), http.StatusText(http.StatusProxyAuthRequired)):
+		p.printf("* %s\n", p.format(color.FgRed, "proxy authentication required by %s to tunnel (CONNECT) to %s",
+			proxyName(req, transport), req.URL.Host))
+	case challenged && f.attempts == 1:
+		p.printf("* %s\n", p.format(color.FgYellow, "proxy authentication required by %s: %s",
+			f.proxy, strings.Join(proxyChallenges(resp.Header), ", ")))
+	case challenged && isHandshakeChallenge(resp.Header):
+		p.printf("* proxy authentication handshake continues (attempt %d): %s\n",
+			f.attempts, strings.Join(proxyChallenges(resp.Header), ", "))
+	case challenged:
+		p.printf("* %s\n", p.format(color.FgRed, "proxy authentication failed: credentials rejected by %s (attempt %d)",
+			f.proxy, f.attempts))
+	case retried && resp != nil:
+		p.printf("* %s\n", p.format(color.FgGreen, "proxy authentication succeeded (attempt %d)", f.attempts+1))
+	}
+}
+
+// setProxyAuthFlow starts linking a challenge to the requests retried after it,
+// forgetting the expired ones. The logger mutex must be held.
+func (l *Logger) setProxyAuthFlow(key string, flow *proxyAuthFlow) {
+	if l.proxyAuth == nil {
+		l.proxyAuth = map[string]*proxyAuthFlow{}
+	}
+
+	for k, f := range l.proxyAuth {
+		if time.Since(f.at) > proxyAuthFlowTTL {
+			delete(l.proxyAuth, k)
+		}
+	}
+
+	l.proxyAuth[key] = flow
+}
+
+// proxyChallenges returns the authentication schemes and realms offered by the proxy.
+// Tokens of handshakes, such as NTLM and Negotiate, are left out.
+func proxyChallenges(h http.Header) []string {
+	var challenges []string
+
+	for _, v := range h["Proxy-Authenticate"] {
+		fields := strings.Fields(v)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		challenge := fields[0]
+
+		if m := realmRegexp.FindStringSubmatch(v); m != nil {
+			challenge += ` realm="` + m[1] + `"`
+		}
+
+		challenges = append(challenges, challenge)
+	}
+
+	if len(challenges) == 0 {
+		return []string{"no Proxy-Authenticate header"}
+	}
+
+	return challenges
+}
+
+// isHandshakeChallenge checks if the challenge carries a token of a connection-based scheme such as NTLM or Negotiate,
+// meaning the authentication continues rather than failing.
+func isHandshakeChallenge(h http.Header) bool {
+	for _, v := range h["Proxy-Authenticate"] {
+		if fields := strings.Fields(v); len(fields) == 2 && !strings.Contains(strings.TrimRight(fields[1], "="), "=") {
+			return true
+		}
+	}
+
+	return false
+}
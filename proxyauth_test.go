@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingProxyAuthentication(t *testing.T) {
+	t.Parallel()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic Z29waGVyOnNlY3JldA==" {
+			w.Header().Add("Proxy-Authenticate", `Basic realm="corp"`)
+			w.Header().Add("Proxy-Authenticate", "NTLM")
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+
+	if err != nil {
+		t.Fatalf("cannot parse proxy URL: %v", err)
+	}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	transport := newTransport()
+	transport.Proxy = http.ProxyURL(proxyURL)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(transport),
+	}
+
+	for _, credentials := range []string{"", "Basic d3Jvbmc=", "Basic Z29waGVyOnNlY3JldA=="} {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/resource", nil)
+
+		if err != nil {
+			t.Fatalf("cannot create request: %v", err)
+		}
+
+		if credentials != "" {
+			req.Header.Set("Proxy-Authorization", credentials)
+		}
+
+		resp, err := client.Do(req)
+
+		if err != nil {
+			t.Fatalf("cannot connect to the proxy: %v", err)
+		}
+
+		resp.Body.Close()
+	}
+
+	want := `> GET /resource HTTP/1.1
+> Host: example.com
+
+* proxy authentication required by ` + proxyURL.Host + `: Basic realm="corp", NTLM
+> GET /resource HTTP/1.1
+> Host: example.com
+> Proxy-Authorization: Basic ████████████████████
+
+* retrying after proxy authentication challenge from ` + proxyURL.Host + ` (attempt 2) with Basic credentials
+* proxy authentication failed: credentials rejected by ` + proxyURL.Host + ` (attempt 2)
+> GET /resource HTTP/1.1
+> Host: example.com
+> Proxy-Authorization: Basic ████████████████████
+
+* retrying after proxy authentication challenge from ` + proxyURL.Host + ` (attempt 3) with Basic credentials
+* proxy authentication succeeded (attempt 3)
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIsHandshakeChallenge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value string
+		want  bool
+	}{
+		{"NTLM", false},
+		{`Basic realm="corp"`, false},
+		{"NTLM TlRMTVNTUAACAAAADAAMADAAAAA=", true},
+		{"Negotiate YIIFyQYGKwYBBQUCoIIFvTCCBbmgMDAu", true},
+	}
+
+	for _, tc := range testCases {
+		h := http.Header{"Proxy-Authenticate": {tc.value}}
+
+		if got := isHandshakeChallenge(h); got != tc.want {
+			t.Errorf("isHandshakeChallenge(%q) = %v, wanted %v", tc.value, got, tc.want)
+		}
+	}
+
+	if got := strings.Join(proxyChallenges(http.Header{}), ", "); got != "no Proxy-Authenticate header" {
+		t.Errorf("proxyChallenges() of response without challenges = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package httpretty
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitAnnotator is a ResponseAnnotator summarizing the rate limit headers of a response,
+// warning when the limit is nearly exhausted, such as with "* rate limit: 14/100 remaining, resets in 32s".
+// Use it with Logger.SetResponseAnnotator.
+//
+// It understands the X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset headers
+// (with the reset time in seconds or as a Unix timestamp), the RateLimit header fields of the IETF draft,
+// and Retry-After.
+func RateLimitAnnotator(resp *http.Response) []string {
+	return rateLimitLines(resp.Header, time.Now())
+}
+
+// rateLimit of a response. Unknown values are negative.
+type rateLimit struct {
+	limit     int64
+	remaining int64
+	reset     time.Duration
+}
+
+func rateLimitLines(h http.Header, now time.Time) []string {
+	var lines []string
+
+	if rl, ok := parseRateLimit(h, now); ok {
+		lines = append(lines, rl.String())
+	}
+
+	if v := h.Get("Retry-After"); v != "" {
+		lines = append(lines, "retry after "+formatRetryAfter(v, now))
+	}
+
+	return lines
+}
+
+func parseRateLimit(h http.Header, now time.Time) (rateLimit, bool) {
+	rl := rateLimit{
+		limit:     -1,
+		remaining: -1,
+		reset:     -1,
+	}
+
+	// RateLimit: limit=100, remaining=50, reset=5 (draft-ietf-httpapi-ratelimit-headers-05 and earlier)
+	// or RateLimit: "default";r=50;t=5 (later drafts).
+	if v := h.Get("RateLimit"); v != "" {
+		for _, item := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+			kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+
+			if len(kv) != 2 {
+				continue
+			}
+
+			n, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+
+			if err != nil {
+				continue
+			}
+
+			switch strings.ToLower(kv[0]) {
+			case "limit":
+				rl.limit = n
+			case "remaining", "r":
+				rl.remaining = n
+			case "reset", "t":
+				rl.reset = time.Duration(n) * time.Second
+			}
+		}
+	}
+
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if rl.limit < 0 {
+			rl.limit = headerInt(h, prefix+"Limit")
+		}
+
+		if rl.remaining < 0 {
+			rl.remaining = headerInt(h, prefix+"Remaining")
+		}
+
+		if rl.reset < 0 {
+			rl.reset = resetDuration(headerInt(h, prefix+"Reset"), now)
+		}
+	}
+
+	return rl, rl.remaining >= 0
+}
+
+// headerInt parses the header value as an integer, returning -1 if it is missing or invalid.
+func headerInt(h http.Header, key string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(h.Get(key)), 10, 64)
+
+	if err != nil || n < 0 {
+		return -1
+	}
+
+	return n
+}
+
+// minUnixReset distinguishes reset times sent as Unix timestamps (such as by GitHub) from seconds left.
+const minUnixReset = 1000000000
+
+func resetDuration(reset int64, now time.Time) time.Duration {
+	switch {
+	case reset < 0:
+		return -1
+	case reset >= minUnixReset:
+		if d := time.Unix(reset, 0).Sub(now).Round(time.Second); d > 0 {
+			return d
+		}
+
+		return 0
+	default:
+		return time.Duration(reset) * time.Second
+	}
+}
+
+func (rl rateLimit) String() string {
+	s := "rate limit: " + strconv.FormatInt(rl.remaining, 10)
+
+	if rl.limit >= 0 {
+		s += "/" + strconv.FormatInt(rl.limit, 10)
+	}
+
+	s += " remaining"
+
+	if rl.reset >= 0 {
+		s += ", resets in " + rl.reset.String()
+	}
+
+	switch {
+	case rl.remaining == 0:
+		s += " (warning: exhausted)"
+	case rl.limit > 0 && rl.remaining*10 <= rl.limit:
+		s += " (warning: nearly exhausted)"
+	}
+
+	return s
+}
+
+// formatRetryAfter formats the Retry-After header, which is either a number of seconds or a HTTP date.
+func formatRetryAfter(v string, now time.Time) string {
+	if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+		return (time.Duration(n) * time.Second).String()
+	}
+
+	t, err := http.ParseTime(v)
+
+	if err != nil {
+		return fmt.Sprintf("%q (invalid)", v)
+	}
+
+	d := t.Sub(now).Round(time.Second)
+
+	if d < 0 {
+		d = 0
+	}
+
+	return d.String() + " (" + v + ")"
+}
@@ -0,0 +1,149 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRateLimitLines(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		want   []string
+	}{
+		{
+			name:   "none",
+			header: http.Header{},
+		},
+		{
+			name: "x-ratelimit",
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"100"},
+				"X-Ratelimit-Remaining": []string{"14"},
+				"X-Ratelimit-Reset":     []string{"32"},
+			},
+			want: []string{"rate limit: 14/100 remaining, resets in 32s"},
+		},
+		{
+			name: "unix reset",
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"5000"},
+				"X-Ratelimit-Remaining": []string{"4999"},
+				"X-Ratelimit-Reset":     []string{"1588335900"},
+			},
+			want: []string{"rate limit: 4999/5000 remaining, resets in 25m0s"},
+		},
+		{
+			name: "nearly exhausted",
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"100"},
+				"X-Ratelimit-Remaining": []string{"10"},
+			},
+			want: []string{"rate limit: 10/100 remaining (warning: nearly exhausted)"},
+		},
+		{
+			name: "exhausted",
+			header: http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"Retry-After":           []string{"120"},
+			},
+			want: []string{
+				"rate limit: 0 remaining (warning: exhausted)",
+				"retry after 2m0s",
+			},
+		},
+		{
+			name: "draft fields",
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"10"},
+				"Ratelimit-Remaining": []string{"7"},
+				"Ratelimit-Reset":     []string{"5"},
+			},
+			want: []string{"rate limit: 7/10 remaining, resets in 5s"},
+		},
+		{
+			name: "draft structured field",
+			header: http.Header{
+				"Ratelimit": []string{"limit=100, remaining=50, reset=5"},
+			},
+			want: []string{"rate limit: 50/100 remaining, resets in 5s"},
+		},
+		{
+			name: "draft policy",
+			header: http.Header{
+				"Ratelimit":        []string{`"default";r=2;t=30`},
+				"Ratelimit-Policy": []string{`"default";q=100;w=60`},
+			},
+			want: []string{"rate limit: 2 remaining, resets in 30s"},
+		},
+		{
+			name: "retry after date",
+			header: http.Header{
+				"Retry-After": []string{"Fri, 01 May 2020 12:01:30 GMT"},
+			},
+			want: []string{"retry after 1m30s (Fri, 01 May 2020 12:01:30 GMT)"},
+		},
+		{
+			name: "retry after invalid",
+			header: http.Header{
+				"Retry-After": []string{"soon"},
+			},
+			want: []string{`retry after "soon" (invalid)`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := rateLimitLines(tc.header, now); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rateLimitLines() = %q, wanted %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIncomingRateLimitAnnotator(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+	}
+
+	logger.SetResponseAnnotator(RateLimitAnnotator)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := `< HTTP/1.1 429 Too Many Requests
+< Retry-After: 30
+< X-Ratelimit-Limit: 60
+< X-Ratelimit-Remaining: 0
+
+* rate limit: 0/60 remaining (warning: exhausted)
+* retry after 30s
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP response %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package httpretty
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// printReasonPhrase flags when the reason phrase of the status line differs from the standard one.
+// The status line is printed as received, so non-standard reason phrases sent by quirky servers are preserved.
+func (p *printer) printReasonPhrase(statusCode int, status string) {
+	want := http.StatusText(statusCode)
+
+	if want == "" {
+		return
+	}
+
+	reason := strings.TrimPrefix(status, strconv.Itoa(statusCode))
+
+	if reason == status {
+		return // not a status line received from a server, such as on a mocked response.
+	}
+
+	switch reason = strings.TrimSpace(reason); {
+	case reason == want:
+	case reason == "":
+		p.printf("* status line has no reason phrase (standard: %s)\n", want)
+	default:
+		p.printf("* %s\n", p.format(color.FgYellow, "non-standard reason phrase %q (standard: %s)", reason, want))
+	}
+}
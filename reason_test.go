@@ -0,0 +1,37 @@
+package httpretty
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintReasonPhrase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		code   int
+		status string
+		want   string
+	}{
+		{code: 200, status: "200 OK"},
+		{code: 200, status: ""},
+		{code: 599, status: "599 Custom"},
+		{code: 200, status: "200", want: "* status line has no reason phrase (standard: OK)\n"},
+		{code: 200, status: "200 Okie Dokie", want: "* non-standard reason phrase \"Okie Dokie\" (standard: OK)\n"},
+		{code: 404, status: "404 Not Here", want: "* non-standard reason phrase \"Not Here\" (standard: Not Found)\n"},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		p.printReasonPhrase(tc.code, tc.status)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("printReasonPhrase(%d, %q) = %q, wanted %q", tc.code, tc.status, got, tc.want)
+		}
+	}
+}
@@ -2,8 +2,13 @@ package httpretty
 
 import (
 	"bytes"
+	"hash"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 )
 
 type bodyCloser struct {
@@ -31,27 +36,222 @@ type responseRecorder struct {
 
 	statusCode int
 
+	// informational status codes of interim responses sent before the final one, such as 102 Processing.
+	informational []int
+
 	maxReadableBody int64
 	size            int64
 	buf             *bytes.Buffer
+
+	// spillDir for saving a body too long to print to a temporary file.
+	spillDir string
+	spill    *os.File
+	preview  []byte
+
+	// timings records the time spent writing the response, if not nil.
+	timings *serverTimings
+
+	// checksum of the body, if not nil. See Logger.ChecksumLongBodies.
+	checksum hash.Hash
+
+	// keepHead keeps the beginning of a body too long to print on head. See Logger.TruncateLongJSON.
+	keepHead bool
+	head     []byte
+
+	// written bytes accepted by the connection, and the first error writing the response.
+	written  int64
+	writeErr error
+
+	// hijacked is set when the handler takes over the connection.
+	hijacked bool
+
+	// skipBody is set when the body isn't printed. A file is sent with sendfile if possible (sentFile),
+	// or else it is copied through the recorder (copiedFile).
+	skipBody   bool
+	sentFile   bool
+	copiedFile bool
+
+	// startEvents is called once the handler starts writing a stream of server-sent events (eventStream),
+	// returning the parser printing its events as they are written, if they are printed (events).
+	startEvents func() *eventStream
+	detected    bool
+	eventStream bool
+	events      *eventStream
 }
 
 // Write the data to the connection as part of an HTTP reply, and records it.
 func (rr *responseRecorder) Write(p []byte) (int, error) {
+	rr.detectEventStream()
+
+	if rr.eventStream {
+		rr.size += int64(len(p))
+
+		if rr.events != nil {
+			rr.events.write(p)
+		}
+
+		return rr.write(p)
+	}
+
+	// a body that isn't printed isn't recorded.
+	if rr.skipBody {
+		rr.size += int64(len(p))
+		return rr.write(p)
+	}
+
+	// a body declared too long by its Content-Length isn't buffered at all, rather than buffered and discarded later.
+	if rr.size == 0 && rr.spillDir == "" && rr.declaredTooLong() {
+		rr.buf = nil
+	}
+
 	rr.size += int64(len(p))
 
-	if rr.maxReadableBody > 0 && rr.size > rr.maxReadableBody {
+	if rr.checksum != nil {
+		rr.checksum.Write(p)
+	}
+
+	if rr.maxReadableBody > 0 && (rr.size > rr.maxReadableBody || rr.buf == nil) {
+		rr.spillWrite(p)
+		rr.headWrite(p)
 		rr.buf = nil
-		return rr.ResponseWriter.Write(p)
+		return rr.write(p)
 	}
 
 	defer rr.buf.Write(p)
-	return rr.ResponseWriter.Write(p)
+	return rr.write(p)
+}
+
+func (rr *responseRecorder) write(p []byte) (int, error) {
+	if rr.timings != nil {
+		defer rr.timeWriting(time.Now())
+	}
+
+	n, err := rr.ResponseWriter.Write(p)
+	rr.written += int64(n)
+
+	if err != nil && rr.writeErr == nil {
+		rr.writeErr = err
+	}
+
+	return n, err
+}
+
+// declaredTooLong checks if the Content-Length of the response is longer than the body can be to be printed.
+func (rr *responseRecorder) declaredTooLong() bool {
+	if rr.maxReadableBody <= 0 {
+		return false
+	}
+
+	n, err := strconv.ParseInt(rr.Header().Get("Content-Length"), 10, 64)
+	return err == nil && n > rr.maxReadableBody
 }
 
 // WriteHeader sends an HTTP response header with the provided
 // status code, and records it.
 func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.timings != nil {
+		defer rr.timeWriting(time.Now())
+	}
+
 	rr.ResponseWriter.WriteHeader(statusCode)
+
+	if isInformational(statusCode) {
+		rr.informational = append(rr.informational, statusCode)
+		return
+	}
+
 	rr.statusCode = statusCode
+	rr.detectEventStream()
+}
+
+func (rr *responseRecorder) timeWriting(start time.Time) {
+	rr.timings.writing += time.Since(start)
+}
+
+// spillWrite saves the body to a temporary file once it becomes too long to keep in memory.
+func (rr *responseRecorder) spillWrite(p []byte) {
+	if rr.spillDir == "" {
+		return
+	}
+
+	if rr.spill == nil {
+		if rr.buf == nil {
+			return // spilling failed before
+		}
+
+		f, err := ioutil.TempFile(rr.spillDir, spillPattern)
+
+		if err != nil {
+			return
+		}
+
+		rr.spill = f
+		rr.preview = append(rr.preview, rr.buf.Bytes()...)
+		rr.buf.WriteTo(f)
+	}
+
+	if len(rr.preview) < spillPreviewSize {
+		rr.preview = append(rr.preview, p...)
+	}
+
+	if len(rr.preview) > spillPreviewSize {
+		rr.preview = rr.preview[:spillPreviewSize]
+	}
+
+	rr.spill.Write(p)
+}
+
+// headWrite keeps the beginning of a body once it becomes too long to keep in memory.
+func (rr *responseRecorder) headWrite(p []byte) {
+	if !rr.keepHead {
+		return
+	}
+
+	if rr.head == nil && rr.buf != nil {
+		rr.head = append([]byte{}, rr.buf.Bytes()...)
+	}
+
+	if n := rr.maxReadableBody - int64(len(rr.head)); n > 0 {
+		if int64(len(p)) > n {
+			p = p[:n]
+		}
+
+		rr.head = append(rr.head, p...)
+	}
+}
+
+// closeSpill closes the temporary file used to save the body, if any.
+func (rr *responseRecorder) closeSpill() {
+	if rr.spill != nil {
+		rr.spill.Close()
+	}
+}
+
+// CapturedBody is a response body backed by the buffer the logger read it into for printing.
+// See Logger.CapturedResponseBody.
+//
+// 	if cb, ok := resp.Body.(*httpretty.CapturedBody); ok {
+// 		body := cb.Bytes()
+// 	}
+type CapturedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newCapturedBody(data []byte) *CapturedBody {
+	return &CapturedBody{
+		Reader: bytes.NewReader(data),
+		data:   data,
+	}
+}
+
+// Bytes of the whole body, regardless of how much of it was read.
+// The slice should not be modified.
+func (cb *CapturedBody) Bytes() []byte {
+	return cb.data
+}
+
+// Close the body. It is a no-op, as the body is already fully read.
+func (cb *CapturedBody) Close() error {
+	return nil
 }
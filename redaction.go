@@ -0,0 +1,218 @@
+package httpretty
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/header"
+)
+
+// RedactionStyle sets how redacted values are printed.
+type RedactionStyle int
+
+const (
+	// RedactBlocks replaces redacted values with a fixed placeholder, hiding even their length.
+	RedactBlocks RedactionStyle = iota
+
+	// RedactShape masks each character of redacted values, keeping their length and character classes:
+	// digits are replaced by 0, letters by x, and other characters, such as whitespace and punctuation, are kept.
+	// Problems with the format of values, such as an API key of the wrong length or with a trailing space,
+	// remain diagnosable from the logs.
+	RedactShape
+)
+
+// Redaction rules applied to the requests using a context created with WithRedaction,
+// on top of the configuration of the logger, such as for applying the masking policy of a tenant.
+type Redaction struct {
+	// Headers whose values are redacted, in addition to the ones sanitized by default.
+	Headers []string
+
+	// Secrets redacted from the printed bodies and URLs, in addition to Logger.RedactSecrets.
+	Secrets []SecretPattern
+}
+
+type contextRedaction struct{}
+
+// WithRedaction returns a context with redaction rules for the requests using it.
+// Rules already on the context are kept, and the new ones are added to them.
+//
+// The rules are read when the logger receives the request, so for incoming requests
+// they must be set by a middleware running before the logger.
+func WithRedaction(ctx context.Context, r Redaction) context.Context {
+	if prev, ok := ctx.Value(contextRedaction{}).(*Redaction); ok {
+		r.Headers = append(append([]string(nil), prev.Headers...), r.Headers...)
+		r.Secrets = append(append([]SecretPattern(nil), prev.Secrets...), r.Secrets...)
+	}
+
+	return context.WithValue(ctx, contextRedaction{}, &r)
+}
+
+func getRedaction(ctx context.Context) *Redaction {
+	r, _ := ctx.Value(contextRedaction{}).(*Redaction)
+	return r
+}
+
+// redactsHeader checks if the values of a header are redacted.
+func (r *Redaction) redactsHeader(key string) bool {
+	if r == nil {
+		return false
+	}
+
+	key = http.CanonicalHeaderKey(key)
+
+	for _, h := range r.Headers {
+		if http.CanonicalHeaderKey(h) == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sanitizers of the headers containing credentials, for the redaction style of the logger.
+func (l *Logger) sanitizers() map[string]header.SanitizeHeaderFunc {
+	if l.RedactionStyle == RedactShape {
+		return header.ShapeSanitizers
+	}
+
+	return header.DefaultSanitizers
+}
+
+// sanitizer of a header containing credentials, if any. Keys that aren't canonical, such as "authorization",
+// are sanitized too, as they are sent all the same.
+func (l *Logger) sanitizer(key string) header.SanitizeHeaderFunc {
+	return l.sanitizers()[http.CanonicalHeaderKey(key)]
+}
+
+// redact a value with the redaction style of the logger.
+func (l *Logger) redact(v string) string {
+	if l.RedactionStyle == RedactShape {
+		return header.Shape(v)
+	}
+
+	return redactedValue
+}
+
+// secretPatterns returns the patterns of the secrets to redact from the exchange.
+func (p *printer) secretPatterns() []SecretPattern {
+	return secretPatterns(p.logger, p.redaction)
+}
+
+func secretPatterns(l *Logger, r *Redaction) []SecretPattern {
+	if r == nil || len(r.Secrets) == 0 {
+		return l.RedactSecrets
+	}
+
+	return append(append([]SecretPattern(nil), l.RedactSecrets...), r.Secrets...)
+}
+
+// exchangeRedaction redacts an exchange saved outside of the log, such as on a HAR file, as the log does:
+// credentials in headers and URLs are sanitized unless SkipSanitize is set, and the secrets of
+// Logger.RedactSecrets and of the redaction rules on the request context are redacted.
+type exchangeRedaction struct {
+	logger    *Logger
+	redaction *Redaction
+}
+
+// exchangeRedaction of the exchange of the request.
+func (l *Logger) exchangeRedaction(req *http.Request) exchangeRedaction {
+	return exchangeRedaction{
+		logger:    l,
+		redaction: getRedaction(req.Context()),
+	}
+}
+
+// headerValue sanitizes a value of the header with the given key.
+func (er exchangeRedaction) headerValue(key, v string) string {
+	if er.redaction.redactsHeader(key) {
+		return er.logger.redact(v)
+	}
+
+	if er.logger.SkipSanitize {
+		return v
+	}
+
+	if sanitize := er.logger.sanitizer(key); sanitize != nil {
+		return sanitize(v)
+	}
+
+	return v
+}
+
+// header returns a copy of the header with its values sanitized.
+func (er exchangeRedaction) header(h http.Header) http.Header {
+	sanitized := make(http.Header, len(h))
+
+	for key, values := range h {
+		for _, v := range values {
+			sanitized[key] = append(sanitized[key], er.headerValue(key, v))
+		}
+	}
+
+	return sanitized
+}
+
+// redactedURL returns a copy of the URL with the password of its userinfo, the credentials in its query,
+// and the secrets of its query redacted, such as for dumping a request.
+func (er exchangeRedaction) redactedURL(u *url.URL) *url.URL {
+	c := *u
+
+	if u.User != nil && !er.logger.SkipSanitize {
+		if password, ok := u.User.Password(); ok {
+			c.User = url.UserPassword(u.User.Username(), er.logger.redact(password))
+		}
+	}
+
+	if c.RawQuery != "" {
+		c.RawQuery = er.uri("?" + c.RawQuery)[1:]
+	}
+
+	return &c
+}
+
+// url redacts the password of the userinfo, the credentials in the query, and the secrets of a URL.
+func (er exchangeRedaction) url(u *url.URL) string {
+	if er.logger.SkipSanitize {
+		return er.secrets(u.String())
+	}
+
+	return er.uri(sanitizeUserinfo(u, er.logger.redact))
+}
+
+// uri redacts the credentials in the query and the secrets of a URL or request URI.
+func (er exchangeRedaction) uri(s string) string {
+	if !er.logger.SkipSanitize {
+		s = sanitizeQuery(s, er.logger.redact)
+	}
+
+	return er.secrets(s)
+}
+
+// queryValue redacts a value of the query parameter with the given name, as uri does.
+func (er exchangeRedaction) queryValue(name, v string) string {
+	if _, ok := sensitiveQueryParameters[strings.ToLower(name)]; ok && v != "" && !er.logger.SkipSanitize {
+		return er.logger.redact(v)
+	}
+
+	return er.secrets(v)
+}
+
+func (er exchangeRedaction) secrets(s string) string {
+	if patterns := secretPatterns(er.logger, er.redaction); len(patterns) != 0 {
+		b, _ := redactSecrets(patterns, []byte(s), er.logger.redact)
+		s = string(b)
+	}
+
+	return s
+}
+
+// body redacts the secrets of a body.
+func (er exchangeRedaction) body(b []byte) []byte {
+	if patterns := secretPatterns(er.logger, er.redaction); len(patterns) != 0 && len(b) != 0 {
+		b, _ = redactSecrets(patterns, b, er.logger.redact)
+	}
+
+	return b
+}
@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithRedaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := WithRedaction(context.Background(), Redaction{Headers: []string{"X-Tenant-Key"}})
+	ctx = WithRedaction(ctx, Redaction{Headers: []string{"x-session"}})
+
+	r := getRedaction(ctx)
+
+	if !r.redactsHeader("X-Tenant-Key") || !r.redactsHeader("X-Session") || r.redactsHeader("Accept") {
+		t.Errorf("unexpected redaction rules: %+v", r)
+	}
+
+	if getRedaction(context.Background()).redactsHeader("X-Tenant-Key") {
+		t.Errorf("expected no redaction rules on a context without them")
+	}
+}
+
+func TestIncomingWithRedaction(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		RequestBody:     true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	tenant := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Tenant") == "acme" {
+				req = req.WithContext(WithRedaction(req.Context(), Redaction{
+					Headers: []string{"X-Customer-Id"},
+					Secrets: []SecretPattern{{Name: "account number", Regexp: regexp.MustCompile(`\b\d{10}\b`)}},
+				}))
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}(handler)
+
+	for _, name := range []string{"acme", "other"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("account 1234567890"))
+		req.Header.Set("X-Tenant", name)
+		req.Header.Set("X-Customer-Id", "42")
+		tenant.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := `> POST / HTTP/1.1
+> Host: example.com
+> X-Customer-Id: ████████████████████
+> X-Tenant: acme
+
+* 1 secret redacted: account number (1)
+account ████████████████████
+> POST / HTTP/1.1
+> Host: example.com
+> X-Customer-Id: 42
+> X-Tenant: other
+
+account 1234567890
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingRedactShape(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		RequestBody:     true,
+		RedactionStyle:  RedactShape,
+		RedactSecrets:   []SecretPattern{{Name: "account number", Regexp: regexp.MustCompile(`\b\d{10}\b`)}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/?access_token=Ab-12", strings.NewReader("account 1234567890"))
+	req.Header.Set("Authorization", "Bearer sk_Live 42 ")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `> POST /?access_token=xx-00 HTTP/1.1
+> Host: example.com
+> Authorization: Bearer xx_xxxx 00 
+
+* 1 secret redacted: account number (1)
+account 0000000000
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package httpretty
+
+import (
+	"net/http"
+	"strings"
+)
+
+// printRedirectCookies prints which cookies were forwarded, dropped, or added when the client
+// follows a redirect, explaining why cookies such as an authentication one disappear after it.
+// Only the names of the cookies are printed.
+func (p *printer) printRedirectCookies(req *http.Request) {
+	via := req.Response
+
+	if via == nil || via.Request == nil {
+		return
+	}
+
+	prev := via.Request
+	sent := cookieNames(prev.Cookies())
+	current := cookieNames(req.Cookies())
+
+	var forwarded, dropped, added, notSent []string
+
+	for _, name := range sent {
+		if containsString(current, name) {
+			forwarded = append(forwarded, name)
+		} else {
+			dropped = append(dropped, name)
+		}
+	}
+
+	for _, name := range current {
+		if !containsString(sent, name) {
+			added = append(added, name)
+		}
+	}
+
+	for _, name := range cookieNames(via.Cookies()) {
+		if !containsString(current, name) {
+			notSent = append(notSent, name)
+		}
+	}
+
+	if len(forwarded) == 0 && len(dropped) == 0 && len(added) == 0 && len(notSent) == 0 {
+		return
+	}
+
+	from, to := prev.URL.Hostname(), req.URL.Hostname()
+	p.printf("* cookies on redirect from %s to %s:\n", from, to)
+
+	if len(forwarded) != 0 {
+		p.printf("*  forwarded: %s\n", strings.Join(forwarded, ", "))
+	}
+
+	if len(dropped) != 0 {
+		var hint string
+
+		if !isSameOrSubdomain(to, from) {
+			hint = " (the client doesn't forward the Cookie header to other domains)"
+		}
+
+		p.printf("*  dropped: %s%s\n", strings.Join(dropped, ", "), hint)
+	}
+
+	if len(added) != 0 {
+		p.printf("*  added: %s\n", strings.Join(added, ", "))
+	}
+
+	if len(notSent) != 0 {
+		p.printf("*  set by the redirect response, but not sent: %s\n", strings.Join(notSent, ", "))
+	}
+}
+
+// cookieNames without repetitions, in order.
+func cookieNames(cookies []*http.Cookie) []string {
+	var names []string
+
+	for _, c := range cookies {
+		if !containsString(names, c.Name) {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+// isSameOrSubdomain checks if host is domain or one of its subdomains.
+func isSameOrSubdomain(host, domain string) bool {
+	return strings.EqualFold(host, domain) || strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+}
@@ -0,0 +1,94 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingRedirectCookies(t *testing.T) {
+	t.Parallel()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer other.Close()
+
+	// localhost is a different domain than the 127.0.0.1 address of the server.
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "token", Value: "abc"})
+			http.Redirect(w, req, "/home", http.StatusFound)
+		case "/away":
+			http.SetCookie(w, &http.Cookie{Name: "token", Value: "abc"})
+			http.Redirect(w, req, otherURL+"/home", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	jar, err := cookiejar.New(nil)
+
+	if err != nil {
+		t.Fatalf("cannot create cookie jar: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		path   string
+		client *http.Client
+		want   string
+	}{
+		{
+			name:   "same domain",
+			path:   "/login",
+			client: &http.Client{Jar: jar},
+			want: `* cookies on redirect from 127.0.0.1 to 127.0.0.1:
+*  forwarded: session
+*  added: token
+`,
+		},
+		{
+			name:   "other domain",
+			path:   "/away",
+			client: &http.Client{},
+			want: `* cookies on redirect from 127.0.0.1 to localhost:
+*  dropped: session (the client doesn't forward the Cookie header to other domains)
+*  set by the redirect response, but not sent: token
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &Logger{
+				SkipRequestInfo: true,
+				RequestHeader:   true,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			tc.client.Transport = logger.RoundTripper(newTransport())
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tc.path, nil)
+
+			if err != nil {
+				t.Fatalf("cannot create request: %v", err)
+			}
+
+			req.AddCookie(&http.Cookie{Name: "session", Value: "42"})
+
+			if _, err := tc.client.Do(req); err != nil {
+				t.Fatalf("cannot connect to the server: %v", err)
+			}
+
+			if got := buf.String(); !strings.Contains(got, tc.want) {
+				t.Errorf("logged HTTP request %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpretty
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FormatterFactory creates a new formatter registered with RegisterFormatter.
+type FormatterFactory func() Formatter
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]FormatterFactory{}
+)
+
+func init() {
+	RegisterFormatter("form", func() Formatter { return &FormFormatter{} })
+	RegisterFormatter("html", func() Formatter { return &HTMLFormatter{} })
+	RegisterFormatter("json", func() Formatter { return &JSONFormatter{} })
+	RegisterFormatter("ndjson", func() Formatter { return &NDJSONFormatter{} })
+	RegisterFormatter("xml", func() Formatter { return &XMLFormatter{} })
+	RegisterFormatter("yaml", func() Formatter { return &YAMLFormatter{} })
+	RegisterFormatter("oauth2", func() Formatter { return &OAuth2Formatter{} })
+}
+
+// RegisterFormatter makes a formatter available by name, so it can be enabled by configuration with NewFormatters.
+// Packages shipping formatters, such as for internal binary protocols, usually register them on their init function,
+// like database drivers do. The form, html, json, ndjson, oauth2, xml, and yaml formatters are registered by default.
+// If RegisterFormatter is called twice with the same name or if factory is nil, it panics.
+func RegisterFormatter(name string, factory FormatterFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("httpretty: RegisterFormatter factory is nil")
+	}
+
+	if _, dup := registry[name]; dup {
+		panic("httpretty: RegisterFormatter called twice for formatter " + name)
+	}
+
+	registry[name] = factory
+}
+
+// RegisteredFormatters returns a sorted list of the names of the registered formatters.
+func RegisteredFormatters() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredNames()
+}
+
+// registeredNames returns a sorted list of the names of the registered formatters. The mutex must be held.
+func registeredNames() []string {
+	names := make([]string, 0, len(registry))
+
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// NewFormatters creates the registered formatters with the given names, in order, for use as Logger.Formatters.
+// Names can also be given as a comma-separated list, such as "oauth2,json,xml" read from a configuration file.
+func NewFormatters(names ...string) ([]Formatter, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var formatters []Formatter
+
+	for _, list := range names {
+		for _, name := range splitList(list) {
+			factory, ok := registry[name]
+
+			if !ok {
+				return nil, fmt.Errorf("unknown formatter %q (registered: %s)", name, strings.Join(registeredNames(), ", "))
+			}
+
+			formatters = append(formatters, factory())
+		}
+	}
+
+	return formatters, nil
+}
@@ -0,0 +1,82 @@
+package httpretty
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type upperFormatter struct{}
+
+func (u *upperFormatter) Match(mediatype string) bool {
+	return mediatype == "text/x-upper"
+}
+
+func (u *upperFormatter) Format(w io.Writer, src []byte) error {
+	_, err := w.Write(bytes.ToUpper(src))
+	return err
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	t.Parallel()
+
+	RegisterFormatter("test-upper", func() Formatter { return &upperFormatter{} })
+
+	names := strings.Join(RegisteredFormatters(), ",")
+
+	if !strings.Contains(names, "form,html,json,ndjson,oauth2,test-upper,xml,yaml") {
+		t.Errorf("registered formatters = %s, wanted built-in and test formatters", names)
+	}
+
+	formatters, err := NewFormatters("oauth2, test-upper", "json")
+
+	if err != nil {
+		t.Fatalf("cannot create formatters: %v", err)
+	}
+
+	if len(formatters) != 3 {
+		t.Fatalf("got %d formatters, wanted 3", len(formatters))
+	}
+
+	if _, ok := formatters[0].(*OAuth2Formatter); !ok {
+		t.Errorf("formatters[0] = %T, wanted *OAuth2Formatter", formatters[0])
+	}
+
+	if _, ok := formatters[1].(*upperFormatter); !ok {
+		t.Errorf("formatters[1] = %T, wanted *upperFormatter", formatters[1])
+	}
+
+	if _, ok := formatters[2].(*JSONFormatter); !ok {
+		t.Errorf("formatters[2] = %T, wanted *JSONFormatter", formatters[2])
+	}
+
+	// each call creates new formatters, so they aren't shared between loggers.
+	again, _ := NewFormatters("json")
+
+	if again[0] == formatters[2] {
+		t.Error("expected a new formatter to be created")
+	}
+}
+
+func TestNewFormattersUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFormatters("json,protobuf")
+
+	if err == nil || !strings.Contains(err.Error(), `unknown formatter "protobuf" (registered: `) {
+		t.Errorf("expected unknown formatter error, got %v", err)
+	}
+}
+
+func TestRegisterFormatterTwice(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected registering a formatter twice to panic")
+		}
+	}()
+
+	RegisterFormatter("json", func() Formatter { return &JSONFormatter{} })
+}
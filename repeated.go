@@ -0,0 +1,49 @@
+package httpretty
+
+import (
+	"net/http"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// singleValueHeaders are headers expected to appear only once in a message.
+var singleValueHeaders = []string{
+	"Authorization",
+	"Content-Length",
+	"Content-Type",
+	"Host",
+}
+
+// printRepeatedHeaders warns about headers expected to have a single value appearing multiple times.
+// Only the first value is returned by http.Header.Get, so conflicting values are likely a bug.
+func (p *printer) printRepeatedHeaders(h http.Header, skipped map[string]struct{}) {
+	for _, key := range singleValueHeaders {
+		values := h[key]
+
+		if len(values) < 2 {
+			continue
+		}
+
+		if _, skip := skipped[key]; skip {
+			continue
+		}
+
+		if conflicting(values) {
+			p.printf("* %s\n", p.format(color.FgRed,
+				"%s header appears %d times with conflicting values", key, len(values)))
+			continue
+		}
+
+		p.printf("* %s header appears %d times\n", key, len(values))
+	}
+}
+
+func conflicting(values []string) bool {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return true
+		}
+	}
+
+	return false
+}
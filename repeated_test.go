@@ -0,0 +1,39 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestPrintRepeatedHeaders(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	p := newPrinter(logger)
+	p.printHeaders('<', http.Header{
+		"Authorization":  []string{"Bearer a", "Bearer b"},
+		"Content-Length": []string{"5", "5"},
+		"Content-Type":   []string{"text/plain"},
+		"Vary":           []string{"Accept", "Origin"},
+	})
+
+	want := `< Authorization: Bearer ████████████████████
+< Authorization: Bearer ████████████████████
+< Content-Length: 5
+< Content-Length: 5
+< Content-Type: text/plain
+< Vary: Accept
+< Vary: Origin
+* Authorization header appears 2 times with conflicting values
+* Content-Length header appears 2 times
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged headers %s; want %s", got, want)
+	}
+}
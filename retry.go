@@ -0,0 +1,77 @@
+package httpretty
+
+import (
+	"context"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// retryTracer detects when the transport retries a request, such as an idempotent request
+// sent on a keep-alive connection the server closed in the meantime.
+type retryTracer struct {
+	p *printer
+
+	mu       sync.Mutex // WroteRequest is called by the goroutine writing the request
+	attempts int
+	addr     string
+	reused   bool
+	idleTime time.Duration
+	writeErr error
+}
+
+// traceRetries adds a trace to the context printing when the transport retries the request on a new connection.
+func (p *printer) traceRetries(ctx context.Context) context.Context {
+	rt := &retryTracer{p: p}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn:      rt.getConn,
+		GotConn:      rt.gotConn,
+		WroteRequest: rt.wroteRequest,
+	})
+}
+
+func (rt *retryTracer) getConn(hostPort string) {
+	rt.mu.Lock()
+	rt.attempts++
+	attempts, addr, reused, idleTime, writeErr := rt.attempts, rt.addr, rt.reused, rt.idleTime, rt.writeErr
+	rt.addr, rt.reused, rt.idleTime, rt.writeErr = "", false, 0, nil
+	rt.mu.Unlock()
+
+	if attempts == 1 {
+		return
+	}
+
+	p := rt.p
+	p.printf("* %s (attempt %d)\n", p.format(color.FgYellow, "transport retried request on new connection"), attempts)
+
+	switch {
+	case addr != "" && reused:
+		p.printf("*  previous connection: %s, reused after being idle for %v\n", addr, idleTime.Round(time.Millisecond))
+	case addr != "":
+		p.printf("*  previous connection: %s\n", addr)
+	}
+
+	if writeErr != nil {
+		p.printf("*  write error: %v\n", writeErr)
+	}
+}
+
+func (rt *retryTracer) gotConn(info httptrace.GotConnInfo) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if info.Conn != nil && info.Conn.RemoteAddr() != nil {
+		rt.addr = info.Conn.RemoteAddr().String()
+	}
+
+	rt.reused, rt.idleTime = info.Reused, info.IdleTime
+}
+
+func (rt *retryTracer) wroteRequest(info httptrace.WroteRequestInfo) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.writeErr = info.Err
+}
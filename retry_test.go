@@ -0,0 +1,95 @@
+package httpretty
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// closingIdleServer answers the first request of each connection, and closes it when it receives another one,
+// like a server closing a keep-alive connection right when the client reuses it.
+func closingIdleServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+
+				req, err := http.ReadRequest(r)
+
+				if err != nil {
+					return
+				}
+
+				io.Copy(ioutil.Discard, req.Body)
+				fmt.Fprint(conn, "HTTP/1.1 200 OK\r\nContent-Length: 13\r\n\r\nHello, world!")
+
+				// read the next request, but close the connection without answering it.
+				http.ReadRequest(r)
+			}(conn)
+		}
+	}()
+
+	return ln
+}
+
+func TestOutgoingTransportRetry(t *testing.T) {
+	t.Parallel()
+
+	ln := closingIdleServer(t)
+	defer ln.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+	}
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	get := func() {
+		resp, err := client.Get("http://" + ln.Addr().String() + "/")
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		testBody(t, resp.Body, []byte("Hello, world!"))
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	get()
+
+	if got := buf.String(); got != "" {
+		t.Errorf("logged HTTP request %q; want nothing", got)
+	}
+
+	buf.Reset()
+	get()
+
+	got := buf.String()
+	want := "* transport retried request on new connection (attempt 2)\n*  previous connection: " + ln.Addr().String() + ", reused after being idle for "
+
+	if !strings.HasPrefix(got, want) || strings.Count(got, "\n") != 2 {
+		t.Errorf("logged HTTP request %q; want %q…", got, want)
+	}
+}
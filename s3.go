@@ -0,0 +1,284 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Sink uploads bodies too long to print to a bucket of an S3-compatible object storage service,
+// such as Amazon S3 or MinIO, so they can be retrieved later without bloating the logs.
+// Use it with Logger.OffloadBodies.
+//
+// Bodies are uploaded asynchronously once fully read, and the logger prints the URL of the object and a preview
+// of the body right away. Each body is kept in memory until it is uploaded.
+// Call Wait before exiting to make sure pending uploads are done.
+type S3Sink struct {
+	// Endpoint of the service, such as https://s3.us-east-1.amazonaws.com or http://localhost:9000.
+	// Objects are addressed with path-style URLs. It is required.
+	Endpoint string
+
+	// Bucket where the bodies are uploaded to. It is required.
+	Bucket string
+
+	// Prefix of the object keys, such as "webhooks/".
+	Prefix string
+
+	// Region of the bucket used for signing the requests. If value is not set, us-east-1 is used.
+	Region string
+
+	// AccessKeyID and SecretAccessKey are the credentials used for signing the requests with AWS Signature Version 4.
+	// If AccessKeyID is not set, requests aren't signed.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken for temporary credentials.
+	SessionToken string
+
+	// Client used for uploading. If value is not set, http.DefaultClient is used.
+	// Don't use a client logged by the same logger, or the uploads are logged too.
+	Client *http.Client
+
+	wg  sync.WaitGroup
+	now func() time.Time
+}
+
+// Wait for pending uploads.
+func (s *S3Sink) Wait() {
+	s.wg.Wait()
+}
+
+func (s *S3Sink) time() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+
+	return time.Now()
+}
+
+// newObject returns the URL of a new object to upload a body to.
+func (s *S3Sink) newObject() (string, error) {
+	random := make([]byte, 8)
+
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s%s-%x", s.Prefix, s.time().UTC().Format("20060102T150405Z"), random)
+	return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + key, nil
+}
+
+// put uploads a body to the object with the given URL.
+func (s *S3Sink) put(object, contentType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPut, object, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if s.AccessKeyID != "" {
+		s.sign(req, body)
+	}
+
+	client := s.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// sign a request with AWS Signature Version 4, including all of its headers.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+func (s *S3Sink) sign(req *http.Request, body []byte) {
+	t := s.time().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for k := range headers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, k := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", k, strings.TrimSpace(headers[k]))
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	region := s.Region
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	scope := t.Format("20060102") + "/" + region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + s.SecretAccessKey)
+
+	for _, part := range []string{t.Format("20060102"), region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	// url.Values.Encode sorts by key, but escapes spaces as '+' rather than "%20".
+	return strings.Replace(values.Encode(), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// offloadBody uploads a body too long to print to the S3Sink once it is read, printing the object URL and a preview.
+func (p *printer) offloadBody(body io.ReadCloser, contentType string) io.ReadCloser {
+	sink := p.logger.OffloadBodies
+	object, err := sink.newObject()
+
+	if err != nil {
+		p.printf("* body is too long to print, and it cannot be uploaded: %v\n", err)
+		return body
+	}
+
+	preview := make([]byte, spillPreviewSize)
+	n, err := io.ReadFull(body, preview)
+	preview = preview[:n]
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		p.printf("* cannot read body: %v (%d bytes read)\n", err, n)
+	}
+
+	p.printf("* body is too long to print, uploading it to %s\n", object)
+	p.printBodyPreview(preview)
+
+	o := &offloadReadCloser{
+		body:        body,
+		logger:      p.logger,
+		sink:        sink,
+		object:      object,
+		contentType: contentType,
+	}
+
+	o.r = io.TeeReader(io.MultiReader(bytes.NewReader(preview), body), &o.buf)
+	return o
+}
+
+type offloadReadCloser struct {
+	r    io.Reader
+	body io.ReadCloser
+	buf  bytes.Buffer
+
+	logger      *Logger
+	sink        *S3Sink
+	object      string
+	contentType string
+
+	once sync.Once
+}
+
+func (o *offloadReadCloser) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+
+	if err == io.EOF {
+		o.once.Do(o.upload)
+	}
+
+	return n, err
+}
+
+func (o *offloadReadCloser) Close() error {
+	o.once.Do(func() {
+		p := newPrinter(o.logger)
+		p.printf("* body not uploaded to %s: closed before it was fully read\n", o.object)
+		p.flush()
+	})
+
+	return o.body.Close()
+}
+
+func (o *offloadReadCloser) upload() {
+	o.sink.wg.Add(1)
+
+	go func() {
+		defer o.sink.wg.Done()
+
+		err := o.sink.put(o.object, o.contentType, o.buf.Bytes())
+
+		p := newPrinter(o.logger)
+
+		if err != nil {
+			p.printf("* cannot upload body to %s: %v\n", o.object, err)
+		} else {
+			p.printf("* body uploaded to %s (%s)\n", o.object, formatBytes(int64(o.buf.Len())))
+		}
+
+		p.flush()
+	}()
+}
@@ -0,0 +1,162 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// jsonSampler indents a valid JSON value like json.Indent does,
+// but leaves out parts of the document to keep the output readable.
+type jsonSampler struct {
+	// arraySample replaces the middle of arrays longer than twice its value with a count of the elements left out.
+	arraySample int
+
+	// maxDepth replaces arrays and objects nested deeper than its value with a truncation notice.
+	maxDepth int
+}
+
+func (s jsonSampler) format(dst *bytes.Buffer, src []byte, prefix string, depth int) error {
+	src = bytes.TrimSpace(src)
+
+	if len(src) == 0 {
+		return nil
+	}
+
+	if (src[0] == '[' || src[0] == '{') && s.maxDepth > 0 && depth >= s.maxDepth {
+		return s.truncate(dst, src)
+	}
+
+	switch src[0] {
+	case '[':
+		return s.array(dst, src, prefix, depth)
+	case '{':
+		return s.object(dst, src, prefix, depth)
+	default:
+		return json.Compact(dst, src)
+	}
+}
+
+// truncate writes a notice in place of an array or object nested too deep, unless it is empty.
+func (s jsonSampler) truncate(dst *bytes.Buffer, src []byte) error {
+	begin, end := "[", "]"
+
+	if src[0] == '{' {
+		begin, end = "{", "}"
+	}
+
+	if rest := bytes.TrimSpace(src[1:]); len(rest) != 0 && string(rest[0]) == end {
+		dst.WriteString(begin + end)
+		return nil
+	}
+
+	dst.WriteString(begin + " … nested deeper than " + strconv.Itoa(s.maxDepth) + " levels, truncated … " + end)
+	return nil
+}
+
+func (s jsonSampler) array(dst *bytes.Buffer, src []byte, prefix string, depth int) error {
+	var elements []json.RawMessage
+
+	if err := json.Unmarshal(src, &elements); err != nil {
+		return err
+	}
+
+	if len(elements) == 0 {
+		dst.WriteString("[]")
+		return nil
+	}
+
+	k := s.arraySample
+	indent := prefix + "    "
+	dst.WriteString("[\n")
+
+	for i := 0; i < len(elements); i++ {
+		if k > 0 && i == k && len(elements) > 2*k {
+			skipped := len(elements) - 2*k
+			dst.WriteString(indent + "… " + formatCount(skipped) + " more elements …\n")
+			i += skipped - 1
+			continue
+		}
+
+		dst.WriteString(indent)
+
+		if err := s.format(dst, elements[i], indent, depth+1); err != nil {
+			return err
+		}
+
+		if i < len(elements)-1 {
+			dst.WriteByte(',')
+		}
+
+		dst.WriteByte('\n')
+	}
+
+	dst.WriteString(prefix + "]")
+	return nil
+}
+
+func (s jsonSampler) object(dst *bytes.Buffer, src []byte, prefix string, depth int) error {
+	// Decoding tokens to keep the order of the keys.
+	dec := json.NewDecoder(bytes.NewReader(src))
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	if !dec.More() {
+		dst.WriteString("{}")
+		return nil
+	}
+
+	indent := prefix + "    "
+	dst.WriteString("{\n")
+
+	for first := true; dec.More(); first = false {
+		if !first {
+			dst.WriteString(",\n")
+		}
+
+		key, err := dec.Token()
+
+		if err != nil {
+			return err
+		}
+
+		var value json.RawMessage
+
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		dst.WriteString(indent)
+
+		// json.Encoder is used instead of json.Marshal to avoid escaping HTML characters.
+		enc := json.NewEncoder(dst)
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(key); err != nil {
+			return err
+		}
+
+		dst.Truncate(dst.Len() - 1) // removing the newline Encode adds.
+		dst.WriteString(": ")
+
+		if err := s.format(dst, value, indent, depth+1); err != nil {
+			return err
+		}
+	}
+
+	dst.WriteString("\n" + prefix + "}")
+	return nil
+}
+
+// formatCount formats a number with thousands separators, such as 4,982.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+
+	return s
+}
@@ -0,0 +1,107 @@
+package httpretty
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormatterArraySample(t *testing.T) {
+	t.Parallel()
+
+	f := &JSONFormatter{ArraySample: 2}
+
+	var buf bytes.Buffer
+
+	src := `{"b": [1, 2, 3, 4, 5, 6, 7], "a": {"<tag>": [true, null, "x", {}]}, "c": []}`
+
+	if err := f.Format(&buf, []byte(src)); err != nil {
+		t.Errorf("JSONFormatter.Format() error = %v", err)
+	}
+
+	want := `{
+    "b": [
+        1,
+        2,
+        … 3 more elements …
+        6,
+        7
+    ],
+    "a": {
+        "<tag>": [
+            true,
+            null,
+            "x",
+            {}
+        ]
+    },
+    "c": []
+}`
+
+	if got := buf.String(); got != want {
+		t.Errorf("JSONFormatter.Format() = %v, wanted %v", got, want)
+	}
+}
+
+func TestJSONFormatterMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	f := &JSONFormatter{MaxDepth: 2}
+
+	var buf bytes.Buffer
+
+	src := `{"a": {"b": {"c": 1}, "d": [], "e": 2}, "f": [[1], [ ]]}`
+
+	if err := f.Format(&buf, []byte(src)); err != nil {
+		t.Errorf("JSONFormatter.Format() error = %v", err)
+	}
+
+	want := `{
+    "a": {
+        "b": { … nested deeper than 2 levels, truncated … },
+        "d": [],
+        "e": 2
+    },
+    "f": [
+        [ … nested deeper than 2 levels, truncated … ],
+        []
+    ]
+}`
+
+	if got := buf.String(); got != want {
+		t.Errorf("JSONFormatter.Format() = %v, wanted %v", got, want)
+	}
+
+	buf.Reset()
+
+	// adversarial document: nesting is cut short instead of recursing all the way down.
+	deep := strings.Repeat("[", 5000) + strings.Repeat("]", 5000)
+
+	if err := f.Format(&buf, []byte(deep)); err != nil {
+		t.Errorf("JSONFormatter.Format() error = %v", err)
+	}
+
+	if want := "[\n    [\n        [ … nested deeper than 2 levels, truncated … ]\n    ]\n]"; buf.String() != want {
+		t.Errorf("JSONFormatter.Format() = %v, wanted %v", buf.String(), want)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "0"},
+		{n: 999, want: "999"},
+		{n: 4982, want: "4,982"},
+		{n: 1234567, want: "1,234,567"},
+	}
+
+	for _, tc := range testCases {
+		if got := formatCount(tc.n); got != tc.want {
+			t.Errorf("formatCount(%d) = %v, wanted %v", tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package httpretty
+
+import (
+	"net/url"
+	"strings"
+)
+
+// sensitiveQueryParameters are the names of query parameters commonly carrying credentials, in lowercase.
+var sensitiveQueryParameters = map[string]struct{}{
+	"access_token":         {},
+	"api_key":              {},
+	"apikey":               {},
+	"client_secret":        {},
+	"code":                 {},
+	"id_token":             {},
+	"key":                  {},
+	"password":             {},
+	"refresh_token":        {},
+	"secret":               {},
+	"sig":                  {},
+	"signature":            {},
+	"token":                {},
+	"x-amz-credential":     {},
+	"x-amz-security-token": {},
+	"x-amz-signature":      {},
+	"x-goog-credential":    {},
+	"x-goog-signature":     {},
+}
+
+const redactedValue = "████████████████████"
+
+// maskURI prepares a request URI (with or without a query) for printing: the path is masked (see Logger.PathPatterns),
+// and credentials in the query and secrets (see Logger.RedactSecrets) are redacted.
+func (p *printer) maskURI(uri string) string {
+	return p.redactURL(p.maskPath(uri))
+}
+
+// redactURL redacts credentials in the query and secrets (see Logger.RedactSecrets) of a URL or request URI.
+func (p *printer) redactURL(s string) string {
+	if !p.logger.SkipSanitize {
+		s = sanitizeQuery(s, p.logger.redact)
+	}
+
+	if patterns := p.secretPatterns(); len(patterns) != 0 {
+		b, _ := redactSecrets(patterns, []byte(s), p.logger.redact)
+		s = string(b)
+	}
+
+	return s
+}
+
+// sanitizeQuery redacts the values of sensitive query parameters, keeping the rest of the URL as it is.
+func sanitizeQuery(s string, redact func(string) string) string {
+	i := strings.IndexByte(s, '?')
+
+	if i == -1 {
+		return s
+	}
+
+	query, fragment := s[i+1:], ""
+
+	if j := strings.IndexByte(query, '#'); j != -1 {
+		query, fragment = query[:j], query[j:]
+	}
+
+	params := strings.Split(query, "&")
+
+	for k, param := range params {
+		kv := strings.SplitN(param, "=", 2)
+
+		if len(kv) != 2 || kv[1] == "" {
+			continue
+		}
+
+		name, err := url.QueryUnescape(kv[0])
+
+		if err != nil {
+			name = kv[0]
+		}
+
+		if _, ok := sensitiveQueryParameters[strings.ToLower(name)]; ok {
+			params[k] = kv[0] + "=" + redact(kv[1])
+		}
+	}
+
+	return s[:i+1] + strings.Join(params, "&") + fragment
+}
+
+// sanitizeUserinfo returns the URL with the password of its userinfo redacted.
+func sanitizeUserinfo(u *url.URL, redact func(string) string) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	password, ok := u.User.Password()
+
+	if !ok {
+		return u.String()
+	}
+
+	c := *u
+	c.User = url.User(u.User.Username())
+	user := c.User.String()
+	return strings.Replace(c.String(), user+"@", user+":"+redact(password)+"@", 1)
+}
@@ -20,3 +20,6 @@ gosec -quiet ./...
 
 echo "Running tests with data race detector"
 go test ./... -race
+
+echo "Running tests with optional Zstandard support"
+go test ./... -race -tags zstd
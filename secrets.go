@@ -0,0 +1,135 @@
+package httpretty
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SecretPattern matches a kind of secret to redact from the printed bodies.
+type SecretPattern struct {
+	// Name of the kind of secret, printed with the number of redactions.
+	Name string
+
+	// Regexp matching the secret. If it has a capturing group, only the text matched by the first group is redacted,
+	// which allows matching secrets by their context, such as the name of a field.
+	Regexp *regexp.Regexp
+}
+
+// Built-in secret patterns to use with Logger.RedactSecrets.
+var (
+	// AWSKeys matches AWS access key IDs, and secret access keys following their usual names.
+	AWSKeys = []SecretPattern{
+		{
+			Name:   "AWS access key ID",
+			Regexp: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+		},
+		{
+			Name:   "AWS secret access key",
+			Regexp: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		},
+	}
+
+	// GitHubTokens matches GitHub personal access, OAuth, user-to-server, server-to-server, and refresh tokens.
+	GitHubTokens = []SecretPattern{
+		{
+			Name:   "GitHub token",
+			Regexp: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,255}\b`),
+		},
+		{
+			Name:   "GitHub token",
+			Regexp: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{82}\b`),
+		},
+	}
+
+	// SlackWebhooks matches Slack incoming webhook and workflow URLs.
+	SlackWebhooks = []SecretPattern{
+		{
+			Name:   "Slack webhook",
+			Regexp: regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows|triggers)/[A-Za-z0-9+/_-]+`),
+		},
+	}
+
+	// PrivateKeys matches PEM blocks of private keys, including when they are escaped in a JSON string.
+	PrivateKeys = []SecretPattern{
+		{
+			Name:   "private key",
+			Regexp: regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY-----[\s\S]*?-----END[ A-Z0-9]*PRIVATE KEY-----`),
+		},
+	}
+)
+
+const redactedSecret = "████████████████████"
+
+// redactSecrets replaces the secrets in the body, returning a copy of it and the number of redactions of each kind.
+func redactSecrets(patterns []SecretPattern, body []byte, redact func(string) string) ([]byte, []secretCount) {
+	var counts []secretCount
+
+	for _, sp := range patterns {
+		var (
+			redacted []byte
+			last     int
+			n        int
+		)
+
+		for _, m := range sp.Regexp.FindAllSubmatchIndex(body, -1) {
+			start, end := m[0], m[1]
+
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+
+			redacted = append(redacted, body[last:start]...)
+			redacted = append(redacted, redact(string(body[start:end]))...)
+			last = end
+			n++
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		body = append(redacted, body[last:]...)
+		counts = addSecretCount(counts, sp.Name, n)
+	}
+
+	return body, counts
+}
+
+type secretCount struct {
+	name string
+	n    int
+}
+
+// addSecretCount adds to the count of a kind of secret, keeping the order they are found.
+func addSecretCount(counts []secretCount, name string, n int) []secretCount {
+	for i := range counts {
+		if counts[i].name == name {
+			counts[i].n += n
+			return counts
+		}
+	}
+
+	return append(counts, secretCount{name: name, n: n})
+}
+
+// printRedactedSecrets prints how many secrets were redacted from a body.
+func (p *printer) printRedactedSecrets(counts []secretCount) {
+	var (
+		total int
+		kinds []string
+	)
+
+	for _, c := range counts {
+		total += c.n
+		kinds = append(kinds, fmt.Sprintf("%s (%d)", c.name, c.n))
+	}
+
+	secrets := "secrets"
+
+	if total == 1 {
+		secrets = "secret"
+	}
+
+	p.printf("* %d %s redacted: %s\n", total, secrets, strings.Join(kinds, ", "))
+}
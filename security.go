@@ -0,0 +1,150 @@
+package httpretty
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// minHSTSMaxAge is the minimum max-age of the Strict-Transport-Security header considered safe (180 days).
+const minHSTSMaxAge = 15552000
+
+// securityCheck evaluates a security header, returning the problem found, or an empty string if it passes.
+type securityCheck struct {
+	header string
+	check  func(v string) (problem string)
+}
+
+var securityChecks = []securityCheck{
+	{"Strict-Transport-Security", checkHSTS},
+	{"Content-Security-Policy", checkCSP},
+	{"X-Content-Type-Options", checkContentTypeOptions},
+	{"Referrer-Policy", checkReferrerPolicy},
+}
+
+// printSecurityHeaders prints a scorecard of the security headers of a response.
+// Strict-Transport-Security is only checked for responses sent over TLS, as browsers ignore it otherwise.
+func (p *printer) printSecurityHeaders(state *tls.ConnectionState, h http.Header) {
+	var (
+		problems []string
+		total    int
+	)
+
+	for _, sc := range securityChecks {
+		if sc.header == "Strict-Transport-Security" && state == nil {
+			continue
+		}
+
+		total++
+		v := h.Get(sc.header)
+
+		if v == "" && sc.header == "Content-Security-Policy" && h.Get("Content-Security-Policy-Report-Only") != "" {
+			problems = append(problems, sc.header+": only reported (Content-Security-Policy-Report-Only)")
+			continue
+		}
+
+		if v == "" {
+			problems = append(problems, sc.header+": missing")
+			continue
+		}
+
+		if problem := sc.check(v); problem != "" {
+			problems = append(problems, sc.header+": "+problem)
+		}
+	}
+
+	score := p.format(color.FgGreen, "%d/%d", total-len(problems), total)
+
+	if len(problems) != 0 {
+		score = p.format(color.FgYellow, "%d/%d", total-len(problems), total)
+	}
+
+	p.printf("* security headers: %s passed\n", score)
+
+	for _, problem := range problems {
+		p.printf("*  %s\n", problem)
+	}
+}
+
+func checkHSTS(v string) string {
+	for _, directive := range strings.Split(v, ";") {
+		name, value := splitDirective(directive)
+
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+
+		maxAge, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+
+		switch {
+		case err != nil:
+			return "invalid max-age"
+		case maxAge < minHSTSMaxAge:
+			return "max-age shorter than 180 days"
+		default:
+			return ""
+		}
+	}
+
+	return "max-age is missing"
+}
+
+func checkCSP(v string) string {
+	for _, directive := range strings.Split(v, ";") {
+		name, value := splitDirective(directive)
+
+		if !strings.EqualFold(name, "script-src") && !strings.EqualFold(name, "default-src") {
+			continue
+		}
+
+		for _, source := range []string{"'unsafe-inline'", "'unsafe-eval'"} {
+			if strings.Contains(strings.ToLower(value), source) {
+				return name + " allows " + source
+			}
+		}
+	}
+
+	return ""
+}
+
+func checkContentTypeOptions(v string) string {
+	if !strings.EqualFold(strings.TrimSpace(v), "nosniff") {
+		return "should be nosniff"
+	}
+
+	return ""
+}
+
+func checkReferrerPolicy(v string) string {
+	// browsers use the last policy they support, so older policies can be listed first as a fallback.
+	policies := splitList(v)
+
+	if len(policies) == 0 {
+		return "missing"
+	}
+
+	policy := strings.ToLower(policies[len(policies)-1])
+
+	switch policy {
+	case "unsafe-url":
+		return "unsafe-url leaks the full URL to other origins"
+	case "no-referrer-when-downgrade":
+		return "no-referrer-when-downgrade leaks the full URL to other origins over HTTPS"
+	default:
+		return ""
+	}
+}
+
+// splitDirective splits a directive, such as "max-age=31536000" or "script-src 'self'", into its name and value.
+func splitDirective(directive string) (name, value string) {
+	directive = strings.TrimSpace(directive)
+
+	if i := strings.IndexAny(directive, "= "); i != -1 {
+		return directive[:i], strings.TrimSpace(directive[i+1:])
+	}
+
+	return directive, ""
+}
@@ -0,0 +1,119 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrintSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		tls    bool
+		header http.Header
+		want   string
+	}{
+		{
+			name: "all passed",
+			tls:  true,
+			header: http.Header{
+				"Strict-Transport-Security": []string{"max-age=63072000; includeSubDomains; preload"},
+				"Content-Security-Policy":   []string{"default-src 'self'; img-src *"},
+				"X-Content-Type-Options":    []string{"nosniff"},
+				"Referrer-Policy":           []string{"no-referrer, strict-origin-when-cross-origin"},
+			},
+			want: "* security headers: 4/4 passed\n",
+		},
+		{
+			name:   "plain HTTP",
+			header: http.Header{},
+			want: `* security headers: 0/3 passed
+*  Content-Security-Policy: missing
+*  X-Content-Type-Options: missing
+*  Referrer-Policy: missing
+`,
+		},
+		{
+			name: "weak",
+			tls:  true,
+			header: http.Header{
+				"Strict-Transport-Security":           []string{"max-age=3600"},
+				"Content-Security-Policy-Report-Only": []string{"default-src 'self'"},
+				"X-Content-Type-Options":              []string{"sniff"},
+				"Referrer-Policy":                     []string{"unsafe-url"},
+			},
+			want: `* security headers: 0/4 passed
+*  Strict-Transport-Security: max-age shorter than 180 days
+*  Content-Security-Policy: only reported (Content-Security-Policy-Report-Only)
+*  X-Content-Type-Options: should be nosniff
+*  Referrer-Policy: unsafe-url leaks the full URL to other origins
+`,
+		},
+		{
+			name: "unsafe",
+			tls:  true,
+			header: http.Header{
+				"Strict-Transport-Security": []string{"includeSubDomains"},
+				"Content-Security-Policy":   []string{"default-src 'self'; script-src 'self' 'unsafe-inline'"},
+				"X-Content-Type-Options":    []string{"NoSniff"},
+				"Referrer-Policy":           []string{"same-origin"},
+			},
+			want: `* security headers: 2/4 passed
+*  Strict-Transport-Security: max-age is missing
+*  Content-Security-Policy: script-src allows 'unsafe-inline'
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		var state *tls.ConnectionState
+
+		if tc.tls {
+			state = &tls.ConnectionState{}
+		}
+
+		p := newPrinter(logger)
+		p.printSecurityHeaders(state, tc.header)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: logged %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIncomingAuditSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo:      true,
+		AuditSecurityHeaders: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer-when-downgrade")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	want := `* security headers: 1/3 passed
+*  Content-Security-Policy: missing
+*  Referrer-Policy: no-referrer-when-downgrade leaks the full URL to other origins over HTTPS
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+package httpretty
+
+// nextSequence numbers the exchange, if Logger.SequenceNumbers is set.
+func (p *printer) nextSequence() {
+	if !p.logger.SequenceNumbers {
+		return
+	}
+
+	p.logger.mu.Lock()
+	defer p.logger.mu.Unlock()
+	p.logger.sequence++
+	p.sequence = p.logger.sequence
+}
+
+func (p *printer) printSequence() {
+	if p.sequence != 0 {
+		p.printf("* Exchange #%d\n", p.sequence)
+	}
+}
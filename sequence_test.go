@@ -0,0 +1,91 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestIncomingSequenceNumbers(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		SequenceNumbers: true,
+	}
+
+	logger.SetFilter(func(req *http.Request) (bool, error) {
+		return req.URL.Path == "/skip", nil
+	})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	handler := logger.Middleware(&helloHandler{})
+
+	for _, path := range []string{"/", "/skip", "/", "/"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil))
+	}
+
+	want := "* Exchange #1\n* Exchange #2\n* Exchange #3\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestOutgoingSequenceNumbersLogfmt(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&helloHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		Logfmt:          true,
+		SequenceNumbers: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		resp.Body.Close()
+	}
+
+	want := regexp.MustCompile(`^seq=1 method=GET .*\nseq=2 method=GET .*\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
+
+func TestIncomingSequenceNumbersEvent(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SequenceNumbers: true,
+	}
+
+	logger.SetEncoder(&JSONEncoder{})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	logger.Middleware(&helloHandler{}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	if event := decodeEvent(t, buf.Bytes()); event["seq"] != float64(1) {
+		t.Errorf("event seq = %v, wanted 1", event["seq"])
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
@@ -230,6 +231,72 @@ Hello, world!`, ts.Listener.Addr())
 	}
 }
 
+func TestIncomingStreamFlusher(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	logger.SetFlusher(OnEnd)
+	logger.SetStreamFlusher(NoBuffer)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	var printed []string
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// the request is already printed while the handler is still streaming events.
+		printed = append(printed, buf.String())
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: hello\n\n")
+	}))
+
+	for _, accept := range []string{"text/event-stream", "text/plain"} {
+		is := inspect(handler, 1)
+		ts := httptest.NewServer(is)
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+		if err != nil {
+			t.Fatalf("cannot create request: %v", err)
+		}
+
+		req.Header.Set("Accept", accept)
+
+		client := &http.Client{
+			Transport: newTransport(),
+		}
+
+		if _, err := client.Do(req); err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		is.Wait()
+		ts.Close()
+	}
+
+	if len(printed) != 2 {
+		t.Fatalf("handler called %d times, wanted 2", len(printed))
+	}
+
+	if !strings.Contains(printed[0], "> Accept: text/event-stream") {
+		t.Errorf("streaming request should be printed before the handler runs, got %q", printed[0])
+	}
+
+	if strings.Contains(printed[1], "> Accept: text/plain") {
+		t.Errorf("request should be buffered until the end, got %q", printed[1])
+	}
+
+	if got := buf.String(); !strings.Contains(got, "data: hello") || !strings.Contains(got, "> Accept: text/plain") {
+		t.Errorf("missing exchanges on the output: %s", got)
+	}
+}
+
 func TestIncomingMinimal(t *testing.T) {
 	t.Parallel()
 
@@ -785,6 +852,58 @@ func TestIncomingWithTimeRequest(t *testing.T) {
 	}
 }
 
+func TestIncomingServerTimings(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		ServerTimings:  true,
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(helloHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	go func() {
+		client := &http.Client{
+			Transport: newTransport(),
+		}
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("Hello, server!"))
+
+		if err != nil {
+			t.Errorf("cannot create request: %v", err)
+		}
+
+		_, err = client.Do(req)
+
+		if err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+	}()
+
+	is.Wait()
+
+	got := buf.String()
+
+	if !strings.Contains(got, "* Server timings: reading request body ") ||
+		!strings.Contains(got, ", handler ") ||
+		!strings.Contains(got, ", writing response ") {
+		t.Errorf("missing printing server timings: %s", got)
+	}
+
+	if want := "Hello, world!\n* Server timings: "; !strings.Contains(got, want) {
+		t.Errorf("server timings should be printed after the response body: %s", got)
+	}
+}
+
 func TestIncomingFormattedJSON(t *testing.T) {
 	t.Parallel()
 
@@ -1214,10 +1333,10 @@ func TestIncomingBinaryBodyNoMediatypeHeader(t *testing.T) {
 > Content-Length: 14
 > User-Agent: Go-http-client/1.1
 
-* body contains binary data
+* body appears to be image/webp (14 B)
 < HTTP/1.1 200 OK
 
-* body contains binary data
+* body appears to be application/pdf (16 B)
 `, uri, is.req.RemoteAddr, ts.Listener.Addr())
 
 	if got := buf.String(); got != want {
@@ -1581,8 +1700,8 @@ func TestIncomingMultipartForm(t *testing.T) {
 	t.Parallel()
 
 	logger := &Logger{
-		RequestHeader: true,
-		// TODO(henvic): print request body once support for printing out multipart/formdata body is added.
+		RequestHeader:  true,
+		RequestBody:    true,
 		ResponseHeader: true,
 		ResponseBody:   true,
 	}
@@ -1634,10 +1753,20 @@ func TestIncomingMultipartForm(t *testing.T) {
 > Content-Type: %s
 > User-Agent: Go-http-client/1.1
 
+* part 1: field "author" (18 B)
+*  Content-Disposition: form-data; name="author"
+Frédéric Bastiat
+* part 2: field "title" (22 B)
+*  Content-Disposition: form-data; name="title"
+Candlemakers' Petition
+* part 3: field "file", file "petition", application/octet-stream (9.6 KiB)
+*  Content-Disposition: form-data; name="file"; filename="petition"
+*  Content-Type: application/octet-stream
+%s
 < HTTP/1.1 200 OK
 
 upload received
-`, uri, is.req.RemoteAddr, ts.Listener.Addr(), writer.FormDataContentType())
+`, uri, is.req.RemoteAddr, ts.Listener.Addr(), writer.FormDataContentType(), petition)
 
 	if got := buf.String(); got != want {
 		t.Errorf("logged HTTP request %s; want %s", got, want)
@@ -1989,3 +2118,522 @@ func newServerClient() *http.Client {
 		Transport: newTransport(),
 	}
 }
+
+func TestIncomingMethods(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		RequestBody:    true,
+		ResponseHeader: true,
+		ResponseBody:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.Methods([]string{http.MethodPost})
+
+	is := inspect(logger.Middleware(helloHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+	uri := fmt.Sprintf("%s/incoming", ts.URL)
+
+	go func() {
+		client := newServerClient()
+
+		if _, err := client.Get(uri); err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+	}()
+
+	is.Wait()
+
+	want := fmt.Sprintf(`* Request to %s
+* Request from %s
+`, uri, is.req.RemoteAddr)
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingHandlerOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		verbosity Verbosity
+		want      string
+	}{
+		{
+			name: "default",
+			want: `* Request to %s
+* Request from %s
+* Route: hello
+* env: test
+* team: gophers
+> GET /handler HTTP/1.1
+> Host: %s
+> Accept-Encoding: gzip
+> User-Agent: Go-http-client/1.1
+
+< HTTP/1.1 200 OK
+
+Hello, world!
+`,
+		},
+		{
+			name:      "headers",
+			verbosity: VerbosityHeaders,
+			want: `* Request to %s
+* Request from %s
+* Route: hello
+* env: test
+* team: gophers
+> GET /handler HTTP/1.1
+> Host: %s
+> Accept-Encoding: gzip
+> User-Agent: Go-http-client/1.1
+
+< HTTP/1.1 200 OK
+
+`,
+		},
+		{
+			name:      "summary",
+			verbosity: VerbositySummary,
+			want: `* Request to %s
+* Request from %s
+* Route: hello
+* env: test
+* team: gophers
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &Logger{
+				RequestHeader:  true,
+				RequestBody:    true,
+				ResponseHeader: true,
+				ResponseBody:   true,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			is := inspect(logger.Handler(helloHandler{},
+				WithRoute("hello"),
+				WithFields(map[string]string{"team": "gophers"}),
+				WithFields(map[string]string{"env": "test"}),
+				WithVerbosity(tc.verbosity),
+			), 1)
+
+			ts := httptest.NewServer(is)
+			defer ts.Close()
+			uri := fmt.Sprintf("%s/handler", ts.URL)
+
+			go func() {
+				client := newServerClient()
+
+				resp, err := client.Get(uri)
+
+				if err != nil {
+					t.Errorf("cannot connect to the server: %v", err)
+				}
+
+				testBody(t, resp.Body, []byte("Hello, world!"))
+			}()
+
+			is.Wait()
+
+			want := fmt.Sprintf(tc.want, uri, is.req.RemoteAddr, ts.Listener.Addr())
+
+			if tc.verbosity == VerbositySummary {
+				want = fmt.Sprintf(tc.want, uri, is.req.RemoteAddr)
+			}
+
+			if got := buf.String(); got != want {
+				t.Errorf("logged HTTP request %s; want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestIncomingNormalizedRequestURI(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(helloHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	go func() {
+		client := newServerClient()
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/a//b/../%7Ec/?q=1", nil)
+
+		if err != nil {
+			t.Errorf("cannot create request: %v", err)
+		}
+
+		if _, err := client.Do(req); err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+	}()
+
+	is.Wait()
+
+	want := fmt.Sprintf(`> GET /a//b/../%%7Ec/?q=1 HTTP/1.1
+* request URI differs from its normalized form
+*  normalized: /a/~c/?q=1
+> Host: %s
+> Accept-Encoding: gzip
+> User-Agent: Go-http-client/1.1
+
+`, ts.Listener.Addr())
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+type annotateHandler struct{}
+
+func (h annotateHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	Annotate(req.Context(), "cache", "hit")
+	Annotate(req.Context(), "user", "gopher")
+	Annotate(req.Context(), "cache", "miss")
+	w.Header()["Date"] = nil
+	fmt.Fprint(w, "annotated")
+}
+
+func TestIncomingAnnotate(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(annotateHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	go func() {
+		client := newServerClient()
+
+		if _, err := client.Get(ts.URL); err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+	}()
+
+	is.Wait()
+
+	want := `annotated
+* note cache=miss
+* note user=gopher
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+type etagHandler struct{}
+
+func (h etagHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header()["Date"] = nil
+	w.Header().Set("ETag", `"v1"`)
+
+	if req.Header.Get("If-None-Match") == `"v1"` {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	fmt.Fprint(w, "fresh")
+}
+
+func TestIncomingConditional(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		etag string
+		want string
+	}{
+		{
+			etag: `"v1"`,
+			want: `* conditional request (If-None-Match): validator matched, served 304
+< HTTP/1.1 304 Not Modified
+< Etag: "v1"
+
+`,
+		},
+		{
+			etag: `"v0"`,
+			want: `* conditional request (If-None-Match): validator did not match, served 200
+< HTTP/1.1 200 OK
+< Etag: "v1"
+
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.etag, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &Logger{
+				SkipRequestInfo: true,
+				ResponseHeader:  true,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			is := inspect(logger.Middleware(etagHandler{}), 1)
+
+			ts := httptest.NewServer(is)
+			defer ts.Close()
+
+			go func() {
+				client := newServerClient()
+
+				req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+
+				if err != nil {
+					t.Errorf("cannot create request: %v", err)
+				}
+
+				req.Header.Set("If-None-Match", tc.etag)
+
+				if _, err := client.Do(req); err != nil {
+					t.Errorf("cannot connect to the server: %v", err)
+				}
+			}()
+
+			is.Wait()
+
+			if got := buf.String(); got != tc.want {
+				t.Errorf("logged HTTP request %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+type corsHandler struct{}
+
+func (h corsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header()["Date"] = nil
+	w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestIncomingCORSPreflight(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	is := inspect(logger.Middleware(corsHandler{}), 1)
+
+	ts := httptest.NewServer(is)
+	defer ts.Close()
+
+	go func() {
+		client := newServerClient()
+
+		req, err := http.NewRequest(http.MethodOptions, ts.URL+"/api", nil)
+
+		if err != nil {
+			t.Errorf("cannot create request: %v", err)
+		}
+
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		req.Header.Set("Access-Control-Request-Headers", "content-type, x-token")
+
+		if _, err := client.Do(req); err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+	}()
+
+	is.Wait()
+
+	want := `* CORS preflight from https://app.example.com
+*  requested method: PUT
+*  requested headers: content-type, x-token
+*  allowed origin: https://app.example.com
+*  allowed methods: GET, PUT
+*  allowed headers: Content-Type
+*  max age: 600
+*  denied: header x-token not allowed
+< HTTP/1.1 204 No Content
+< Access-Control-Allow-Headers: Content-Type
+< Access-Control-Allow-Methods: GET, PUT
+< Access-Control-Allow-Origin: https://app.example.com
+< Access-Control-Max-Age: 600
+
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingMountedPaths(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		mounted bool
+		want    string
+	}{
+		{
+			name: "default",
+			want: `* Request to http://%[1]s/users?page=2
+* Request from %[2]s
+> GET /users?page=2 HTTP/1.1
+> Host: %[1]s
+
+`,
+		},
+		{
+			name:    "mounted",
+			mounted: true,
+			want: `* Request to http://%[1]s/api/users?page=2
+* Handler path: /users?page=2
+* Request from %[2]s
+> GET /users?page=2 HTTP/1.1
+> Host: %[1]s
+
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &Logger{
+				MountedPaths:  tc.mounted,
+				RequestHeader: true,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+			logger.SkipHeader([]string{"Accept-Encoding", "User-Agent"})
+
+			is := inspect(http.StripPrefix("/api", logger.Middleware(helloHandler{})), 1)
+
+			ts := httptest.NewServer(is)
+			defer ts.Close()
+
+			go func() {
+				client := newServerClient()
+
+				if _, err := client.Get(ts.URL + "/api/users?page=2"); err != nil {
+					t.Errorf("cannot connect to the server: %v", err)
+				}
+			}()
+
+			is.Wait()
+
+			want := fmt.Sprintf(tc.want, is.req.Host, is.req.RemoteAddr)
+
+			if got := buf.String(); got != want {
+				t.Errorf("logged HTTP request %s; want %s", got, want)
+			}
+		})
+	}
+}
+
+type principalHandler struct{}
+
+func (h principalHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if user, _, ok := req.BasicAuth(); ok {
+		SetPrincipal(req.Context(), "user:"+user)
+	}
+
+	w.Header()["Date"] = nil
+	fmt.Fprint(w, "Hello, world!")
+}
+
+func TestIncomingPrincipal(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestHeader:   true,
+		ResponseHeader:  true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("gopher", "secret")
+	logger.Middleware(principalHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `> GET / HTTP/1.1
+> Host: example.com
+> Authorization: Basic ████████████████████
+
+* principal: user:gopher
+< HTTP/1.1 200 OK
+< Content-Type: text/plain; charset=utf-8
+
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingLogfmtPrincipal(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		Logfmt: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("gopher", "secret")
+	logger.Middleware(principalHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	want := regexp.MustCompile(`^method=GET host=example\.com path=/ principal=user:gopher status=200 dur=\S+ req_bytes=0 resp_bytes=13\n$`)
+
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("logged HTTP request %q; want %v", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package httpretty
+
+import (
+	"io"
+	"time"
+)
+
+// serverTimings records where the time handling a server request goes.
+type serverTimings struct {
+	start time.Time
+	end   time.Time
+
+	reading time.Duration
+	writing time.Duration
+}
+
+// timedBody is a request body recording the time spent reading it.
+type timedBody struct {
+	io.ReadCloser
+	timings *serverTimings
+}
+
+func (tb *timedBody) Read(p []byte) (int, error) {
+	start := time.Now()
+	defer func() {
+		tb.timings.reading += time.Since(start)
+	}()
+
+	return tb.ReadCloser.Read(p)
+}
+
+func (t *serverTimings) stop() {
+	t.end = time.Now()
+}
+
+// printServerTimings prints how long was spent reading the request body, running the handler, and writing the response.
+func (p *printer) printServerTimings(t *serverTimings) {
+	handler := t.end.Sub(t.start) - t.reading - t.writing
+
+	p.printf("* Server timings: reading request body %v, handler %v, writing response %v\n",
+		t.reading, handler, t.writing)
+}
@@ -0,0 +1,97 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// sniffMediatype detects the media type of a body sent without a Content-Type header from its first bytes.
+// Besides the types detected by http.DetectContentType, JSON, zstd, and protobuf messages are recognized.
+func sniffMediatype(body []byte) string {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) != 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+
+	if bytes.HasPrefix(body, []byte{0x28, 0xB5, 0x2F, 0xFD}) {
+		return "application/zstd"
+	}
+
+	mediatype, _, err := mime.ParseMediaType(http.DetectContentType(body))
+
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case mediatype == "text/xml":
+		return "application/xml"
+	case mediatype == "application/octet-stream" && isProtobuf(body):
+		return "application/x-protobuf"
+	}
+
+	return mediatype
+}
+
+// isProtobuf checks if a body can be decoded as a sequence of protobuf fields.
+// See https://developers.google.com/protocol-buffers/docs/encoding
+func isProtobuf(body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+
+	for len(body) != 0 {
+		key, n := protobufVarint(body)
+
+		if n == 0 || key>>3 == 0 {
+			return false
+		}
+
+		body = body[n:]
+
+		switch key & 7 {
+		case 0: // varint
+			if _, n = protobufVarint(body); n == 0 {
+				return false
+			}
+		case 1: // 64-bit
+			n = 8
+		case 2: // length-delimited
+			var length uint64
+
+			if length, n = protobufVarint(body); n == 0 || length > uint64(len(body)-n) {
+				return false
+			}
+
+			n += int(length)
+		case 5: // 32-bit
+			n = 4
+		default:
+			return false
+		}
+
+		if n > len(body) {
+			return false
+		}
+
+		body = body[n:]
+	}
+
+	return true
+}
+
+// protobufVarint decodes a varint, returning its value and the number of bytes read, or 0 if it is invalid.
+func protobufVarint(b []byte) (uint64, int) {
+	var v uint64
+
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7F) << (7 * uint(i))
+
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,103 @@
+package httpretty
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSniffMediatype(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{
+			name: "json object",
+			body: []byte(` {"name": "Gopher"}`),
+			want: "application/json",
+		},
+		{
+			name: "json array",
+			body: []byte(`[1, 2, 3]`),
+			want: "application/json",
+		},
+		{
+			name: "invalid json",
+			body: []byte(`{name}`),
+			want: "text/plain",
+		},
+		{
+			name: "xml",
+			body: []byte(`<?xml version="1.0"?><a/>`),
+			want: "application/xml",
+		},
+		{
+			name: "html",
+			body: []byte(`<!DOCTYPE html><html></html>`),
+			want: "text/html",
+		},
+		{
+			name: "png",
+			body: []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"),
+			want: "image/png",
+		},
+		{
+			name: "jpeg",
+			body: []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"),
+			want: "image/jpeg",
+		},
+		{
+			name: "gzip",
+			body: []byte("\x1F\x8B\x08\x00\x00\x00\x00\x00"),
+			want: "application/x-gzip",
+		},
+		{
+			name: "zstd",
+			body: []byte("\x28\xB5\x2F\xFD\x04\x00"),
+			want: "application/zstd",
+		},
+		{
+			// field 1: varint 150, field 2: string "testing"
+			name: "protobuf",
+			body: []byte("\x08\x96\x01\x12\x07testing"),
+			want: "application/x-protobuf",
+		},
+		{
+			name: "truncated protobuf",
+			body: []byte("\x08\x96\x01\x12\x07test"),
+			want: "application/octet-stream",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := sniffMediatype(tc.body); got != tc.want {
+			t.Errorf("sniffMediatype(%s) = %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPrintBodySniffedJSON(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		Formatters: []Formatter{&JSONFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	p := newPrinter(logger)
+	p.printBodyReader(nil, bytes.NewReader([]byte(`{"name":"Gopher"}`)))
+	p.flush()
+
+	want := `{
+    "name": "Gopher"
+}
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged body = %q, wanted %q", got, want)
+	}
+}
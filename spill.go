@@ -0,0 +1,80 @@
+package httpretty
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	spillPattern     = "httpretty-body-*"
+	spillPreviewSize = 256 // bytes
+)
+
+// spillBody saves a body too long to print to a temporary file as it is read, printing its path and a preview.
+func (p *printer) spillBody(body io.ReadCloser) io.ReadCloser {
+	f, err := ioutil.TempFile(p.logger.SpillDir, spillPattern)
+
+	if err != nil {
+		p.printf("* body is too long to print, and it cannot be saved: %v\n", err)
+		return body
+	}
+
+	preview := make([]byte, spillPreviewSize)
+	n, err := io.ReadFull(body, preview)
+	preview = preview[:n]
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		p.printf("* cannot read body: %v (%d bytes read)\n", err, n)
+	}
+
+	p.printSpilledBody(f.Name(), preview)
+
+	return &spillReadCloser{
+		r:    io.TeeReader(io.MultiReader(bytes.NewReader(preview), body), f),
+		body: body,
+		f:    f,
+	}
+}
+
+func (p *printer) printSpilledBody(name string, preview []byte) {
+	p.printf("* body is too long to print, saving it to %s\n", name)
+	p.printBodyPreview(preview)
+}
+
+// printBodyPreview prints the beginning of a body too long to print.
+func (p *printer) printBodyPreview(preview []byte) {
+	if isBinary(preview) {
+		p.println("* preview omitted: body contains binary data")
+		return
+	}
+
+	n := len(preview)
+	var counts []secretCount
+
+	if patterns := p.secretPatterns(); len(patterns) != 0 {
+		preview, counts = redactSecrets(patterns, preview, p.logger.redact)
+	}
+
+	p.printf("* preview (first %d bytes):\n%s\n", n, preview)
+
+	if len(counts) != 0 {
+		p.printRedactedSecrets(counts)
+	}
+}
+
+type spillReadCloser struct {
+	r    io.Reader
+	body io.ReadCloser
+	f    *os.File
+}
+
+func (s *spillReadCloser) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+func (s *spillReadCloser) Close() error {
+	s.f.Close()
+	return s.body.Close()
+}
@@ -0,0 +1,272 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// serverSentEvent is an event of a text/event-stream body.
+type serverSentEvent struct {
+	id, event, retry string
+	hasID            bool
+	data             []string
+	size             int
+
+	// truncated is set when the data of the event is longer than can be printed.
+	truncated bool
+}
+
+func (e *serverSentEvent) empty() bool {
+	return !e.hasID && e.event == "" && e.retry == "" && len(e.data) == 0 && !e.truncated
+}
+
+// eventStream parses a text/event-stream body as it is written, printing each event once it is complete.
+// See https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type eventStream struct {
+	p   *printer
+	max int // of the data of an event
+
+	line       []byte
+	longLine   bool // set when line is cut short
+	skipLF     bool // set when the last chunk ended with CR, which can be followed by LF in the next one
+	event      serverSentEvent
+	dispatched int
+}
+
+func (es *eventStream) write(b []byte) {
+	if es.skipLF && len(b) != 0 && b[0] == '\n' {
+		b = b[1:]
+	}
+
+	es.skipLF = false
+
+	for len(b) != 0 {
+		i := bytes.IndexAny(b, "\r\n")
+
+		if i == -1 {
+			es.appendLine(b)
+			return
+		}
+
+		es.appendLine(b[:i])
+		es.field(string(es.line))
+		es.line, es.longLine = es.line[:0], false
+
+		if b[i] == '\r' {
+			if i+1 == len(b) {
+				es.skipLF = true
+			} else if b[i+1] == '\n' {
+				i++
+			}
+		}
+
+		b = b[i+1:]
+	}
+}
+
+// maxFieldName is how much longer than the data of an event its lines can be, to make room for the field name.
+const maxFieldName = 16
+
+func (es *eventStream) appendLine(b []byte) {
+	if left := es.max + maxFieldName - len(es.line); left < len(b) {
+		b, es.longLine = b[:left], true
+	}
+
+	es.line = append(es.line, b...)
+}
+
+// field processes a line of the stream.
+func (es *eventStream) field(line string) {
+	if line == "" {
+		es.dispatch()
+		return
+	}
+
+	if line[0] == ':' {
+		return // comment, such as a keep-alive
+	}
+
+	name, value := line, ""
+
+	if i := strings.IndexByte(line, ':'); i != -1 {
+		name, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+	}
+
+	e := &es.event
+
+	switch name {
+	case "id":
+		e.id, e.hasID = value, true
+	case "event":
+		e.event = value
+	case "retry":
+		e.retry = value
+	case "data":
+		if es.longLine {
+			e.truncated = true
+		}
+
+		if e.size += len(value); e.size > es.max {
+			e.truncated = true
+			return
+		}
+
+		e.data = append(e.data, value)
+	}
+}
+
+// dispatch prints the event read so far, if any.
+func (es *eventStream) dispatch() {
+	if es.event.empty() {
+		return
+	}
+
+	es.dispatched++
+	es.p.printServerSentEvent(es.dispatched, es.event)
+	es.p.maybeOnReady()
+	es.event = serverSentEvent{}
+}
+
+// close prints what is left of an event at the end of the stream.
+func (es *eventStream) close() {
+	if len(es.line) != 0 {
+		es.field(string(es.line))
+		es.line = nil
+	}
+
+	if !es.event.empty() {
+		es.p.println("* incomplete event at the end of the stream:")
+		es.dispatch()
+	}
+}
+
+func (p *printer) printServerSentEvent(n int, e serverSentEvent) {
+	var details []string
+
+	if e.hasID {
+		details = append(details, "id: "+e.id)
+	}
+
+	if e.event != "" {
+		details = append(details, "event: "+e.event)
+	}
+
+	if e.retry != "" {
+		details = append(details, "retry: "+e.retry)
+	}
+
+	if len(details) == 0 {
+		p.printf("* event #%d\n", n)
+	} else {
+		p.printf("* event #%d (%s)\n", n, strings.Join(details, ", "))
+	}
+
+	patterns := p.secretPatterns()
+
+	var counts []secretCount
+
+	for _, d := range e.data {
+		if len(patterns) != 0 {
+			b, c := redactSecrets(patterns, []byte(d), p.logger.redact)
+			d = string(b)
+
+			for _, sc := range c {
+				counts = addSecretCount(counts, sc.name, sc.n)
+			}
+		}
+
+		p.printf("data: %s\n", d)
+	}
+
+	if len(counts) != 0 {
+		p.printRedactedSecrets(counts)
+	}
+
+	if e.truncated {
+		p.printf("* event data truncated (longer than %d bytes)\n", p.maxEventData())
+	}
+}
+
+func (p *printer) maxEventData() int {
+	if max := p.logger.MaxResponseBody; max > 0 {
+		return int(max)
+	}
+
+	return maxDefaultUnknownReadable
+}
+
+// startEventStream prints the response header of a stream of server-sent events once the handler starts writing it,
+// and returns a parser to print its events as they are written, or nil if its body isn't printed.
+func (p *printer) startEventStream(req *http.Request, rec *responseRecorder, resolveFile func(req *http.Request) string) *eventStream {
+	p.printServerResponseHeader(req, rec, resolveFile)
+
+	captured := p.isCaptured(rec.statusCode, rec.Header())
+	p.releaseRequestBody(captured)
+	defer p.maybeOnReady()
+
+	if !p.logger.ResponseBody || !captured || p.skipBodies {
+		return nil
+	}
+
+	skip, err := p.checkBodyFiltered(rec.Header())
+
+	if err != nil {
+		p.printf("* %s\n", p.format(color.FgRed, "error on response body filter: %v", err))
+	}
+
+	if skip {
+		return nil
+	}
+
+	return &eventStream{p: p, max: p.maxEventData()}
+}
+
+// printEventStreamEnd prints what is left of a stream of server-sent events once the handler returns.
+func (p *printer) printEventStreamEnd(rec *responseRecorder) {
+	es := rec.events
+
+	if es == nil {
+		p.printResponseBodySize(rec.size)
+		return
+	}
+
+	es.close()
+	events := "events"
+
+	if es.dispatched == 1 {
+		events = "event"
+	}
+
+	p.printf("* event stream ended after %d %s (%s)\n", es.dispatched, events, formatBytes(rec.size))
+}
+
+// detectEventStream starts printing the response as a stream of server-sent events,
+// if it is one, once the handler starts writing it.
+func (rr *responseRecorder) detectEventStream() {
+	if rr.detected || rr.hijacked {
+		return
+	}
+
+	rr.detected = true
+
+	if rr.startEvents == nil || rr.statusCode != http.StatusOK || !isEventStream(rr.Header()) {
+		return
+	}
+
+	rr.eventStream = true
+	rr.buf = nil
+	rr.events = rr.startEvents()
+}
+
+// Flush sends any buffered data to the client, if the underlying writer supports it.
+// Streaming handlers, such as the ones sending server-sent events, rely on it.
+func (rr *responseRecorder) Flush() {
+	rr.detectEventStream()
+
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
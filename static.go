@@ -0,0 +1,104 @@
+package httpretty
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WithFileResolver sets a function resolving the file a request is served from, for handlers serving static files
+// such as http.FileServer. The path returned is printed along with the size and modification time of the file,
+// or a note if it doesn't exist. Return an empty string for requests not served from a file.
+func WithFileResolver(resolve func(req *http.Request) (path string)) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.resolveFile = resolve
+	}
+}
+
+// printStaticFile prints the file a request is served from, and how its body was sent.
+func (p *printer) printStaticFile(req *http.Request, resolve func(req *http.Request) string, rec *responseRecorder) {
+	if resolve != nil {
+		if name := resolve(req); name != "" {
+			p.printf("* static file: %s\n", describeFile(name))
+		}
+	}
+
+	switch {
+	case rec.sentFile:
+		p.printf("* file sent with sendfile (%s), the body isn't printed\n", formatBytes(rec.size))
+	case rec.copiedFile:
+		p.println("* file copied through the logger to print its body, so sendfile isn't used")
+	}
+}
+
+func describeFile(name string) string {
+	fi, err := os.Stat(name)
+
+	switch {
+	case os.IsNotExist(err):
+		return name + " (not found)"
+	case err != nil:
+		return name + " (" + err.Error() + ")"
+	case fi.IsDir():
+		return name + " (directory)"
+	}
+
+	return name + " (" + formatBytes(fi.Size()) + ", modified " + fi.ModTime().UTC().Format(time.RFC3339) + ")"
+}
+
+// ReadFrom lets the underlying writer send files with sendfile, when they are sent by io.Copy,
+// as http.FileServer does, and the body doesn't need to be recorded.
+func (rr *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	rf, ok := rr.ResponseWriter.(io.ReaderFrom)
+	file := isFileReader(src)
+
+	if !ok || !rr.skipsBody() {
+		rr.copiedFile = rr.copiedFile || file
+		return io.Copy(writerOnly{rr}, src)
+	}
+
+	if rr.timings != nil {
+		defer rr.timeWriting(time.Now())
+	}
+
+	// a body declared too long isn't buffered from now on. A skipped body is never buffered (see Write).
+	if !rr.skipBody {
+		rr.buf = nil
+	}
+
+	n, err := rf.ReadFrom(src)
+	rr.size += n
+	rr.written += n
+	rr.sentFile = rr.sentFile || file
+
+	if err != nil && rr.writeErr == nil {
+		rr.writeErr = err
+	}
+
+	return n, err
+}
+
+// skipsBody checks if the body can be sent without being recorded.
+func (rr *responseRecorder) skipsBody() bool {
+	if rr.checksum != nil || rr.spillDir != "" || rr.keepHead {
+		return false
+	}
+
+	return rr.skipBody || rr.size == 0 && rr.declaredTooLong()
+}
+
+// isFileReader checks if a reader is a file, possibly limited, which the net/http server can send with sendfile.
+func isFileReader(r io.Reader) bool {
+	if lr, ok := r.(*io.LimitedReader); ok {
+		r = lr.R
+	}
+
+	_, ok := r.(*os.File)
+	return ok
+}
+
+// writerOnly hides the ReadFrom method of a writer from io.Copy.
+type writerOnly struct {
+	io.Writer
+}
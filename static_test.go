@@ -0,0 +1,182 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "httpretty-static")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	content := strings.Repeat("static content\n", 1000)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := func(req *http.Request) string {
+		return filepath.Join(dir, filepath.FromSlash(req.URL.Path))
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		header       http.Header
+		responseBody bool
+		want         []string
+		notWant      []string
+	}{
+		{
+			name: "sendfile",
+			path: "/file.txt",
+			want: []string{
+				"* static file: " + filepath.Join(dir, "file.txt") + " (14.6 KiB, modified ",
+				"* file sent with sendfile (14.6 KiB), the body isn't printed\n",
+			},
+		},
+		{
+			name:         "copied",
+			path:         "/file.txt",
+			responseBody: true,
+			want: []string{
+				"* file copied through the logger to print its body, so sendfile isn't used\n",
+				"static content\n",
+			},
+		},
+		{
+			name:   "range",
+			path:   "/file.txt",
+			header: http.Header{"Range": []string{"bytes=0-99"}},
+			want: []string{
+				"* range request (bytes=0-99): served 206 (partial content), Content-Range: bytes 0-99/15000\n",
+			},
+		},
+		{
+			name:   "not satisfiable",
+			path:   "/file.txt",
+			header: http.Header{"Range": []string{"bytes=20000-"}},
+			want: []string{
+				"* range request (bytes=20000-): not satisfiable, served 416\n",
+			},
+			notWant: []string{"sendfile"},
+		},
+		{
+			name:   "not modified",
+			path:   "/file.txt",
+			header: http.Header{"If-Modified-Since": []string{"Fri, 01 Jan 2100 00:00:00 GMT"}},
+			want: []string{
+				"* conditional request (If-Modified-Since): validator matched, served 304\n",
+			},
+			notWant: []string{"sendfile"},
+		},
+		{
+			name: "not found",
+			path: "/missing.txt",
+			want: []string{
+				"* static file: " + filepath.Join(dir, "missing.txt") + " (not found)\n",
+			},
+			notWant: []string{"sendfile"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &Logger{
+				ResponseHeader: true,
+				ResponseBody:   tc.responseBody,
+			}
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+
+			ts := httptest.NewServer(logger.Handler(http.FileServer(http.Dir(dir)), WithFileResolver(resolve)))
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tc.path, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+
+			if err != nil {
+				t.Fatalf("cannot connect to the server: %v", err)
+			}
+
+			if _, err := ioutil.ReadAll(resp.Body); err != nil {
+				t.Errorf("cannot read body: %v", err)
+			}
+
+			resp.Body.Close()
+			ts.Close()
+
+			got := buf.String()
+
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("logged output doesn't contain %q:\n%s", want, got)
+				}
+			}
+
+			for _, notWant := range tc.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("logged output contains %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFromThenWrite(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		ResponseHeader: true,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	handler := logger.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// hide the type of the reader, so it isn't sent as a file.
+		if _, err := io.Copy(w, struct{ io.Reader }{strings.NewReader("hello, ")}); err != nil {
+			t.Errorf("cannot copy: %v", err)
+		}
+
+		fmt.Fprint(w, "world")
+	}))
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if body, err := ioutil.ReadAll(resp.Body); err != nil || string(body) != "hello, world" {
+		t.Errorf("response body = %q (error: %v), wanted %q", body, err, "hello, world")
+	}
+}
@@ -0,0 +1,454 @@
+package httpretty
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// defaultMaxExchanges kept by an ExchangeStore.
+const defaultMaxExchanges = 1000
+
+// ExchangeStore keeps the recent exchanges in memory, so applications can search them programmatically.
+// Use it with Logger.Store. It is also a http.Handler dumping the recent exchanges (see DebugPath).
+//
+// The oldest exchanges are evicted first when any of the limits is reached.
+//
+// Headers are kept as they were sent, credentials such as Authorization and Cookie included,
+// so the exchanges found can be sent again; they are only sanitized when the store is dumped (see ServeHTTP).
+// Protect the exchanges found as you would protect the credentials themselves.
+//
+// Bodies are copied as they are read, up to Logger.MaxRequestBody and Logger.MaxResponseBody,
+// or 4096 bytes if the limits aren't set. Exchanges with longer bodies are kept with empty ones.
+// The exchanges of a client are kept once their response body is read to the end or closed.
+type ExchangeStore struct {
+	// MaxExchanges kept. If value is not set, 1000 exchanges are kept.
+	MaxExchanges int
+
+	// MaxBytes of bodies kept, adding the request and response bodies of each exchange.
+	// Identical bodies are kept only once, and are counted once.
+	// There is no limit if value is not set.
+	MaxBytes int64
+
+	// TTL is how long exchanges are kept. There is no limit if value is not set.
+	TTL time.Duration
+
+	mu        sync.Mutex
+	exchanges []storedExchange
+	bodies    map[[sha256.Size]byte]*sharedBody
+	size      int64
+	now       func() time.Time
+}
+
+// sharedBody is a body kept once for all the exchanges with identical content.
+type sharedBody struct {
+	data []byte
+	refs int
+}
+
+// Query for exchanges in an ExchangeStore. Fields that aren't set match all exchanges.
+type Query struct {
+	// Path of the request. It can be a pattern, such as /users/* (see path.Match).
+	Path string
+
+	// MinStatus and MaxStatus of the response, such as 500 and 599 for server errors.
+	// Exchanges that failed without a response don't match them.
+	MinStatus int
+	MaxStatus int
+
+	// Since and Until limit when the exchanges started.
+	Since time.Time
+	Until time.Time
+}
+
+// storedExchange keeps the bodies, so a new Exchange can be created each time it is found.
+type storedExchange struct {
+	startedAt time.Time
+	duration  time.Duration
+
+	req     *http.Request
+	reqBody []byte
+
+	resp     *http.Response
+	respBody []byte
+
+	// redaction of the logger that saved the exchange, for dumping it (see ServeHTTP).
+	redaction exchangeRedaction
+}
+
+func (se storedExchange) match(q Query) bool {
+	if q.Path != "" {
+		if ok, _ := path.Match(q.Path, se.req.URL.Path); !ok {
+			return false
+		}
+	}
+
+	if q.MinStatus != 0 || q.MaxStatus != 0 {
+		if se.resp == nil {
+			return false
+		}
+
+		if q.MinStatus != 0 && se.resp.StatusCode < q.MinStatus {
+			return false
+		}
+
+		if q.MaxStatus != 0 && se.resp.StatusCode > q.MaxStatus {
+			return false
+		}
+	}
+
+	if !q.Since.IsZero() && se.startedAt.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && se.startedAt.After(q.Until) {
+		return false
+	}
+
+	return true
+}
+
+// exchange creates an Exchange with new copies of the request and response.
+func (se storedExchange) exchange() Exchange {
+	req := se.req.Clone(se.req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(se.reqBody))
+	req.ContentLength = int64(len(se.reqBody))
+
+	e := Exchange{
+		StartedAt: se.startedAt,
+		Duration:  se.duration,
+		Request:   req,
+	}
+
+	if se.resp != nil {
+		resp := *se.resp
+		resp.Header = se.resp.Header.Clone()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(se.respBody))
+		resp.ContentLength = int64(len(se.respBody))
+		resp.Request = req
+		e.Response = &resp
+	}
+
+	return e
+}
+
+// Find the exchanges matching the query, from the oldest to the newest.
+func (s *ExchangeStore) Find(q Query) []Exchange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+
+	var exchanges []Exchange
+
+	for _, se := range s.exchanges {
+		if se.match(q) {
+			exchanges = append(exchanges, se.exchange())
+		}
+	}
+
+	return exchanges
+}
+
+// Len is the number of exchanges in the store.
+func (s *ExchangeStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	return len(s.exchanges)
+}
+
+func (s *ExchangeStore) add(se storedExchange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	se.reqBody = s.keep(se.reqBody)
+	se.respBody = s.keep(se.respBody)
+	s.exchanges = append(s.exchanges, se)
+	s.evict()
+}
+
+// keep a body, returning the copy already kept if there is one with identical content. The mutex must be held.
+func (s *ExchangeStore) keep(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if s.bodies == nil {
+		s.bodies = map[[sha256.Size]byte]*sharedBody{}
+	}
+
+	sum := sha256.Sum256(body)
+
+	if sb, ok := s.bodies[sum]; ok {
+		sb.refs++
+		return sb.data
+	}
+
+	s.bodies[sum] = &sharedBody{data: body, refs: 1}
+	s.size += int64(len(body))
+	return body
+}
+
+// release a body kept, removing it once no exchange uses it. The mutex must be held.
+func (s *ExchangeStore) release(body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	sb, ok := s.bodies[sum]
+
+	if !ok {
+		return
+	}
+
+	if sb.refs--; sb.refs == 0 {
+		delete(s.bodies, sum)
+		s.size -= int64(len(body))
+	}
+}
+
+// evict the oldest exchanges until the store is within its limits. The mutex must be held.
+func (s *ExchangeStore) evict() {
+	max := s.MaxExchanges
+
+	if max <= 0 {
+		max = defaultMaxExchanges
+	}
+
+	var expired time.Time
+
+	if s.TTL > 0 {
+		expired = s.getNow().Add(-s.TTL)
+	}
+
+	var n int
+
+	for n < len(s.exchanges) {
+		se := s.exchanges[n]
+
+		if len(s.exchanges)-n <= max &&
+			(s.MaxBytes <= 0 || s.size <= s.MaxBytes) &&
+			(expired.IsZero() || se.startedAt.After(expired)) {
+			break
+		}
+
+		s.release(se.reqBody)
+		s.release(se.respBody)
+		n++
+	}
+
+	if n != 0 {
+		s.exchanges = append([]storedExchange(nil), s.exchanges[n:]...)
+	}
+}
+
+func (s *ExchangeStore) getNow() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+
+	return time.Now()
+}
+
+// readBody reads a body fully, returning its content and a new body to pass along.
+func readBody(body io.ReadCloser) ([]byte, io.ReadCloser) {
+	if body == nil || body == http.NoBody {
+		return nil, body
+	}
+
+	b, err := ioutil.ReadAll(body)
+	body.Close()
+
+	if err != nil {
+		return b, ioutil.NopCloser(io.MultiReader(bytes.NewReader(b), errorReader{err}))
+	}
+
+	return b, ioutil.NopCloser(bytes.NewReader(b))
+}
+
+// sinkLimit is the maximum length of the bodies kept by the sinks saving the exchanges, such as a HARWriter:
+// max, or 4096 bytes if max isn't set, even for bodies whose length is known.
+func sinkLimit(max int64) int64 {
+	if max <= 0 {
+		return maxDefaultUnknownReadable
+	}
+
+	return max
+}
+
+// readSinkBody reads a request body for a sink, up to sinkLimit(max), returning its content,
+// or nil if it is too long, and the body to pass along. Streamed bodies aren't read ahead of the transport.
+func readSinkBody(body io.ReadCloser, length, max int64) ([]byte, io.ReadCloser) {
+	if isStreamedBody(body) {
+		return nil, body
+	}
+
+	b, _, newBody := readBodyLimit(body, length, sinkLimit(max))
+	return b, newBody
+}
+
+// teeBody keeps a copy of a response body for a sink while the client reads it, instead of reading it ahead,
+// so streaming responses, such as server-sent events, aren't held back.
+// done is called once the body is read to the end or closed, with the copy, or nil if the body is longer
+// than sinkLimit(max) or isn't read to the end.
+func teeBody(body io.ReadCloser, length, max int64, done func(b []byte)) io.ReadCloser {
+	max = sinkLimit(max)
+
+	if body == nil || body == http.NoBody || length > max {
+		done(nil)
+		return body
+	}
+
+	return &sinkBody{
+		ReadCloser: body,
+		max:        max,
+		done:       done,
+	}
+}
+
+// sinkBody keeps a copy of a body as it is read (see teeBody).
+type sinkBody struct {
+	io.ReadCloser
+
+	buf     bytes.Buffer
+	max     int64
+	tooLong bool
+	once    sync.Once
+	done    func(b []byte)
+}
+
+func (sb *sinkBody) Read(p []byte) (int, error) {
+	n, err := sb.ReadCloser.Read(p)
+
+	switch {
+	case sb.tooLong:
+	case int64(sb.buf.Len()+n) > sb.max:
+		sb.tooLong = true
+		sb.buf = bytes.Buffer{}
+	default:
+		sb.buf.Write(p[:n])
+	}
+
+	if err != nil {
+		sb.once.Do(func() { sb.finish(err == io.EOF) })
+	}
+
+	return n, err
+}
+
+func (sb *sinkBody) Close() error {
+	err := sb.ReadCloser.Close()
+	sb.once.Do(func() { sb.finish(false) })
+	return err
+}
+
+// finish calls done with the copy of the body, if it was read to the end within the limit.
+func (sb *sinkBody) finish(eof bool) {
+	if !eof || sb.tooLong {
+		sb.done(nil)
+		return
+	}
+
+	sb.done(sb.buf.Bytes())
+}
+
+type storeRoundTripper struct {
+	logger *Logger
+	store  *ExchangeStore
+	next   http.RoundTripper
+}
+
+func (srt storeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	se := storedExchange{
+		startedAt: srt.store.getNow(),
+		redaction: srt.logger.exchangeRedaction(req),
+	}
+
+	// bodies too long aren't read, and are kept empty.
+	se.reqBody, req.Body = readSinkBody(req.Body, req.ContentLength, srt.logger.MaxRequestBody)
+	se.req = req.Clone(req.Context())
+	start := time.Now()
+	resp, err := srt.next.RoundTrip(req)
+	se.duration = time.Since(start)
+
+	if resp == nil {
+		srt.store.add(se)
+		return resp, err
+	}
+
+	stored := *resp
+	stored.Header = resp.Header.Clone()
+	se.resp = &stored
+
+	// the exchange is kept once the response body is read or closed.
+	resp.Body = teeBody(resp.Body, resp.ContentLength, srt.logger.MaxResponseBody, func(b []byte) {
+		se.respBody = b
+		srt.store.add(se)
+	})
+
+	return resp, err
+}
+
+// storeHandler saves the exchanges of a server to a store.
+type storeHandler struct {
+	logger *Logger
+	store  *ExchangeStore
+	next   http.Handler
+}
+
+func (sh storeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	se := storedExchange{
+		startedAt: sh.store.getNow(),
+		redaction: sh.logger.exchangeRedaction(req),
+	}
+
+	se.reqBody, req.Body = readSinkBody(req.Body, req.ContentLength, sh.logger.MaxRequestBody)
+	se.req = req.Clone(req.Context())
+
+	// the stored request is ready to be sent again, so it needs an absolute URL.
+	u := *req.URL
+	u.Scheme, u.Host = "http", req.Host
+
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+
+	se.req.URL = &u
+	se.req.RequestURI = ""
+
+	cw := &copyingResponseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+		max:            sinkLimit(sh.logger.MaxResponseBody),
+	}
+
+	start := time.Now()
+
+	defer func() {
+		se.duration = time.Since(start)
+
+		if !cw.tooLong {
+			se.respBody = cw.body.Bytes()
+		}
+
+		se.resp = &http.Response{
+			Status:     fmt.Sprintf("%d %s", cw.statusCode, http.StatusText(cw.statusCode)),
+			StatusCode: cw.statusCode,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     w.Header().Clone(),
+		}
+
+		sh.store.add(se)
+	}()
+
+	sh.next.ServeHTTP(cw, req)
+}
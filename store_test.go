@@ -0,0 +1,317 @@
+package httpretty
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type storeHandlerFixture struct{}
+
+func (h storeHandlerFixture) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	if strings.HasPrefix(req.URL.Path, "/missing") {
+		http.NotFound(w, req)
+		return
+	}
+
+	fmt.Fprintf(w, "%s %s", req.URL.Path, body)
+}
+
+func TestOutgoingStore(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(storeHandlerFixture{})
+	defer ts.Close()
+
+	store := &ExchangeStore{}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+		Store:           store,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		resp, err := client.Post(ts.URL+path, "text/plain", strings.NewReader("hi"))
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if n := store.Len(); n != 3 {
+		t.Errorf("store has %d exchanges; want 3", n)
+	}
+
+	users := store.Find(Query{Path: "/users/*"})
+
+	if len(users) != 2 {
+		t.Fatalf("found %d exchanges; want 2", len(users))
+	}
+
+	testBody(t, users[1].Request.Body, []byte("hi"))
+	testBody(t, users[1].Response.Body, []byte("/users/2 hi"))
+
+	if users[1].Request.URL.Path != "/users/2" || users[1].Response.Request != users[1].Request {
+		t.Errorf("wrong exchange found: %v", users[1].Request.URL)
+	}
+
+	// each query returns new bodies.
+	testBody(t, store.Find(Query{Path: "/users/2"})[0].Response.Body, []byte("/users/2 hi"))
+
+	missing := store.Find(Query{MinStatus: 400, MaxStatus: 499})
+
+	if len(missing) != 1 || missing[0].Response.StatusCode != http.StatusNotFound {
+		t.Errorf("found %v; want the request to /missing", missing)
+	}
+
+	if found := store.Find(Query{Until: time.Now().Add(-time.Hour)}); len(found) != 0 {
+		t.Errorf("found %d exchanges before an hour ago; want none", len(found))
+	}
+}
+
+func TestIncomingStore(t *testing.T) {
+	t.Parallel()
+
+	store := &ExchangeStore{}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		Store:           store,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+	handler := logger.Middleware(storeHandlerFixture{})
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/missing?q=1", strings.NewReader("hi"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	found := store.Find(Query{})
+
+	if len(found) != 1 {
+		t.Fatalf("found %d exchanges; want 1", len(found))
+	}
+
+	e := found[0]
+
+	if got, want := e.Request.URL.String(), "http://example.com/missing?q=1"; got != want {
+		t.Errorf("stored request URL = %q; want %q", got, want)
+	}
+
+	if e.Response.Status != "404 Not Found" {
+		t.Errorf("stored response status = %q; want 404 Not Found", e.Response.Status)
+	}
+
+	testBody(t, e.Request.Body, []byte("hi"))
+	testBody(t, e.Response.Body, []byte("404 page not found\n"))
+}
+
+func TestStoreBodyLimits(t *testing.T) {
+	t.Parallel()
+
+	store := &ExchangeStore{}
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		MaxRequestBody:  5,
+		MaxResponseBody: 5,
+		Store:           store,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+	handler := logger.Middleware(storeHandlerFixture{})
+
+	for _, body := range []string{"hi", "hello, world"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/a", strings.NewReader(body))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	found := store.Find(Query{})
+
+	if len(found) != 2 {
+		t.Fatalf("found %d exchanges; want 2", len(found))
+	}
+
+	testBody(t, found[0].Request.Body, []byte("hi"))
+	testBody(t, found[0].Response.Body, []byte("/a hi"))
+	testBody(t, found[1].Request.Body, []byte{})
+	testBody(t, found[1].Response.Body, []byte{})
+}
+
+func TestOutgoingStoreStreamingResponse(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	defer ts.Close()
+
+	store := &ExchangeStore{}
+
+	logger := &Logger{
+		Store: store,
+	}
+
+	logger.SetOutput(ioutil.Discard)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	done := make(chan *http.Response)
+
+	go func() {
+		resp, err := client.Get(ts.URL)
+
+		if err != nil {
+			t.Errorf("cannot connect to the server: %v", err)
+		}
+
+		done <- resp
+	}()
+
+	var resp *http.Response
+
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("response wasn't returned before the stream ended")
+	}
+
+	if n := store.Len(); n != 0 {
+		t.Errorf("store has %d exchanges before the body is read; want 0", n)
+	}
+
+	close(release)
+
+	if resp == nil {
+		return
+	}
+
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	found := store.Find(Query{})
+
+	if len(found) != 1 {
+		t.Fatalf("found %d exchanges; want 1", len(found))
+	}
+
+	testBody(t, found[0].Response.Body, []byte("data: first\n\ndata: second\n\n"))
+}
+
+func TestExchangeStoreEviction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name  string
+		store *ExchangeStore
+		want  []string
+	}{
+		{
+			name:  "count",
+			store: &ExchangeStore{MaxExchanges: 2},
+			want:  []string{"/2", "/3"},
+		},
+		{
+			name:  "bytes",
+			store: &ExchangeStore{MaxBytes: 25},
+			want:  []string{"/3"},
+		},
+		{
+			name:  "ttl",
+			store: &ExchangeStore{TTL: 90 * time.Second},
+			want:  []string{"/2", "/3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc.store.now = func() time.Time {
+			return now
+		}
+
+		for i := 1; i <= 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://example.com/%d", i), nil)
+
+			tc.store.add(storedExchange{
+				startedAt: now.Add(time.Duration(i-3) * time.Minute),
+				req:       req,
+				reqBody:   []byte(strings.Repeat(fmt.Sprintf("a%d", i), 5)),
+				respBody:  []byte(strings.Repeat(fmt.Sprintf("b%d", i), 5)),
+			})
+		}
+
+		var got []string
+
+		for _, e := range tc.store.Find(Query{}) {
+			got = append(got, e.Request.URL.Path)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: stored exchanges %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExchangeStoreDeduplicatesBodies(t *testing.T) {
+	t.Parallel()
+
+	store := &ExchangeStore{}
+	body := strings.Repeat("a", 100)
+
+	for i := 1; i <= 3; i++ {
+		store.add(storedExchange{
+			req:      httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://example.com/%d", i), nil),
+			resp:     &http.Response{StatusCode: http.StatusOK},
+			respBody: []byte(body),
+		})
+	}
+
+	if store.size != 100 {
+		t.Errorf("store size = %d bytes; want identical bodies kept once (100 bytes)", store.size)
+	}
+
+	found := store.Find(Query{})
+
+	if len(found) != 3 {
+		t.Fatalf("found %d exchanges; want 3", len(found))
+	}
+
+	for _, e := range found {
+		testBody(t, e.Response.Body, []byte(body))
+	}
+
+	store.MaxExchanges = 1
+	store.add(storedExchange{
+		req:      httptest.NewRequest(http.MethodGet, "http://example.com/4", nil),
+		respBody: []byte("b"),
+	})
+
+	if store.size != 1 || len(store.bodies) != 1 {
+		t.Errorf("store size = %d bytes in %d bodies; want the evicted body released", store.size, len(store.bodies))
+	}
+}
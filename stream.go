@@ -0,0 +1,50 @@
+package httpretty
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const eventStreamMediatype = "text/event-stream"
+
+// isStreamingRequest checks if the request asks for a protocol upgrade (such as WebSocket) or server-sent events.
+func isStreamingRequest(req *http.Request) bool {
+	if req.Header.Get("Upgrade") != "" {
+		return true
+	}
+
+	for _, v := range splitList(req.Header.Get("Accept")) {
+		if mediatype, _, err := mime.ParseMediaType(v); err == nil && mediatype == eventStreamMediatype {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isStreamingResponse checks if the response switches protocols or is a stream of server-sent events.
+func isStreamingResponse(statusCode int, h http.Header) bool {
+	if statusCode == http.StatusSwitchingProtocols {
+		return true
+	}
+
+	return isEventStream(h)
+}
+
+// isEventStream checks if the content type of a response is text/event-stream.
+func isEventStream(h http.Header) bool {
+	mediatype, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && strings.EqualFold(mediatype, eventStreamMediatype)
+}
+
+// streaming switches to the flusher used for streaming exchanges, if one is set.
+// Anything buffered so far is flushed first to keep the output in order.
+func (p *printer) streaming() {
+	if p.streamFlusher == nil || p.flusher == *p.streamFlusher {
+		return
+	}
+
+	p.flushStep()
+	p.flusher = *p.streamFlusher
+}
@@ -0,0 +1,96 @@
+package httpretty
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// isStreamedBody checks if a request body is a stream, such as a pipe, which must not be read ahead of the
+// transport: the other end might be waiting for the response before writing more, causing a deadlock.
+func isStreamedBody(body io.ReadCloser) bool {
+	_, ok := body.(*io.PipeReader)
+	return ok
+}
+
+// streamedBody captures up to max bytes of a body as the transport reads it.
+type streamedBody struct {
+	io.ReadCloser
+
+	mu   sync.Mutex // the transport might still be reading the body after the response is received
+	buf  bytes.Buffer
+	max  int64
+	n    int64
+	done bool
+}
+
+func (sb *streamedBody) Read(p []byte) (int, error) {
+	n, err := sb.ReadCloser.Read(p)
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if left := sb.max - int64(sb.buf.Len()); left > 0 {
+		if int64(n) < left {
+			left = int64(n)
+		}
+
+		sb.buf.Write(p[:left])
+	}
+
+	sb.n += int64(n)
+
+	if err == io.EOF {
+		sb.done = true
+	}
+
+	return n, err
+}
+
+// streamRequestBody captures the request body as it is sent, instead of reading it ahead.
+// It is printed by printStreamedRequestBody once the response is received.
+func (p *printer) streamRequestBody(req *http.Request) {
+	max := p.logger.MaxRequestBody
+
+	if max == 0 {
+		max = maxDefaultUnknownReadable
+	}
+
+	sb := &streamedBody{
+		ReadCloser: req.Body,
+		max:        max,
+	}
+
+	req.Body = sb
+	p.streamed = sb
+}
+
+// printStreamedRequestBody prints what was captured of a streamed request body.
+func (p *printer) printStreamedRequestBody(h http.Header) {
+	sb := p.streamed
+
+	if sb == nil {
+		return
+	}
+
+	p.streamed = nil
+
+	sb.mu.Lock()
+	body, n, done := append([]byte(nil), sb.buf.Bytes()...), sb.n, sb.done
+	sb.mu.Unlock()
+
+	switch {
+	case done && n <= sb.max:
+		p.printf("* request body streamed, %s in total\n", formatBytes(n))
+		p.printBodyReader(h, bytes.NewReader(body))
+	case done:
+		p.printf("* request body streamed, %s in total, skipping (longer than %d bytes)\n", formatBytes(n), sb.max)
+	default:
+		p.printf("* request body streamed, %s captured of unknown total\n", formatBytes(int64(len(body))))
+
+		if len(body) != 0 {
+			p.printBody("", body)
+		}
+	}
+}
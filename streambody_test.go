@@ -0,0 +1,136 @@
+package httpretty
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// startHandler signals when it receives the start of the request body.
+type startHandler struct {
+	started chan struct{}
+}
+
+func (h startHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := make([]byte, 5)
+
+	if _, err := io.ReadFull(req.Body, start); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	close(h.started)
+	body, _ := ioutil.ReadAll(req.Body)
+	w.Write(append(start, body...))
+}
+
+func TestOutgoingStreamedRequestBody(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	ts := httptest.NewServer(startHandler{started: started})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.Write([]byte("hello"))
+
+		// the rest of the body is only sent after the server receives its start,
+		// so reading the body ahead of the transport would deadlock.
+		<-started
+		pw.Write([]byte(", world"))
+		pw.Close()
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL, pr)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("hello, world"))
+
+	want := "* request body streamed, 12 B in total\nhello, world\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestPrintStreamedRequestBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		max  int64
+		read int
+		want string
+	}{
+		{
+			name: "in progress",
+			read: 5,
+			want: "* request body streamed, 5 B captured of unknown total\nhello\n",
+		},
+		{
+			name: "complete",
+			read: 100,
+			want: "* request body streamed, 12 B in total\nhello, world\n",
+		},
+		{
+			name: "too long",
+			max:  5,
+			read: 100,
+			want: "* request body streamed, 12 B in total, skipping (longer than 5 bytes)\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := &Logger{
+			MaxRequestBody: tc.max,
+		}
+
+		var buf bytes.Buffer
+		logger.SetOutput(&buf)
+
+		p := newPrinter(logger)
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/", nil)
+		req.Body = ioutil.NopCloser(strings.NewReader("hello, world"))
+		p.streamRequestBody(req)
+
+		// reading one byte at a time to control how much the transport read so far.
+		for i := 0; i < tc.read; i++ {
+			if _, err := req.Body.Read(make([]byte, 1)); err != nil {
+				break
+			}
+		}
+
+		p.printStreamedRequestBody(req.Header)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: logged %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
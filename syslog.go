@@ -0,0 +1,231 @@
+package httpretty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exchangeWriter is implemented by outputs recording the outcome of an exchange along with its log.
+type exchangeWriter interface {
+	writeExchange(s string, statusCode int, failed bool) error
+}
+
+// writeExchange writes to w, passing along the outcome of the exchange if w is an exchangeWriter.
+func writeExchange(w io.Writer, s string, statusCode int, failed bool) {
+	if ew, ok := w.(exchangeWriter); ok {
+		ew.writeExchange(s, statusCode, failed)
+		return
+	}
+
+	io.WriteString(w, s)
+}
+
+// Syslog severity levels, also used for the priority of journald entries.
+const (
+	severityError   = 3
+	severityWarning = 4
+	severityInfo    = 6
+)
+
+// severity of an exchange, given the class of its status code: server errors and failed exchanges are errors,
+// client errors are warnings, and everything else is informational.
+func severity(statusCode int, failed bool) int {
+	switch {
+	case failed || statusCode >= 500:
+		return severityError
+	case statusCode >= 400:
+		return severityWarning
+	default:
+		return severityInfo
+	}
+}
+
+// syslogSDID is the ID of the structured data element, using the enterprise number reserved for documentation.
+const syslogSDID = "httpretty@32473"
+
+// SyslogSink writes the logs to a syslog server, using the RFC 5424 format.
+// Use it as the output of a logger with Logger.SetOutput, with the OnEnd flusher so each exchange is a single message.
+//
+// The severity of a message is error for server errors (5xx) and failed exchanges, warning for client errors (4xx),
+// and informational otherwise. The status code is sent as structured data, such as [httpretty@32473 status="404"].
+// Messages written to it directly, rather than by a logger, are informational.
+type SyslogSink struct {
+	// Network and Addr of the syslog server, such as "udp" and "localhost:514" (see net.Dial).
+	// Messages sent over stream connections, such as "tcp", are framed by octet counting (RFC 6587).
+	// If Network is not set, the local syslog server is used.
+	Network string
+	Addr    string
+
+	// Facility of the messages, such as 16 for local0. If value is not set, 1 (user-level messages) is used.
+	Facility int
+
+	// Hostname sent with the messages. If value is not set, the hostname reported by the system is used.
+	Hostname string
+
+	// AppName sent with the messages, such as "api-server". If value is not set, the program name is used.
+	AppName string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool
+	now    func() time.Time
+}
+
+// Write a message with informational severity.
+func (s *SyslogSink) Write(p []byte) (n int, err error) {
+	if err := s.writeExchange(string(p), 0, false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (s *SyslogSink) writeExchange(msg string, statusCode int, failed bool) error {
+	msg = strings.TrimSuffix(msg, "\n")
+
+	if msg == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+
+	m := s.format(msg, statusCode, failed)
+
+	if s.stream {
+		m = strconv.Itoa(len(m)) + " " + m
+	}
+
+	if _, err := io.WriteString(s.conn, m); err != nil {
+		// reconnect on the next message, as the server might have restarted.
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// format a RFC 5424 message.
+func (s *SyslogSink) format(msg string, statusCode int, failed bool) string {
+	now := time.Now
+
+	if s.now != nil {
+		now = s.now
+	}
+
+	facility := s.Facility
+
+	if facility == 0 {
+		facility = 1
+	}
+
+	hostname := s.Hostname
+
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	sd := "-"
+
+	if statusCode != 0 {
+		sd = fmt.Sprintf(`[%s status="%d"]`, syslogSDID, statusCode)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d - %s %s",
+		facility*8+severity(statusCode, failed),
+		now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(hostname, 255),
+		syslogHeaderField(appName(s.AppName), 48),
+		os.Getpid(),
+		sd,
+		msg)
+}
+
+// syslogHeaderField replaces values not allowed in a header field of a RFC 5424 message.
+func syslogHeaderField(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+
+		return r
+	}, v)
+
+	if v == "" {
+		return "-"
+	}
+
+	if len(v) > max {
+		v = v[:max]
+	}
+
+	return v
+}
+
+// appName is the name of the program, unless a name is given.
+func appName(name string) string {
+	if name != "" {
+		return name
+	}
+
+	return filepath.Base(os.Args[0])
+}
+
+// connect to the syslog server, if not connected yet.
+func (s *SyslogSink) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+
+	if s.Network != "" {
+		conn, err := net.Dial(s.Network, s.Addr)
+
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+		s.stream = s.Network != "udp" && s.Network != "udp4" && s.Network != "udp6" && s.Network != "unixgram"
+		return nil
+	}
+
+	// see log/syslog for the local sockets.
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				s.conn = conn
+				s.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+
+	return errors.New("cannot connect to the local syslog server")
+}
+
+// Close the connection to the syslog server.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
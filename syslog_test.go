@@ -0,0 +1,162 @@
+package httpretty
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	defer conn.Close()
+
+	sink := &SyslogSink{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Facility: 16,
+		Hostname: "example.com",
+		AppName:  "api server",
+		now: func() time.Time {
+			return time.Date(2020, 1, 2, 15, 4, 5, 6000, time.UTC)
+		},
+	}
+
+	defer sink.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+	}
+
+	logger.SetOutput(sink)
+	logger.SetFlusher(OnEnd)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	fmt.Fprint(sink, "hello\n")
+
+	want := []string{
+		fmt.Sprintf("<132>1 2020-01-02T15:04:05.000006Z example.com api_server %d - [httpretty@32473 status=\"404\"] not found\n", os.Getpid()),
+		fmt.Sprintf("<134>1 2020-01-02T15:04:05.000006Z example.com api_server %d - - hello", os.Getpid()),
+	}
+
+	buf := make([]byte, 1024)
+
+	for _, w := range want {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+
+		if err != nil {
+			t.Fatalf("cannot read message: %v", err)
+		}
+
+		if got := string(buf[:n]); got != w {
+			t.Errorf("got message %q; want %q", got, w)
+		}
+	}
+}
+
+func TestSyslogSinkStream(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	defer ln.Close()
+
+	sink := &SyslogSink{
+		Network:  "tcp",
+		Addr:     ln.Addr().String(),
+		Hostname: "example.com",
+		AppName:  "app",
+		now: func() time.Time {
+			return time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+		},
+	}
+
+	defer sink.Close()
+
+	if _, err := fmt.Fprint(sink, "first\nsecond\n"); err != nil {
+		t.Fatalf("cannot write message: %v", err)
+	}
+
+	conn, err := ln.Accept()
+
+	if err != nil {
+		t.Fatalf("cannot accept connection: %v", err)
+	}
+
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	msg := fmt.Sprintf("<14>1 2020-01-02T15:04:05.000000Z example.com app %d - - first\nsecond", os.Getpid())
+	want := fmt.Sprintf("%d %s", len(msg), msg)
+
+	got := make([]byte, len(want))
+
+	if _, err := bufio.NewReader(conn).Read(got); err != nil {
+		t.Fatalf("cannot read message: %v", err)
+	}
+
+	if string(got) != want {
+		t.Errorf("got message %q; want %q", got, want)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		statusCode int
+		failed     bool
+		want       int
+	}{
+		{0, false, severityInfo},
+		{0, true, severityError},
+		{200, false, severityInfo},
+		{304, false, severityInfo},
+		{404, false, severityWarning},
+		{503, false, severityError},
+	}
+
+	for _, tc := range testCases {
+		if got := severity(tc.statusCode, tc.failed); got != tc.want {
+			t.Errorf("severity(%d, %v) = %d; want %d", tc.statusCode, tc.failed, got, tc.want)
+		}
+	}
+}
+
+func TestSyslogHeaderField(t *testing.T) {
+	t.Parallel()
+
+	for v, want := range map[string]string{
+		"":                      "-",
+		"app":                   "app",
+		"my app":                "my_app",
+		"café":                  "caf_",
+		strings.Repeat("a", 50): strings.Repeat("a", 48),
+	} {
+		if got := syslogHeaderField(v, 48); got != want {
+			t.Errorf("syslogHeaderField(%q) = %q; want %q", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package httpretty
+
+import "net/http"
+
+// Tee combines loggers, so each exchange is printed by all of them from a single RoundTripper or Middleware wrap,
+// such as a verbose logger printing to the terminal and a redacted one writing to a file.
+// Bodies are only read from the connection once: the first logger reads them,
+// and the others print the copy it keeps in memory.
+func Tee(loggers ...*Logger) *Loggers {
+	return &Loggers{
+		loggers: loggers,
+	}
+}
+
+// Loggers print each exchange with all the loggers combined with Tee, in order.
+type Loggers struct {
+	loggers []*Logger
+}
+
+// RoundTripper returns a RoundTripper that uses all the loggers.
+func (t *Loggers) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	for i := len(t.loggers) - 1; i >= 0; i-- {
+		rt = t.loggers[i].RoundTripper(rt)
+	}
+
+	return rt
+}
+
+// Middleware for logging incoming requests to a HTTP server with all the loggers.
+func (t *Loggers) Middleware(next http.Handler) http.Handler {
+	return t.Handler(next)
+}
+
+// Handler wraps a http.Handler for logging its incoming requests with all the loggers, like Middleware.
+func (t *Loggers) Handler(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	for i := len(t.loggers) - 1; i >= 0; i-- {
+		next = t.loggers[i].Handler(next, opts...)
+	}
+
+	return next
+}
+
+// unwrapTransport returns the round tripper wrapped by other loggers, such as when using Tee.
+func unwrapTransport(rt http.RoundTripper) http.RoundTripper {
+	for {
+		r, ok := rt.(roundTripper)
+
+		if !ok {
+			return rt
+		}
+
+		if r.rt == nil {
+			return http.DefaultTransport
+		}
+
+		rt = r.rt
+	}
+}
@@ -0,0 +1,115 @@
+package httpretty
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingTee(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header()["Date"] = nil
+		w.Write(bytes.ToUpper(body))
+	}))
+	defer ts.Close()
+
+	verbose := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+		ResponseBody:    true,
+	}
+
+	summary := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+	}
+
+	var verboseBuf, summaryBuf bytes.Buffer
+	verbose.SetOutput(&verboseBuf)
+	summary.SetOutput(&summaryBuf)
+
+	client := &http.Client{
+		Transport: Tee(verbose, summary).RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("hello"))
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte("HELLO"))
+
+	if got, want := verboseBuf.String(), "hello\nHELLO\n"; got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+
+	want := `< HTTP/1.1 200 OK
+< Content-Length: 5
+< Content-Type: text/plain; charset=utf-8
+
+`
+
+	if got := summaryBuf.String(); got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestIncomingTee(t *testing.T) {
+	t.Parallel()
+
+	verbose := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+		ResponseBody:    true,
+	}
+
+	summary := &Logger{}
+
+	var verboseBuf, summaryBuf bytes.Buffer
+	verbose.SetOutput(&verboseBuf)
+	summary.SetOutput(&summaryBuf)
+
+	handler := Tee(verbose, summary).Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Write(bytes.ToUpper(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("hello"))
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Body.String(); got != "HELLO" {
+		t.Errorf("got response body %q; want %q", got, "HELLO")
+	}
+
+	if got, want := verboseBuf.String(), "hello\nHELLO\n"; got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+
+	if got, want := summaryBuf.String(), "* Request to http://example.com/\n* Request from 192.0.2.1:1234\n"; got != want {
+		t.Errorf("logged HTTP request %q; want %q", got, want)
+	}
+}
+
+func TestUnwrapTransport(t *testing.T) {
+	t.Parallel()
+
+	transport := newTransport()
+	logger := &Logger{}
+
+	if got := unwrapTransport(logger.RoundTripper(logger.RoundTripper(transport))); got != transport {
+		t.Errorf("unwrapped transport = %v; want %v", got, transport)
+	}
+
+	if got := unwrapTransport(logger.RoundTripper(nil)); got != http.DefaultTransport {
+		t.Errorf("unwrapped transport = %v; want http.DefaultTransport", got)
+	}
+}
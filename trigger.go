@@ -0,0 +1,80 @@
+package httpretty
+
+import (
+	"net"
+	"net/http"
+)
+
+// CaptureTrigger enables full logging of a request only when it carries a header or query parameter,
+// so you can capture specific requests during development, such as from a browser, without changing code.
+// Other requests only have their request line summary printed (unless SkipRequestInfo is set).
+type CaptureTrigger struct {
+	// Header that triggers the capture when present, such as "X-Httpretty".
+	Header string
+
+	// QueryParam that triggers the capture when present, such as "httpretty" (as in /path?httpretty).
+	QueryParam string
+
+	// AllowedNetworks that can trigger the capture, as IP addresses or CIDR blocks, such as "10.0.0.0/8".
+	// Invalid entries never match. If value is not set, only loopback addresses are allowed.
+	AllowedNetworks []string
+}
+
+// WithCaptureTrigger only fully logs requests carrying the header or query parameter of the trigger.
+func WithCaptureTrigger(t CaptureTrigger) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.trigger = &t
+	}
+}
+
+// triggered checks if a request triggers the capture.
+func (t *CaptureTrigger) triggered(req *http.Request) bool {
+	if t == nil {
+		return true
+	}
+
+	if !(t.Header != "" && req.Header.Get(t.Header) != "") && !t.hasQueryParam(req) {
+		return false
+	}
+
+	return t.allowed(req.RemoteAddr)
+}
+
+func (t *CaptureTrigger) hasQueryParam(req *http.Request) bool {
+	if t.QueryParam == "" || req.URL == nil {
+		return false
+	}
+
+	_, ok := req.URL.Query()[t.QueryParam]
+	return ok
+}
+
+func (t *CaptureTrigger) allowed(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return false
+	}
+
+	if len(t.AllowedNetworks) == 0 {
+		return ip.IsLoopback()
+	}
+
+	for _, n := range t.AllowedNetworks {
+		if _, network, err := net.ParseCIDR(n); err == nil && network.Contains(ip) {
+			return true
+		}
+
+		if allowed := net.ParseIP(n); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaptureTriggerTriggered(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		trigger    *CaptureTrigger
+		target     string
+		header     string
+		remoteAddr string
+		want       bool
+	}{
+		{
+			name:       "no trigger",
+			target:     "/",
+			remoteAddr: "192.0.2.1:1234",
+			want:       true,
+		},
+		{
+			name:       "header",
+			trigger:    &CaptureTrigger{Header: "X-Httpretty"},
+			target:     "/",
+			header:     "1",
+			remoteAddr: "127.0.0.1:1234",
+			want:       true,
+		},
+		{
+			name:       "query parameter",
+			trigger:    &CaptureTrigger{QueryParam: "httpretty"},
+			target:     "/?httpretty",
+			remoteAddr: "[::1]:1234",
+			want:       true,
+		},
+		{
+			name:       "missing",
+			trigger:    &CaptureTrigger{Header: "X-Httpretty", QueryParam: "httpretty"},
+			target:     "/?other",
+			remoteAddr: "127.0.0.1:1234",
+		},
+		{
+			name:       "not loopback",
+			trigger:    &CaptureTrigger{QueryParam: "httpretty"},
+			target:     "/?httpretty",
+			remoteAddr: "192.0.2.1:1234",
+		},
+		{
+			name:       "allowed network",
+			trigger:    &CaptureTrigger{QueryParam: "httpretty", AllowedNetworks: []string{"invalid", "192.0.2.0/24"}},
+			target:     "/?httpretty",
+			remoteAddr: "192.0.2.1:1234",
+			want:       true,
+		},
+		{
+			name:       "allowed address",
+			trigger:    &CaptureTrigger{QueryParam: "httpretty", AllowedNetworks: []string{"192.0.2.1"}},
+			target:     "/?httpretty",
+			remoteAddr: "192.0.2.1:1234",
+			want:       true,
+		},
+		{
+			name:       "not allowed",
+			trigger:    &CaptureTrigger{QueryParam: "httpretty", AllowedNetworks: []string{"10.0.0.0/8"}},
+			target:     "/?httpretty",
+			remoteAddr: "127.0.0.1:1234",
+		},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		req.RemoteAddr = tc.remoteAddr
+
+		if tc.header != "" {
+			req.Header.Set("X-Httpretty", tc.header)
+		}
+
+		if got := tc.trigger.triggered(req); got != tc.want {
+			t.Errorf("triggered(%s) = %v, wanted %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIncomingCaptureTrigger(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		RequestHeader:  true,
+		ResponseHeader: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	h := logger.Handler(helloHandler{}, WithCaptureTrigger(CaptureTrigger{QueryParam: "httpretty"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := buf.String(); strings.Contains(got, "> GET") {
+		t.Errorf("request without trigger was fully logged: %s", got)
+	}
+
+	buf.Reset()
+
+	req = httptest.NewRequest(http.MethodGet, "/hello?httpretty", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := buf.String(); !strings.Contains(got, "> GET /hello?httpretty HTTP/1.1") {
+		t.Errorf("request with trigger wasn't fully logged: %s", got)
+	}
+}
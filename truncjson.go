@@ -0,0 +1,174 @@
+package httpretty
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+)
+
+// truncatedJSONMarker is added to the objects and arrays left open by truncateJSON.
+const truncatedJSONMarker = `"_truncated":true`
+
+// isJSONMediatype checks if the content type is JSON, including structured syntax suffixes such as application/problem+json.
+func isJSONMediatype(contentType string) bool {
+	mediatype, _, _ := mime.ParseMediaType(contentType)
+	return mediatype == "application/json" || strings.HasSuffix(mediatype, "+json")
+}
+
+// jsonContainer is an array or object open at some point of a JSON document.
+type jsonContainer struct {
+	kind      byte // '[' or '{'
+	expectKey bool
+	n         int // number of values completed in it
+}
+
+// truncateJSON cuts the beginning of a JSON document at the end of its last complete value, and closes the arrays
+// and objects left open, so the result is valid JSON. Objects get a "_truncated": true field,
+// and arrays a {"_truncated": true} element. It returns false if there isn't any complete value to keep.
+func truncateJSON(src []byte) ([]byte, bool) {
+	var (
+		stack    []jsonContainer
+		safePos  = -1
+		safeOpen []jsonContainer
+	)
+
+	markSafe := func(pos int) {
+		safePos = pos
+		safeOpen = append(safeOpen[:0], stack...)
+	}
+
+	valueDone := func(pos int) {
+		if len(stack) != 0 {
+			stack[len(stack)-1].n++
+		}
+
+		markSafe(pos)
+	}
+
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case ' ', '\t', '\r', '\n':
+		case '{', '[':
+			stack = append(stack, jsonContainer{kind: c, expectKey: c == '{'})
+			markSafe(i + 1)
+		case '}', ']':
+			if len(stack) == 0 {
+				return nil, false
+			}
+
+			stack = stack[:len(stack)-1]
+			valueDone(i + 1)
+
+			if len(stack) == 0 {
+				return src[:i+1], false
+			}
+		case ':':
+			if len(stack) != 0 {
+				stack[len(stack)-1].expectKey = false
+			}
+		case ',':
+			if len(stack) != 0 && stack[len(stack)-1].kind == '{' {
+				stack[len(stack)-1].expectKey = true
+			}
+		case '"':
+			end := jsonStringEnd(src, i)
+
+			if end == -1 {
+				return closeJSON(src, safePos, safeOpen)
+			}
+
+			i = end
+
+			if len(stack) != 0 && stack[len(stack)-1].expectKey {
+				continue
+			}
+
+			valueDone(i + 1)
+		default:
+			// numbers and literals end on a delimiter, so one cut at the end of the input might be incomplete.
+			end := i
+
+			for end < len(src) && strings.IndexByte(" \t\r\n,]}", src[end]) == -1 {
+				end++
+			}
+
+			if end == len(src) {
+				return closeJSON(src, safePos, safeOpen)
+			}
+
+			i = end - 1
+			valueDone(end)
+		}
+	}
+
+	return closeJSON(src, safePos, safeOpen)
+}
+
+// jsonStringEnd returns the position of the quote ending the string starting at start, or -1 if it is cut.
+func jsonStringEnd(src []byte, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func closeJSON(src []byte, safePos int, open []jsonContainer) ([]byte, bool) {
+	if safePos == -1 || len(open) == 0 {
+		return nil, false
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.TrimRight(src[:safePos], " \t\r\n"))
+
+	for i := len(open) - 1; i >= 0; i-- {
+		// containers other than the innermost have the one just closed as their last value.
+		if open[i].n != 0 || i != len(open)-1 {
+			b.WriteByte(',')
+		}
+
+		if open[i].kind == '{' {
+			b.WriteString(truncatedJSONMarker + "}")
+			continue
+		}
+
+		b.WriteString("{" + truncatedJSONMarker + "}]")
+	}
+
+	return b.Bytes(), true
+}
+
+// truncateLongJSON prints the beginning of a JSON body too long to print, truncated to valid JSON,
+// returning a new body to pass along. See Logger.TruncateLongJSON.
+func (p *printer) truncateLongJSON(body io.ReadCloser, size, max int64) io.ReadCloser {
+	head := make([]byte, max)
+	n, err := io.ReadFull(body, head)
+	head = head[:n]
+	newBody := newBodyReaderBuf(bytes.NewReader(head), body)
+
+	if err != nil && err != io.ErrUnexpectedEOF {
+		p.printf("* cannot read body: %v (%d bytes read)\n", err, n)
+		return newBody
+	}
+
+	p.printTruncatedJSON(head, size, max)
+	return newBody
+}
+
+func (p *printer) printTruncatedJSON(head []byte, size, max int64) {
+	truncated, ok := truncateJSON(head)
+
+	if !ok {
+		p.printf("* body is too long (%d bytes) to print, skipping (longer than %d bytes)\n", size, max)
+		return
+	}
+
+	p.printf("* body is too long (%d bytes) to print, truncated to valid JSON (longer than %d bytes)\n", size, max)
+	p.printBody("application/json", truncated)
+}
@@ -0,0 +1,172 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTruncateJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		src  string
+		want string
+		ok   bool
+	}{
+		{src: `{"a": 1, "b": "hel`, want: `{"a": 1,"_truncated":true}`, ok: true},
+		{src: `{"a": 1, "b": 12`, want: `{"a": 1,"_truncated":true}`, ok: true},
+		{src: `{"a": 1, "b"`, want: `{"a": 1,"_truncated":true}`, ok: true},
+		{src: `{"a": 1, "b": `, want: `{"a": 1,"_truncated":true}`, ok: true},
+		{src: `[1, 2, 3`, want: `[1, 2,{"_truncated":true}]`, ok: true},
+		{src: `[1, 2, 3 `, want: `[1, 2, 3,{"_truncated":true}]`, ok: true},
+		{src: `{"list": [{"x": "a\"b"}, {"x": tr`, want: `{"list": [{"x": "a\"b"}, {"_truncated":true},{"_truncated":true}],"_truncated":true}`, ok: true},
+		{src: `{"a": {`, want: `{"a": {"_truncated":true},"_truncated":true}`, ok: true},
+		{src: `[`, want: `[{"_truncated":true}]`, ok: true},
+		{src: `"unterminated`},
+		{src: `12`},
+		{src: `{"complete": true}`},
+	}
+
+	for _, tc := range testCases {
+		got, ok := truncateJSON([]byte(tc.src))
+
+		if ok != tc.ok {
+			t.Errorf("truncateJSON(%q) ok = %v, wanted %v", tc.src, ok, tc.ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if string(got) != tc.want {
+			t.Errorf("truncateJSON(%q) = %q, wanted %q", tc.src, got, tc.want)
+		}
+
+		if !json.Valid(got) {
+			t.Errorf("truncateJSON(%q) = %q is not valid JSON", tc.src, got)
+		}
+	}
+}
+
+func TestIsJSONMediatype(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]bool{
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"application/problem+json":        true,
+		"text/plain":                      false,
+		"":                                false,
+	}
+
+	for contentType, want := range testCases {
+		if got := isJSONMediatype(contentType); got != want {
+			t.Errorf("isJSONMediatype(%q) = %v, wanted %v", contentType, got, want)
+		}
+	}
+}
+
+const longJSON = `{"items": [{"id": 1, "name": "first"}, {"id": 2, "name": "second"}, {"id": 3, "name": "third"}]}`
+
+type longJSONHandler struct{}
+
+func (h longJSONHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// written in parts, so the body only becomes too long after it starts being buffered.
+	for i := 0; i < len(longJSON); i += 20 {
+		end := i + 20
+
+		if end > len(longJSON) {
+			end = len(longJSON)
+		}
+
+		io.WriteString(w, longJSON[i:end])
+	}
+}
+
+func TestIncomingTruncateLongJSON(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo:  true,
+		ResponseBody:     true,
+		MaxResponseBody:  50,
+		TruncateLongJSON: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	w := httptest.NewRecorder()
+	logger.Middleware(longJSONHandler{}).ServeHTTP(w, req)
+
+	if got := w.Body.String(); got != longJSON {
+		t.Errorf("got body %q, wanted %q", got, longJSON)
+	}
+
+	want := fmt.Sprintf(`* body is too long (%d bytes) to print, truncated to valid JSON (longer than 50 bytes)
+{"items": [{"id": 1, "name": "first"}, {"id": 2,"_truncated":true},{"_truncated":true}],"_truncated":true}
+`, len(longJSON))
+
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestOutgoingTruncateLongJSON(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(longJSONHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo:  true,
+		RequestBody:      true,
+		ResponseBody:     true,
+		MaxRequestBody:   20,
+		MaxResponseBody:  50,
+		TruncateLongJSON: true,
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	reqBody := `{"name": "httpretty", "tags": ["http", "debug"]}`
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(reqBody))
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	testBody(t, resp.Body, []byte(longJSON))
+
+	want := fmt.Sprintf(`* body is too long (%d bytes) to print, truncated to valid JSON (longer than 20 bytes)
+{"name": "httpretty","_truncated":true}
+* body is too long (%d bytes) to print, truncated to valid JSON (longer than 50 bytes)
+{"items": [{"id": 1, "name": "first"}, {"id": 2,"_truncated":true},{"_truncated":true}],"_truncated":true}
+`, len(reqBody), len(longJSON))
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package httpretty
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"time"
+)
+
+type bodyDigest struct {
+	sum [sha256.Size]byte
+	at  time.Time
+}
+
+func responseBodyKey(req *http.Request) string {
+	host := req.Host
+
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	return req.Method + " " + host + req.URL.Path
+}
+
+// checkUnchangedBody prints a short notice instead of the body if it didn't change since it was last printed.
+func (p *printer) checkUnchangedBody(body []byte) (unchanged bool) {
+	sum := sha256.Sum256(body)
+	now := time.Now()
+
+	p.logger.mu.Lock()
+	last, ok := p.logger.bodies[p.bodyKey]
+
+	if !ok || last.sum != sum {
+		if p.logger.bodies == nil {
+			p.logger.bodies = map[string]bodyDigest{}
+		}
+
+		p.logger.bodies[p.bodyKey] = bodyDigest{sum: sum, at: now}
+	}
+
+	p.logger.mu.Unlock()
+
+	if !ok || last.sum != sum {
+		return false
+	}
+
+	p.printf("* response unchanged (same as %v ago)\n", roundElapsed(now.Sub(last.at)))
+	return true
+}
+
+func roundElapsed(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+
+	return d.Round(time.Second)
+}
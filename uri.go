@@ -0,0 +1,69 @@
+package httpretty
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// printNormalizedRequestURI prints the raw and normalized forms of the request URI when they differ.
+// Differences due to percent-encoding, dot segments, or duplicate slashes might cause routing mismatches.
+func (p *printer) printNormalizedRequestURI(req *http.Request) {
+	if req.Method == http.MethodConnect || req.URL.Opaque != "" || req.URL.Path == "" {
+		return
+	}
+
+	// RequestURI is only set on requests received by a server.
+	raw := req.RequestURI
+
+	if raw == "" || !strings.HasPrefix(raw, "/") || isRewritten(req) {
+		raw = req.URL.RequestURI()
+	}
+
+	normalized := normalizeRequestURI(req)
+
+	if raw == normalized {
+		return
+	}
+
+	p.printf("* %s\n", p.format(color.FgRed, "request URI differs from its normalized form"))
+
+	// the request line shows the parsed form, which might differ from what was received.
+	if raw != req.URL.RequestURI() {
+		p.printf("*  raw: %s\n", p.format(color.FgYellow, "%s", p.maskURI(raw)))
+	}
+
+	p.printf("*  normalized: %s\n", p.format(color.FgYellow, "%s", p.maskURI(normalized)))
+}
+
+func normalizeRequestURI(req *http.Request) string {
+	u := *req.URL
+	u.Path = cleanPath(u.Path)
+	u.RawPath = ""
+	return u.RequestURI()
+}
+
+// cleanPath removes dot segments and duplicate slashes, but keeps the trailing slash.
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// isRewritten checks if the path of a request received by a server was rewritten
+// by a router, such as when using http.StripPrefix.
+func isRewritten(req *http.Request) bool {
+	if req.RequestURI == "" || !strings.HasPrefix(req.RequestURI, "/") {
+		return false
+	}
+
+	u, err := url.ParseRequestURI(req.RequestURI)
+	return err == nil && u.Path != req.URL.Path
+}
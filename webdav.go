@@ -0,0 +1,98 @@
+package httpretty
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+)
+
+// isInformational checks if the status code is of an interim response, such as 102 Processing,
+// which is sent before the final response. 101 Switching Protocols is final.
+func isInformational(statusCode int) bool {
+	return statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols
+}
+
+// printInformational prints an interim response, such as 102 Processing sent by WebDAV servers.
+func (p *printer) printInformational(proto string, statusCode int, h http.Header) {
+	p.printResponseHeader(proto, fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)), h)
+}
+
+// traceInformational adds a trace to the context printing the interim responses received by the client.
+func (p *printer) traceInformational(ctx context.Context, proto string) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			p.printInformational(proto, code, http.Header(header))
+			p.maybeOnReady()
+			return nil
+		},
+	})
+}
+
+// maxMultiStatusSummary is the maximum number of resources listed in the summary of a 207 Multi-Status response.
+const maxMultiStatusSummary = 20
+
+// WebDAV multi-status response body.
+// See https://tools.ietf.org/html/rfc4918#section-13
+type davMultiStatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Hrefs    []string `xml:"DAV: href"`
+	Status   string   `xml:"DAV: status"`
+	Propstat []struct {
+		Status string `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+}
+
+// statuses of the resource, either for the whole resource or for each group of its properties.
+func (r davResponse) statuses() []string {
+	var statuses []string
+
+	if r.Status != "" {
+		statuses = append(statuses, davStatus(r.Status))
+	}
+
+	for _, ps := range r.Propstat {
+		statuses = append(statuses, davStatus(ps.Status))
+	}
+
+	return statuses
+}
+
+// davStatus removes the protocol from a status line, such as "HTTP/1.1 200 OK".
+func davStatus(line string) string {
+	line = strings.TrimSpace(line)
+
+	if parts := strings.SplitN(line, " ", 2); len(parts) == 2 && strings.HasPrefix(parts[0], "HTTP/") {
+		return parts[1]
+	}
+
+	return line
+}
+
+// printMultiStatus prints a summary of the status of each resource of a 207 Multi-Status response.
+func (p *printer) printMultiStatus(body []byte) {
+	var ms davMultiStatus
+
+	if err := xml.Unmarshal(body, &ms); err != nil {
+		p.printf("* cannot read multi-status response: %v\n", err)
+		return
+	}
+
+	p.printf("* multi-status: %d resources\n", len(ms.Responses))
+
+	for i, r := range ms.Responses {
+		if i == maxMultiStatusSummary {
+			p.printf("*  … %d more resources\n", len(ms.Responses)-i)
+			break
+		}
+
+		p.printf("*  %s: %s\n", strings.Join(r.Hrefs, ", "), strings.Join(r.statuses(), ", "))
+	}
+}
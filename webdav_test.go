@@ -0,0 +1,215 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const multiStatusBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:"><D:response><D:href>/files/a.txt</D:href><D:propstat><D:prop><D:getcontentlength>42</D:getcontentlength></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat><D:propstat><D:prop><D:owner/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status></D:propstat></D:response>
+<D:response><D:href>/files/b.txt</D:href><D:status>HTTP/1.1 423 Locked</D:status></D:response></D:multistatus>`
+
+type webDAVHandler struct{}
+
+func (h webDAVHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusProcessing)
+	w.Header()["Date"] = nil
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprint(w, multiStatusBody)
+}
+
+const multiStatusLog = `* multi-status: 2 resources
+*  /files/a.txt: 200 OK, 404 Not Found
+*  /files/b.txt: 423 Locked
+<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:">
+    <D:response>
+        <D:href>/files/a.txt</D:href>
+        <D:propstat>
+            <D:prop>
+                <D:getcontentlength>42</D:getcontentlength>
+            </D:prop>
+            <D:status>HTTP/1.1 200 OK</D:status>
+        </D:propstat>
+        <D:propstat>
+            <D:prop>
+                <D:owner/>
+            </D:prop>
+            <D:status>HTTP/1.1 404 Not Found</D:status>
+        </D:propstat>
+    </D:response>
+    <D:response>
+        <D:href>/files/b.txt</D:href>
+        <D:status>HTTP/1.1 423 Locked</D:status>
+    </D:response>
+</D:multistatus>
+`
+
+func TestOutgoingWebDAV(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(webDAVHandler{})
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&XMLFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	req, err := http.NewRequest("PROPFIND", ts.URL+"/files/", nil)
+
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	want := fmt.Sprintf(`< HTTP/1.1 102 Processing
+
+< HTTP/1.1 207 Multi-Status
+< Content-Length: %d
+< Content-Type: application/xml; charset=utf-8
+
+%s`, len(multiStatusBody), multiStatusLog)
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestIncomingWebDAV(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseHeader:  true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&XMLFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest("PROPFIND", "http://example.com/files/", nil)
+	logger.Middleware(webDAVHandler{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `< HTTP/1.1 102 Processing
+
+< HTTP/1.1 207 Multi-Status
+< Content-Type: application/xml; charset=utf-8
+
+` + multiStatusLog
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
+
+func TestXMLFormatter(t *testing.T) {
+	t.Parallel()
+
+	f := &XMLFormatter{}
+
+	if !f.Match("application/xml") || !f.Match("text/xml") || !f.Match("application/atom+xml") || f.Match("application/json") {
+		t.Errorf("XMLFormatter doesn't match the expected media types")
+	}
+
+	var buf bytes.Buffer
+
+	if err := f.Format(&buf, []byte(`<a x="1 &amp; 2"><!-- note --><b>text &lt;</b><c></c></a>`)); err != nil {
+		t.Errorf("XMLFormatter.Format() error = %v", err)
+	}
+
+	want := `<a x="1 &amp; 2">
+    <!-- note -->
+    <b>text &lt;</b>
+    <c/>
+</a>`
+
+	if got := buf.String(); got != want {
+		t.Errorf("XMLFormatter.Format() = %v, wanted %v", got, want)
+	}
+
+	buf.Reset()
+
+	if err := f.Format(&buf, []byte(`<a><b>`)); err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("XMLFormatter.Format() error = %v, wanted unexpected EOF", err)
+	}
+}
+
+func TestOutgoingSOAP(t *testing.T) {
+	t.Parallel()
+
+	responses := []string{
+		`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:GetPriceResponse xmlns:m="https://www.example.org/stock"><m:Price>34.5</m:Price></m:GetPriceResponse></soap:Body></soap:Envelope>`,
+		`<soap:Envelope><soap:Body>`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		w.Header()["Date"] = nil
+
+		if req.URL.Path == "/malformed" {
+			fmt.Fprint(w, responses[1])
+			return
+		}
+
+		fmt.Fprint(w, responses[0])
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&JSONFormatter{}, &XMLFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	for _, path := range []string{"/", "/malformed"} {
+		resp, err := client.Get(ts.URL + path)
+
+		if err != nil {
+			t.Fatalf("cannot connect to the server: %v", err)
+		}
+
+		resp.Body.Close()
+	}
+
+	want := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+    <soap:Body>
+        <m:GetPriceResponse xmlns:m="https://www.example.org/stock">
+            <m:Price>34.5</m:Price>
+        </m:GetPriceResponse>
+    </soap:Body>
+</soap:Envelope>
+* body cannot be formatted: XML syntax error: unexpected EOF
+<soap:Envelope><soap:Body>
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
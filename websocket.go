@@ -0,0 +1,93 @@
+package httpretty
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/henvic/httpretty/internal/color"
+)
+
+// isWebSocketUpgrade checks if the request asks to upgrade the connection to WebSocket.
+// See https://tools.ietf.org/html/rfc6455#section-4.1
+func isWebSocketUpgrade(req *http.Request) bool {
+	return containsToken(splitList(req.Header.Get("Upgrade")), "websocket", false)
+}
+
+// headerList returns the elements of a comma-separated list header, which might be sent multiple times.
+func headerList(h http.Header, key string) []string {
+	return splitList(strings.Join(h[http.CanonicalHeaderKey(key)], ","))
+}
+
+// printWebSocketNegotiation prints a summary of the subprotocol and extensions negotiated for a WebSocket upgrade.
+func (p *printer) printWebSocketNegotiation(req *http.Request, statusCode int, h http.Header) {
+	if !isWebSocketUpgrade(req) {
+		return
+	}
+
+	requestedProtocols := headerList(req.Header, "Sec-WebSocket-Protocol")
+	requestedExtensions := headerList(req.Header, "Sec-WebSocket-Extensions")
+	protocol := h.Get("Sec-WebSocket-Protocol")
+	extensions := headerList(h, "Sec-WebSocket-Extensions")
+
+	p.println("* WebSocket upgrade")
+
+	if len(requestedProtocols) != 0 {
+		p.printf("*  requested subprotocols: %s\n", strings.Join(requestedProtocols, ", "))
+	}
+
+	if protocol != "" {
+		p.printf("*  accepted subprotocol: %s\n", protocol)
+	}
+
+	if len(requestedExtensions) != 0 {
+		p.printf("*  requested extensions: %s\n", strings.Join(requestedExtensions, ", "))
+	}
+
+	if len(extensions) != 0 {
+		p.printf("*  accepted extensions: %s\n", strings.Join(extensions, ", "))
+	}
+
+	switch reason := webSocketFailure(statusCode, requestedProtocols, protocol, requestedExtensions, extensions); {
+	case reason != "":
+		p.printf("*  %s\n", p.format(color.FgRed, "failed: %s", reason))
+	case len(requestedProtocols) != 0 && protocol == "":
+		p.printf("*  %s\n", p.format(color.FgYellow, "upgraded, but the server didn't accept any of the requested subprotocols"))
+	default:
+		p.printf("*  %s\n", p.format(color.FgGreen, "upgraded"))
+	}
+}
+
+// webSocketFailure returns why a WebSocket upgrade failed, or an empty string if it succeeded.
+func webSocketFailure(statusCode int, requestedProtocols []string, protocol string, requestedExtensions, extensions []string) (reason string) {
+	if statusCode != http.StatusSwitchingProtocols {
+		return fmt.Sprintf("server responded with %d %s instead of switching protocols", statusCode, http.StatusText(statusCode))
+	}
+
+	if protocol != "" && !containsToken(requestedProtocols, protocol, false) {
+		return "server accepted subprotocol " + protocol + ", which wasn't requested"
+	}
+
+	requested := make([]string, 0, len(requestedExtensions))
+
+	for _, e := range requestedExtensions {
+		requested = append(requested, extensionName(e))
+	}
+
+	for _, e := range extensions {
+		if name := extensionName(e); !containsToken(requested, name, false) {
+			return "server accepted extension " + name + ", which wasn't requested"
+		}
+	}
+
+	return ""
+}
+
+// extensionName removes the parameters of an extension, such as in "permessage-deflate; client_max_window_bits".
+func extensionName(extension string) string {
+	if i := strings.IndexByte(extension, ';'); i != -1 {
+		extension = extension[:i]
+	}
+
+	return strings.TrimSpace(extension)
+}
@@ -0,0 +1,89 @@
+package httpretty
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebSocketFailure(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                string
+		statusCode          int
+		requestedProtocols  []string
+		protocol            string
+		requestedExtensions []string
+		extensions          []string
+		want                string
+	}{
+		{
+			name:                "upgraded",
+			statusCode:          http.StatusSwitchingProtocols,
+			requestedProtocols:  []string{"chat", "superchat"},
+			protocol:            "chat",
+			requestedExtensions: []string{"permessage-deflate; client_max_window_bits"},
+			extensions:          []string{"permessage-deflate; server_no_context_takeover"},
+		},
+		{
+			name:       "not switching protocols",
+			statusCode: http.StatusUpgradeRequired,
+			want:       "server responded with 426 Upgrade Required instead of switching protocols",
+		},
+		{
+			name:               "subprotocol not requested",
+			statusCode:         http.StatusSwitchingProtocols,
+			requestedProtocols: []string{"chat"},
+			protocol:           "mqtt",
+			want:               "server accepted subprotocol mqtt, which wasn't requested",
+		},
+		{
+			name:       "extension not requested",
+			statusCode: http.StatusSwitchingProtocols,
+			extensions: []string{"permessage-deflate"},
+			want:       "server accepted extension permessage-deflate, which wasn't requested",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := webSocketFailure(tc.statusCode, tc.requestedProtocols, tc.protocol,
+			tc.requestedExtensions, tc.extensions); got != tc.want {
+			t.Errorf("%s: webSocketFailure() = %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPrintWebSocketNegotiation(t *testing.T) {
+	t.Parallel()
+
+	logger := &Logger{}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/chat", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Add("Sec-WebSocket-Protocol", "chat")
+	req.Header.Add("Sec-WebSocket-Protocol", "superchat")
+	req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_max_window_bits")
+
+	p := newPrinter(logger)
+	p.printWebSocketNegotiation(req, http.StatusSwitchingProtocols, http.Header{
+		"Upgrade":    {"websocket"},
+		"Connection": {"Upgrade"},
+	})
+	p.flush()
+
+	want := `* WebSocket upgrade
+*  requested subprotocols: chat, superchat
+*  requested extensions: permessage-deflate; client_max_window_bits
+*  upgraded, but the server didn't accept any of the requested subprotocols
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged negotiation = %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package httpretty
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XMLFormatter helps you read XML documents, such as WebDAV responses.
+//
+// Namespace prefixes are kept as they are in the document.
+type XMLFormatter struct{}
+
+// Match XML media types.
+func (x *XMLFormatter) Match(mediatype string) bool {
+	return mediatype == "application/xml" || mediatype == "text/xml" || strings.HasSuffix(mediatype, "+xml")
+}
+
+// Format XML content.
+func (x *XMLFormatter) Format(w io.Writer, src []byte) error {
+	dst, ok := w.(*bytes.Buffer)
+	if !ok {
+		return errors.New("underlying writer for XMLFormatter must be *bytes.Buffer")
+	}
+
+	tokens, err := xmlTokens(src)
+
+	if err != nil {
+		return err
+	}
+
+	var depth int
+
+	for i := 0; i < len(tokens); i++ {
+		if dst.Len() != 0 {
+			dst.WriteByte('\n')
+		}
+
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			dst.WriteString(strings.Repeat("    ", depth))
+			dst.WriteString("<" + xmlName(t.Name))
+
+			for _, attr := range t.Attr {
+				dst.WriteString(" " + xmlName(attr.Name) + `="`)
+				xml.EscapeText(dst, []byte(attr.Value))
+				dst.WriteString(`"`)
+			}
+
+			// keep elements with no children or only text on a single line.
+			if i+1 < len(tokens) {
+				if _, ok := tokens[i+1].(xml.EndElement); ok {
+					dst.WriteString("/>")
+					i++
+					continue
+				}
+			}
+
+			if i+2 < len(tokens) {
+				text, isText := tokens[i+1].(xml.CharData)
+				end, isEnd := tokens[i+2].(xml.EndElement)
+
+				if isText && isEnd {
+					dst.WriteString(">")
+					xml.EscapeText(dst, text)
+					dst.WriteString("</" + xmlName(end.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+
+			dst.WriteString(">")
+			depth++
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+			}
+
+			dst.WriteString(strings.Repeat("    ", depth))
+			dst.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			dst.WriteString(strings.Repeat("    ", depth))
+			xml.EscapeText(dst, t)
+		case xml.Comment:
+			dst.WriteString(strings.Repeat("    ", depth))
+			dst.WriteString("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			dst.WriteString(strings.Repeat("    ", depth))
+			dst.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			dst.WriteString(strings.Repeat("    ", depth))
+			dst.WriteString("<!" + string(t) + ">")
+		}
+	}
+
+	return nil
+}
+
+// xmlTokens reads the tokens of a XML document, keeping the namespace prefixes,
+// and skipping the whitespace between elements.
+func xmlTokens(src []byte) ([]xml.Token, error) {
+	dec := xml.NewDecoder(bytes.NewReader(src))
+	var tokens []xml.Token
+	var depth int
+
+	for {
+		t, err := dec.RawToken()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tt := t.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if len(bytes.TrimSpace(tt)) == 0 {
+				continue
+			}
+
+			t = xml.CharData(bytes.TrimSpace(tt))
+		}
+
+		tokens = append(tokens, xml.CopyToken(t))
+	}
+
+	if depth != 0 {
+		return nil, errors.New("XML syntax error: unexpected EOF")
+	}
+
+	return tokens, nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
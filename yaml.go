@@ -0,0 +1,348 @@
+package httpretty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// YAMLFormatter helps you read YAML documents, such as configuration payloads.
+//
+// Block mappings and sequences are re-indented with two spaces per level.
+// Comments, quoted and flow values, and the content of literal and folded scalars are kept as they are.
+// It doesn't validate documents: it only rejects the ones it can't re-indent, with tabs in their indentation,
+// inconsistent indentation, or unterminated quoted or flow values, and prints other invalid documents,
+// such as with a plain value holding another mapping (a: b: c), re-indented as they are.
+type YAMLFormatter struct{}
+
+// Match YAML media types.
+func (y *YAMLFormatter) Match(mediatype string) bool {
+	switch mediatype {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+
+	return strings.HasSuffix(mediatype, "+yaml")
+}
+
+// Format YAML content.
+func (y *YAMLFormatter) Format(w io.Writer, src []byte) error {
+	dst, ok := w.(*bytes.Buffer)
+	if !ok {
+		return errors.New("underlying writer for YAMLFormatter must be *bytes.Buffer")
+	}
+
+	f := yamlIndenter{dst: dst}
+
+	for i, line := range strings.Split(strings.TrimRight(string(src), "\r\n"), "\n") {
+		if err := f.line(strings.TrimRight(line, " \t\r")); err != nil {
+			return fmt.Errorf("YAML syntax error on line %d: %v", i+1, err)
+		}
+	}
+
+	if f.open.unclosed() {
+		return errors.New("YAML syntax error: unexpected EOF in quoted or flow value")
+	}
+
+	return nil
+}
+
+// yamlLevel is a block collection open at some point of a YAML document.
+type yamlLevel struct {
+	indent int // in the source
+	depth  int // in the output
+
+	// indentless sequences are at the same indentation as the key holding them.
+	indentless bool
+}
+
+// yamlIndenter re-indents a YAML document line by line.
+type yamlIndenter struct {
+	dst    *bytes.Buffer
+	levels []yamlLevel
+
+	// pending is set when the last line opened a block, such as a key with no value on the same line.
+	pending       bool
+	pendingKey    bool
+	pendingIndent int
+	pendingDepth  int
+
+	// block is set while reading a literal or folded scalar.
+	block        bool
+	blockParent  int
+	blockIndent  int // of the content, or -1 before its first line
+	blockDepth   int
+	continuation int // depth of continuation lines of plain, quoted, and flow values
+
+	open yamlScanner
+}
+
+func (f *yamlIndenter) write(depth int, s string) {
+	if f.dst.Len() != 0 {
+		f.dst.WriteByte('\n')
+	}
+
+	if s != "" {
+		f.dst.WriteString(strings.Repeat("  ", depth))
+		f.dst.WriteString(s)
+	}
+}
+
+func (f *yamlIndenter) line(line string) error {
+	text := strings.TrimLeft(line, " \t")
+	indent := len(line) - len(text)
+
+	if f.block {
+		if text == "" {
+			f.write(0, "")
+			return nil
+		}
+
+		if indent > f.blockParent {
+			if f.blockIndent == -1 {
+				f.blockIndent = indent
+			}
+
+			if indent < f.blockIndent {
+				return errors.New("bad indentation in block scalar")
+			}
+
+			f.write(f.blockDepth, line[f.blockIndent:])
+			return nil
+		}
+
+		f.block = false
+	}
+
+	if f.open.unclosed() {
+		f.open.scan(text)
+		f.write(f.continuation, text)
+		return nil
+	}
+
+	if text == "" {
+		f.write(0, "")
+		return nil
+	}
+
+	if strings.Contains(line[:indent], "\t") {
+		return errors.New("found a tab character in indentation")
+	}
+
+	if indent == 0 && (text == "---" || text == "..." || strings.HasPrefix(text, "--- ") || strings.HasPrefix(text, "%")) {
+		f.levels, f.pending = nil, false
+		f.write(0, text)
+		return nil
+	}
+
+	if strings.HasPrefix(text, "#") {
+		f.write(f.commentDepth(indent), text)
+		return nil
+	}
+
+	level, err := f.level(indent, text)
+
+	if err != nil {
+		return err
+	}
+
+	if level == nil {
+		// continuation of a multi-line plain value.
+		f.write(f.continuation, text)
+		return nil
+	}
+
+	base := level.depth
+	depth := base
+	var b strings.Builder
+
+	for text == "-" || strings.HasPrefix(text, "- ") {
+		rest := strings.TrimLeft(text[1:], " ")
+		b.WriteString("- ")
+
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			b.WriteString(rest)
+			f.write(base, strings.TrimRight(b.String(), " "))
+			f.openBlock(indent, depth+1, false)
+			return nil
+		}
+
+		indent += len(text) - len(rest)
+		depth++
+		f.levels = append(f.levels, yamlLevel{indent: indent, depth: depth})
+		text = rest
+	}
+
+	b.WriteString(text)
+	f.write(base, b.String())
+	f.continuation = depth + 1
+
+	value, isKey := yamlValue(text)
+
+	switch {
+	case isKey && value == "":
+		f.openBlock(indent, depth+1, true)
+	case isBlockScalarIndicator(value):
+		f.block, f.blockParent, f.blockIndent, f.blockDepth = true, indent, -1, depth+1
+	default:
+		f.open.scan(value)
+	}
+
+	return nil
+}
+
+func (f *yamlIndenter) openBlock(indent, depth int, key bool) {
+	f.pending, f.pendingKey, f.pendingIndent, f.pendingDepth = true, key, indent, depth
+}
+
+// level finds the level of a line, or returns nil for the continuation of a plain value.
+func (f *yamlIndenter) level(indent int, text string) (*yamlLevel, error) {
+	isItem := text == "-" || strings.HasPrefix(text, "- ")
+
+	if f.pending {
+		f.pending = false
+
+		switch {
+		case indent > f.pendingIndent:
+			f.levels = append(f.levels, yamlLevel{indent: indent, depth: f.pendingDepth})
+			return &f.levels[len(f.levels)-1], nil
+		case indent == f.pendingIndent && isItem && f.pendingKey:
+			f.levels = append(f.levels, yamlLevel{indent: indent, depth: f.pendingDepth, indentless: true})
+			return &f.levels[len(f.levels)-1], nil
+		}
+	}
+
+	if len(f.levels) == 0 {
+		f.levels = append(f.levels, yamlLevel{indent: indent})
+		return &f.levels[0], nil
+	}
+
+	var dedent bool
+
+	for len(f.levels) > 1 {
+		top := f.levels[len(f.levels)-1]
+
+		if top.indent <= indent && !(top.indent == indent && top.indentless && !isItem) {
+			break
+		}
+
+		f.levels = f.levels[:len(f.levels)-1]
+		dedent = true
+	}
+
+	top := &f.levels[len(f.levels)-1]
+
+	switch {
+	case top.indent == indent:
+		return top, nil
+	case top.indent > indent, dedent:
+		// lines can only go back to the indentation of an open collection.
+		return nil, errors.New("bad indentation")
+	}
+
+	if _, isKey := yamlValue(text); isKey || isItem {
+		return nil, errors.New("bad indentation of a mapping entry or sequence item")
+	}
+
+	return nil, nil
+}
+
+func (f *yamlIndenter) commentDepth(indent int) int {
+	if f.pending && indent > f.pendingIndent {
+		return f.pendingDepth
+	}
+
+	for i := len(f.levels) - 1; i >= 0; i-- {
+		if f.levels[i].indent <= indent {
+			return f.levels[i].depth
+		}
+	}
+
+	return 0
+}
+
+// yamlValue returns the value of a mapping entry without comments, and whether the text is a mapping entry.
+// Otherwise, it returns the text itself without comments.
+func yamlValue(text string) (value string, isKey bool) {
+	var s yamlScanner
+
+	for i := 0; i < len(text); i++ {
+		if s.quote == 0 && s.flow == 0 && text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return stripYAMLComment(text[i+1:]), true
+		}
+
+		s.scanByte(text, &i)
+	}
+
+	return stripYAMLComment(text), false
+}
+
+// stripYAMLComment removes a comment at the end of a value.
+func stripYAMLComment(value string) string {
+	var s yamlScanner
+
+	for i := 0; i < len(value); i++ {
+		if s.quote == 0 && value[i] == '#' && (i == 0 || value[i-1] == ' ') {
+			return strings.TrimSpace(value[:i])
+		}
+
+		s.scanByte(value, &i)
+	}
+
+	return strings.TrimSpace(value)
+}
+
+func isBlockScalarIndicator(value string) bool {
+	if value == "" || value[0] != '|' && value[0] != '>' {
+		return false
+	}
+
+	return strings.Trim(value[1:], "+-0123456789") == ""
+}
+
+// yamlScanner keeps track of quoted and flow values spanning multiple lines.
+type yamlScanner struct {
+	quote byte
+	flow  int
+}
+
+func (s *yamlScanner) unclosed() bool {
+	return s.quote != 0 || s.flow > 0
+}
+
+// scan a value, or a line continuing it.
+func (s *yamlScanner) scan(text string) {
+	if !s.unclosed() && (text == "" || !strings.ContainsAny(text[:1], `"'[{`)) {
+		return
+	}
+
+	for i := 0; i < len(text); i++ {
+		if s.quote == 0 && text[i] == '#' && (i == 0 || text[i-1] == ' ') {
+			return
+		}
+
+		s.scanByte(text, &i)
+	}
+}
+
+func (s *yamlScanner) scanByte(text string, i *int) {
+	c := text[*i]
+
+	switch {
+	case s.quote == '"' && c == '\\':
+		*i++
+	case s.quote == '\'' && c == '\'' && *i+1 < len(text) && text[*i+1] == '\'':
+		*i++
+	case s.quote != 0 && c == s.quote:
+		s.quote = 0
+	case s.quote != 0:
+	case (c == '"' || c == '\'') && (*i == 0 || strings.IndexByte(" [{,:", text[*i-1]) != -1):
+		s.quote = c
+	case c == '[' || c == '{':
+		s.flow++
+	case (c == ']' || c == '}') && s.flow > 0:
+		s.flow--
+	}
+}
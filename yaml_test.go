@@ -0,0 +1,187 @@
+package httpretty
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestYAMLFormatterMatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]bool{
+		"application/yaml":      true,
+		"application/x-yaml":    true,
+		"text/yaml":             true,
+		"text/x-yaml":           true,
+		"application/ld+yaml":   true,
+		"application/json":      false,
+		"text/plain":            false,
+		"application/yaml-like": false,
+	}
+
+	var y YAMLFormatter
+
+	for mediatype, want := range testCases {
+		if got := y.Match(mediatype); got != want {
+			t.Errorf("Match(%q) = %v, wanted %v", mediatype, got, want)
+		}
+	}
+}
+
+func TestYAMLFormatter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "nested",
+			src: `service:
+    name: api   # public name
+    ports:
+        - 80
+        - 443
+`,
+			want: `service:
+  name: api   # public name
+  ports:
+    - 80
+    - 443`,
+		},
+		{
+			name: "indentless sequence",
+			src: `hosts:
+- name: a
+  port: 1
+- name: b
+  port: 2
+region: us`,
+			want: `hosts:
+  - name: a
+    port: 1
+  - name: b
+    port: 2
+region: us`,
+		},
+		{
+			name: "block scalar",
+			src: `script: |
+      echo start
+        indented
+      echo done
+next: "a # not a comment"`,
+			want: `script: |
+  echo start
+    indented
+  echo done
+next: "a # not a comment"`,
+		},
+		{
+			name: "multi-line flow and plain values",
+			src: `list: [a,
+     b]
+text: some long
+   plain text
+---
+- -   x
+  -   y`,
+			want: `list: [a,
+  b]
+text: some long
+  plain text
+---
+- - x
+  - y`,
+		},		{
+			name: "not validated",
+			src:  "a:\n    b: c: d",
+			want: "a:\n  b: c: d",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		var y YAMLFormatter
+
+		if err := y.Format(&buf, []byte(tc.src)); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: got %s, wanted %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestYAMLFormatterInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"a:\n\tb: 1":           "YAML syntax error on line 2: found a tab character in indentation",
+		"a:\n    b: 1\n  c: 2": "YAML syntax error on line 3: bad indentation",
+		"a: 1\n  b: 2":         "YAML syntax error on line 2: bad indentation of a mapping entry or sequence item",
+		`a: "unterminated`:     "YAML syntax error: unexpected EOF in quoted or flow value",
+	}
+
+	for src, want := range testCases {
+		var buf bytes.Buffer
+		var y YAMLFormatter
+
+		if err := y.Format(&buf, []byte(src)); err == nil || err.Error() != want {
+			t.Errorf("Format(%q) error = %v, wanted %v", src, err, want)
+		}
+	}
+}
+
+func TestOutgoingYAML(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		fmt.Fprint(w, "version: 3\nfeatures:\n    beta: true\n")
+	}))
+	defer ts.Close()
+
+	logger := &Logger{
+		SkipRequestInfo: true,
+		RequestBody:     true,
+		ResponseBody:    true,
+		Formatters:      []Formatter{&YAMLFormatter{}},
+	}
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	client := &http.Client{
+		Transport: logger.RoundTripper(newTransport()),
+	}
+
+	resp, err := client.Post(ts.URL, "text/yaml", strings.NewReader("name: test\nlimits:\n\tcpu: 2\n"))
+
+	if err != nil {
+		t.Fatalf("cannot connect to the server: %v", err)
+	}
+
+	resp.Body.Close()
+
+	want := `* body cannot be formatted: YAML syntax error on line 3: found a tab character in indentation
+name: test
+limits:
+	cpu: 2
+
+version: 3
+features:
+  beta: true
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("logged HTTP request %s; want %s", got, want)
+	}
+}
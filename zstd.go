@@ -0,0 +1,24 @@
+//go:build zstd
+// +build zstd
+
+package httpretty
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Zstandard decoding is optional to avoid adding a dependency to everyone.
+// Build with -tags zstd to enable it.
+func init() {
+	contentDecoders["zstd"] = func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return d.IOReadCloser(), nil
+	}
+}
@@ -0,0 +1,32 @@
+//go:build zstd
+// +build zstd
+
+package httpretty
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecodeContentZstd(t *testing.T) {
+	t.Parallel()
+
+	enc, err := zstd.NewWriter(nil)
+
+	if err != nil {
+		t.Fatalf("cannot create zstd encoder: %v", err)
+	}
+
+	body := enc.EncodeAll([]byte("Hello, zstd!"), nil)
+
+	got, err := decodeContent("zstd", body)
+
+	if err != nil {
+		t.Errorf("decodeContent(zstd) error = %v", err)
+	}
+
+	if want := "Hello, zstd!"; string(got) != want {
+		t.Errorf("decodeContent(zstd) = %q, wanted %q", got, want)
+	}
+}